@@ -71,11 +71,12 @@ func generateFakeData(s *sqlite.Storage, cfg config) {
 		fmt.Printf("openIssues: %d, closedIssues: %d\n", openIssues, closedIssues)
 		allIssues := openIssues + closedIssues
 
+		// the entities are the ones of the legacy GitLab instance
 		var err error
 		if cfg.projectID != 0 {
-			err = s.AddProjectStats(int64(cfg.projectID), int64(openIssues), int64(closedIssues), int64(allIssues), dbegin)
+			err = s.AddProjectStats(0, int64(cfg.projectID), int64(openIssues), int64(closedIssues), int64(allIssues), dbegin)
 		} else {
-			err = s.AddGroupStats(int64(cfg.groupID), int64(openIssues), int64(closedIssues), int64(allIssues), dbegin)
+			err = s.AddGroupStats(0, int64(cfg.groupID), int64(openIssues), int64(closedIssues), int64(allIssues), dbegin)
 		}
 		if err != nil {
 			fmt.Println(err.Error())
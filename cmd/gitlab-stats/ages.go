@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/golang-module/carbon/v2"
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+	"github.com/sgaunet/gitlab-stats/pkg/graphissues"
+	"github.com/sgaunet/gitlab-stats/pkg/storage/sqlite"
+	"github.com/sirupsen/logrus"
+)
+
+// openIssueAges counts the open issues of the entity of cfg per age. The ages
+// complete the snapshot: on failure, a warning is logged and the snapshot is
+// stored without them.
+func openIssueAges(ctx context.Context, f gitlab.StatisticsFetcher, cfg config) *sqlite.OpenIssueAges {
+	ages, err := gitlab.OpenIssueAges(ctx, f, scopeOf(cfg), cfg.filter, time.Now())
+	if err != nil {
+		logrus.Warnf("age of the open issues of %s not recorded: %v", describeEntity(cfg), err)
+		return nil
+	}
+	return &sqlite.OpenIssueAges{
+		UnderWeek:      int64(ages.UnderWeek),
+		WeekToMonth:    int64(ages.WeekToMonth),
+		MonthToQuarter: int64(ages.MonthToQuarter),
+		QuarterToYear:  int64(ages.QuarterToYear),
+		OverYear:       int64(ages.OverYear),
+	}
+}
+
+func generateOpenIssueAgesGraph(s *sqlite.Storage, cfg config) {
+	begindate := carbon.CreateFromStdTime(s.Now()).AddMonths(-cfg.sinceMonth).StartOfMonth()
+	enddate := carbon.CreateFromStdTime(s.Now()).StartOfMonth()
+
+	logrus.Infoln("retrieve open issue ages from database")
+	entityType, key := agesEntity(cfg)
+	ages, err := s.GetOpenIssueAges(context.Background(), entityType, key, begindate, enddate)
+	if err != nil {
+		logrus.Errorln("error when retrieving open issue ages: ", err.Error())
+		os.Exit(exitError)
+	}
+	if len(ages.DateExecSeries) == 0 {
+		logrus.Errorf("No open issue age found in database for %s. Please collect statistics first before generating a chart.",
+			describeEntity(cfg))
+		os.Exit(exitError)
+	}
+
+	err = graphissues.CreateOpenIssueAgesGraph(
+		cfg.graphFilePath,
+		ages.UnderWeek,
+		ages.WeekToMonth,
+		ages.MonthToQuarter,
+		ages.QuarterToYear,
+		ages.OverYear,
+		ages.DateExecSeries,
+	)
+	if err != nil {
+		logrus.Errorln("error when creating open issue ages graph: ", err.Error())
+		os.Exit(exitError)
+	}
+}
+
+// agesEntity returns the entity type and the key under which the ages of the
+// open issues of cfg are stored: the series if any, else its entity.
+func agesEntity(cfg config) (string, int64) {
+	if cfg.seriesName != "" {
+		return sqlite.EntitySeries, cfg.seriesID
+	}
+	return seriesEntity(cfg)
+}
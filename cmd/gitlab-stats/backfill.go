@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sgaunet/gitlab-stats/pkg/backfill"
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+	"github.com/sgaunet/gitlab-stats/pkg/storage/sqlite"
+	"github.com/sirupsen/logrus"
+)
+
+// parseDayRange sets the days of -from and -to, left zero when not given.
+// With -backfill, -to is yesterday by default: the snapshot of today is
+// collected by the run of the day.
+func parseDayRange(cfg *config, from string, to string) error {
+	first, err := parseDateFlag("from", from)
+	if err != nil {
+		return err
+	}
+	last, err := parseDateFlag("to", to)
+	if err != nil {
+		return err
+	}
+	switch {
+	case last != nil:
+		cfg.toDay = localDay(*last)
+	case cfg.backfill:
+		cfg.toDay = localDay(time.Now()).AddDate(0, 0, -1)
+	}
+	if first != nil {
+		cfg.fromDay = localDay(*first)
+	}
+	return nil
+}
+
+// localDay returns the start of the local day of the date, the day of a date
+// given as YYYY-MM-DD.
+func localDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// backfillIssues rebuilds the daily snapshots of the project or group from
+// the creation and closing dates of its issues. They are stored as
+// reconstructed snapshots, replacing those of a previous backfill of the same
+// days; the days which have live statistics are kept.
+func backfillIssues(ctx context.Context, s *sqlite.Storage, l gitlab.IssueLister, cfg config) {
+	// the issues created after the window do not change its snapshots
+	issues, err := l.Issues(ctx, scopeOf(cfg), cfg.toDay.AddDate(0, 0, 1))
+	if err != nil {
+		exitOnCanceled(ctx)
+		exitOnAPIError(err, describeEntity(cfg))
+	}
+	snapshots := backfill.Reconstruct(issues, cfg.fromDay, cfg.toDay)
+	reconstructed := make([]sqlite.Snapshot, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		reconstructed = append(reconstructed, sqlite.Snapshot{
+			Opened:   int64(snapshot.Opened),
+			Closed:   int64(snapshot.Closed),
+			Total:    int64(snapshot.Total),
+			DateExec: snapshot.Date,
+		})
+	}
+
+	entityType, key := sqlite.EntityGroup, cfg.groupKey
+	if cfg.projectID != 0 {
+		entityType, key = sqlite.EntityProject, cfg.projectKey
+	}
+	added, err := s.AddReconstructedStats(ctx, entityType, key, reconstructed)
+	if err != nil {
+		logrus.Errorln(err.Error())
+		os.Exit(exitError)
+	}
+	fmt.Printf("%d daily snapshots of %s reconstructed from %d issues, %d days with live statistics kept\n",
+		added, describeEntity(cfg), len(issues), len(snapshots)-added)
+}
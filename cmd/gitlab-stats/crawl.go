@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/golang-module/carbon/v2"
+	"github.com/sgaunet/gitlab-stats/pkg/collector"
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+	"github.com/sgaunet/gitlab-stats/pkg/storage/sqlite"
+	"github.com/sirupsen/logrus"
+)
+
+// crawlGroup collects the statistics of a group and of every project of the
+// group and its subgroups. The membership of each project is recorded.
+// Projects are collected concurrently and a failing project does not stop the
+// others: a summary is printed at the end and the exit code is exitError if
+// any entity failed.
+func crawlGroup(ctx context.Context, s *sqlite.Storage, f gitlab.Fetcher, cfg config) {
+	projects, err := f.GroupProjects(ctx, cfg.groupID)
+	if err != nil {
+		exitOnCanceled(ctx)
+		exitOnAPIError(err, describeEntity(cfg))
+	}
+	logrus.Infof("%d projects found in %s", len(projects), describeEntity(cfg))
+
+	newJob := issueJob
+	if cfg.mergeRequests {
+		newJob = mergeRequestJob
+	}
+	jobs := make([]collector.Job, 0, len(projects)+1)
+	jobs = append(jobs, newJob(f, s, cfg))
+	dateExec := carbon.Now()
+	for _, project := range projects {
+		member, err := s.SaveProject(ctx, sqlite.Project{
+			ID:         int64(project.ID),
+			FullPath:   project.PathWithNamespace,
+			Name:       project.Name,
+			InstanceID: cfg.instanceID,
+		})
+		if err != nil {
+			logrus.Errorln(err.Error())
+			os.Exit(exitError)
+		}
+		projectCfg := cfg
+		projectCfg.groupID = 0
+		projectCfg.groupKey = 0
+		projectCfg.groupPath = ""
+		setProject(&projectCfg, member)
+		projectCfg.projectPath = project.PathWithNamespace
+		job := newJob(f, s, projectCfg)
+		jobs = append(jobs, withMembership(job, s, cfg.groupKey, member, dateExec))
+	}
+
+	summary := collector.New(cfg.workers).Run(ctx, jobs)
+	printSummary(summary, cfg.cache)
+	if len(summary.Failed()) > 0 {
+		os.Exit(exitError)
+	}
+}
+
+// withMembership records the membership of the project in the group before storing its statistics.
+func withMembership(
+	job collector.Job,
+	s *sqlite.Storage,
+	groupKey int64,
+	member sqlite.Project,
+	dateExec *carbon.Carbon,
+) collector.Job {
+	fetch := job.Fetch
+	job.Fetch = func(ctx context.Context) (collector.Store, error) {
+		store, err := fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) error {
+			if err := s.AddGroupProject(ctx, groupKey, member, dateExec); err != nil {
+				return err
+			}
+			return store(ctx)
+		}, nil
+	}
+	return job
+}
+
+// printSummary prints the number of collected entities, the reason of each
+// failure and, when the cache is enabled, the number of unchanged responses.
+func printSummary(summary collector.Summary, cache *gitlab.Cache) {
+	fmt.Printf("%d/%d entities collected\n", summary.Succeeded(), len(summary.Results))
+	if cache != nil {
+		fmt.Printf("%d API responses unchanged and served from the cache, %d downloaded\n", cache.Hits(), cache.Misses())
+	}
+	for _, r := range summary.Failed() {
+		_, msg := describeAPIError(r.Err, r.Name)
+		fmt.Printf("  %s: %s\n", r.Name, msg)
+	}
+}
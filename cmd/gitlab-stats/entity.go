@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+	"github.com/sgaunet/gitlab-stats/pkg/provider"
+	"github.com/sgaunet/gitlab-stats/pkg/storage/sqlite"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	errEmptyPath  = errors.New("empty path")
+	errInvalidID  = errors.New("invalid ID, IDs are positive")
+	errDigitsPath = errors.New("path made only of digits, which the API reads as an ID: give the ID instead")
+)
+
+// entityFlag is the value of -p and -g: a numeric ID or a full path such as "namespace/subgroup/project".
+// A value made only of digits is an ID. A path made only of digits, such as a
+// top-level group "2024", cannot be given: the API also reads it as an ID.
+type entityFlag struct {
+	id   *int
+	path *string
+}
+
+func (f entityFlag) String() string {
+	if f.path != nil && *f.path != "" {
+		return *f.path
+	}
+	if f.id != nil && *f.id != 0 {
+		return strconv.Itoa(*f.id)
+	}
+	return ""
+}
+
+func (f entityFlag) Set(value string) error {
+	if id, err := strconv.Atoi(value); err == nil {
+		if id <= 0 {
+			return errInvalidID
+		}
+		*f.id = id
+		*f.path = ""
+		return nil
+	}
+	path := strings.Trim(strings.TrimSpace(value), "/")
+	if path == "" {
+		return errEmptyPath
+	}
+	if _, err := strconv.Atoi(path); err == nil {
+		return errDigitsPath
+	}
+	*f.id = 0
+	*f.path = path
+	return nil
+}
+
+// hasProject reports whether a project was given with -p.
+func (c config) hasProject() bool {
+	return c.projectID != 0 || c.projectPath != ""
+}
+
+// hasGroup reports whether a group was given with -g.
+func (c config) hasGroup() bool {
+	return c.groupID != 0 || c.groupPath != ""
+}
+
+// resolveEntities sets the IDs and the keys of the project and group given
+// with -p and -g. In collection mode, paths are resolved with the API and the
+// ID, path and name are cached in the database. Graphs use the cache first,
+// so they work offline for entities which were already collected. On other
+// forges than GitLab, entities given by ID are resolved too, to record their
+// path and name. The keys identify the entities of the instance in the
+// database: the same ID on another instance is another entity.
+func resolveEntities(ctx context.Context, s *sqlite.Storage, meta gitlab.MetadataFetcher, cfg *config) {
+	resolveIDs := cfg.provider != provider.GitLab && cfg.graphFilePath == ""
+	switch {
+	case cfg.projectPath != "" || (resolveIDs && cfg.projectID != 0):
+		setProject(cfg, resolveProject(ctx, s, meta, *cfg))
+		logrus.Infof("project %s resolved to ID %d", cfg.projectPath, cfg.projectID)
+	case cfg.projectID != 0:
+		cfg.projectKey = projectKey(ctx, s, *cfg)
+	}
+	switch {
+	case cfg.groupPath != "" || (resolveIDs && cfg.groupID != 0):
+		setGroup(cfg, resolveGroup(ctx, s, meta, *cfg))
+		logrus.Infof("group %s resolved to ID %d", cfg.groupPath, cfg.groupID)
+	case cfg.groupID != 0:
+		cfg.groupKey = groupKey(ctx, s, *cfg)
+	}
+}
+
+func setProject(cfg *config, project sqlite.Project) {
+	cfg.projectID = int(project.ID)
+	cfg.projectKey = project.Key
+}
+
+func setGroup(cfg *config, group sqlite.Group) {
+	cfg.groupID = int(group.ID)
+	cfg.groupKey = group.Key
+}
+
+// projectKey returns the key of the project given by its ID. Graphs do not
+// create the project: its key is 0 when it was never collected.
+func projectKey(ctx context.Context, s *sqlite.Storage, cfg config) int64 {
+	if cfg.graphFilePath != "" {
+		project, err := s.GetProject(ctx, cfg.instanceID, int64(cfg.projectID))
+		if err != nil && !errors.Is(err, sqlite.ErrProjectNotFound) {
+			logrus.Errorln(err.Error())
+			os.Exit(exitError)
+		}
+		return project.Key
+	}
+	key, err := s.ProjectKey(ctx, cfg.instanceID, int64(cfg.projectID))
+	if err != nil {
+		logrus.Errorln(err.Error())
+		os.Exit(exitError)
+	}
+	return key
+}
+
+// groupKey returns the key of the group given by its ID, see projectKey.
+func groupKey(ctx context.Context, s *sqlite.Storage, cfg config) int64 {
+	if cfg.graphFilePath != "" {
+		group, err := s.GetGroup(ctx, cfg.instanceID, int64(cfg.groupID))
+		if err != nil && !errors.Is(err, sqlite.ErrGroupNotFound) {
+			logrus.Errorln(err.Error())
+			os.Exit(exitError)
+		}
+		return group.Key
+	}
+	key, err := s.GroupKey(ctx, cfg.instanceID, int64(cfg.groupID))
+	if err != nil {
+		logrus.Errorln(err.Error())
+		os.Exit(exitError)
+	}
+	return key
+}
+
+func resolveProject(ctx context.Context, s *sqlite.Storage, meta gitlab.MetadataFetcher, cfg config) sqlite.Project {
+	if cfg.graphFilePath != "" {
+		project, err := s.GetProjectByPath(ctx, cfg.instanceID, cfg.projectPath)
+		if err == nil {
+			return project
+		}
+		if !errors.Is(err, sqlite.ErrProjectNotFound) {
+			logrus.Errorln(err.Error())
+			os.Exit(exitError)
+		}
+	}
+	idOrPath := cfg.projectPath
+	if idOrPath == "" {
+		idOrPath = strconv.Itoa(cfg.projectID)
+	}
+	project, err := meta.Project(ctx, idOrPath)
+	if err != nil {
+		exitOnCanceled(ctx)
+		exitOnAPIError(err, describeEntity(cfg))
+	}
+	saved, err := s.SaveProject(ctx, sqlite.Project{
+		ID:         int64(project.ID),
+		FullPath:   project.PathWithNamespace,
+		Name:       project.Name,
+		InstanceID: cfg.instanceID,
+	})
+	if err != nil {
+		logrus.Errorln(err.Error())
+		os.Exit(exitError)
+	}
+	return saved
+}
+
+func resolveGroup(ctx context.Context, s *sqlite.Storage, meta gitlab.MetadataFetcher, cfg config) sqlite.Group {
+	if cfg.graphFilePath != "" {
+		group, err := s.GetGroupByPath(ctx, cfg.instanceID, cfg.groupPath)
+		if err == nil {
+			return group
+		}
+		if !errors.Is(err, sqlite.ErrGroupNotFound) {
+			logrus.Errorln(err.Error())
+			os.Exit(exitError)
+		}
+	}
+	idOrPath := cfg.groupPath
+	if idOrPath == "" {
+		idOrPath = strconv.Itoa(cfg.groupID)
+	}
+	group, err := meta.Group(ctx, idOrPath)
+	if err != nil {
+		exitOnCanceled(ctx)
+		exitOnAPIError(err, describeEntity(cfg))
+	}
+	saved, err := s.SaveGroup(ctx, sqlite.Group{
+		ID:         int64(group.ID),
+		FullPath:   group.FullPath,
+		Name:       group.Name,
+		InstanceID: cfg.instanceID,
+	})
+	if err != nil {
+		logrus.Errorln(err.Error())
+		os.Exit(exitError)
+	}
+	return saved
+}
+
+// describeEntity returns a human readable name of the project, group or series to collect.
+func describeEntity(cfg config) string {
+	switch {
+	case cfg.seriesName != "":
+		return fmt.Sprintf("series %q", cfg.seriesName)
+	case cfg.instance:
+		return "instance " + cfg.baseURL
+	case cfg.projectPath != "":
+		return "project " + cfg.projectPath
+	case cfg.projectID != 0:
+		return fmt.Sprintf("project %d", cfg.projectID)
+	case cfg.groupPath != "":
+		return "group " + cfg.groupPath
+	default:
+		return fmt.Sprintf("group %d", cfg.groupID)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+	"github.com/sirupsen/logrus"
+)
+
+// Exit codes of the CLI. exitUsage is also used by the flag package on
+// unknown options.
+const (
+	exitError        = 1
+	exitUsage        = 2
+	exitUnauthorized = 3
+	exitForbidden    = 4
+	exitNotFound     = 5
+	exitRateLimited  = 6
+	exitServerError  = 7
+)
+
+// exitOnAPIError logs an actionable message for a GitLab API error and exits
+// with a code specific to the kind of error. entity describes the requested
+// resource, for instance "group 1234".
+func exitOnAPIError(err error, entity string) {
+	code, msg := describeAPIError(err, entity)
+	logrus.Errorln(msg)
+	os.Exit(code)
+}
+
+func describeAPIError(err error, entity string) (int, string) {
+	var apiErr *gitlab.APIError
+	if !errors.As(err, &apiErr) {
+		return exitError, err.Error()
+	}
+	switch {
+	case errors.Is(err, gitlab.ErrUnauthorized):
+		return exitUnauthorized, "GitLab rejected the token: check that it is valid and not expired (run with -d info to log where it was read from)"
+	case apiErr.InsufficientScope():
+		return exitForbidden, "token lacks read_api scope: create a token with the read_api (or api) scope"
+	case errors.Is(err, gitlab.ErrForbidden):
+		return exitForbidden, fmt.Sprintf("access to %s is forbidden for this token: %s", entity, apiErr.Message)
+	case errors.Is(err, gitlab.ErrNotFound):
+		return exitNotFound, entity + " not found or not visible with this token"
+	case errors.Is(err, gitlab.ErrRateLimited):
+		return exitRateLimited, "rate limit still exceeded after retries: try again later or raise -retries"
+	case errors.Is(err, gitlab.ErrServerError):
+		return exitServerError, fmt.Sprintf("GitLab server error (%d): try again later", apiErr.StatusCode)
+	default:
+		return exitError, err.Error()
+	}
+}
@@ -2,103 +2,57 @@
 package main
 
 import (
-	"encoding/json"
-	"errors"
-	"fmt"
-	"io"
+	"context"
+	"net/url"
 	"os"
-	"path/filepath"
-	"strings"
 
 	log "github.com/sirupsen/logrus"
-	"gopkg.in/ini.v1"
 
 	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+	"github.com/sgaunet/gitlab-stats/pkg/gitremote"
+	"github.com/sgaunet/gitlab-stats/pkg/storage/sqlite"
 )
 
-var (
-	// ErrProjectNotFound is returned when a project cannot be found.
-	ErrProjectNotFound = errors.New("project not found")
-	// ErrGitNotFound is returned when .git directory is not found.
-	ErrGitNotFound     = errors.New(".git not found")
-)
-
-type project struct {
-	ID           int    `json:"id"`
-	Name         string `json:"name"`
-	SSHURLToRepo string `json:"ssh_url_to_repo"`
-	HTTPURLToRepo string `json:"http_url_to_repo"`
-}
-
-func findProject(remoteOrigin string) (project, error) {
-	projectName := filepath.Base(remoteOrigin)
-	projectName = strings.ReplaceAll(projectName, ".git", "")
-	log.Infof("Try to find project %s in %s\n", projectName, os.Getenv("GITLAB_URI"))
-
-	gs := gitlab.NewService()
-	gs.SetGitlabEndpoint(os.Getenv("GITLAB_URI"))
+// detectProjectIfNeeded sets the project from a remote of the git repository
+// of the working directory when no project, group or instance is given.
+// The namespace path of the remote is looked up directly with the GitLab API.
+func detectProjectIfNeeded(ctx context.Context, s *sqlite.Storage, meta gitlab.MetadataFetcher, cfg *config) {
+	if cfg.hasGroup() || cfg.hasProject() || cfg.instance {
+		return
+	}
 
-	resp, err := gs.Get("search?scope=projects&search=" + projectName)
+	cwd, err := os.Getwd()
 	if err != nil {
-		return project{}, fmt.Errorf("failed to search for project: %w", err)
+		log.Errorln(err.Error())
+		os.Exit(exitError)
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Printf("Error closing response body: %v", err)
-		}
-	}()
-	res, err := io.ReadAll(resp.Body)
+	repo, err := gitremote.FindRepository(cwd)
 	if err != nil {
-		return project{}, fmt.Errorf("failed to read response body: %w", err)
+		log.Errorf("no -p or -g option given and %v", err)
+		os.Exit(exitError)
 	}
-
-	var p []project
-	err = json.Unmarshal(res, &p)
+	remoteURL, err := repo.RemoteURL(cfg.remote)
 	if err != nil {
-		return project{}, fmt.Errorf("failed to unmarshal JSON: %w", err)
-	}
-
-	for _, project := range p {
-		log.Debugln(project.Name)
-		log.Debugln(project.ID)
-		log.Debugln(project.HTTPURLToRepo)
-		log.Debugln(project.SSHURLToRepo)
-
-		if project.SSHURLToRepo == remoteOrigin {
-			return project, nil
-		}
+		log.Errorf("%v: choose the remote with -remote", err)
+		os.Exit(exitError)
 	}
-	return project{}, ErrProjectNotFound
-}
-
-func findGitRepository() (string, error) {
-	cwd, err := os.Getwd()
+	remote, err := gitremote.Parse(remoteURL)
 	if err != nil {
-		return "", fmt.Errorf("failed to get working directory: %w", err)
+		log.Errorln(err.Error())
+		os.Exit(exitError)
 	}
 
-	for cwd != "/" {
-		log.Debugln(cwd)
-		stat, err := os.Stat(cwd + string(os.PathSeparator) + ".git")
-		if err == nil {
-			if stat.IsDir() {
-				return cwd, nil
-			}
+	baseURL, err := url.Parse(cfg.baseURL)
+	if err == nil {
+		remote = remote.TrimBasePath(baseURL.Path)
+		if baseURL.Hostname() != remote.Host {
+			log.Warnf("remote %s is on %s but the %s instance is %s", cfg.remote, remote.Host, cfg.provider, baseURL)
 		}
-		cwd = filepath.Dir(cwd)
-	}
-	return "", ErrGitNotFound
-}
-
-// GetRemoteOrigin extracts the remote origin URL from git config file.
-func GetRemoteOrigin(gitConfigFile string) string {
-	cfg, err := ini.Load(gitConfigFile)
-	if err != nil {
-		log.Errorf("Fail to read file: %v", err)
-		os.Exit(1)
 	}
+	log.Infof("Try to find project %s of remote %s in %s", remote.Path, cfg.remote, cfg.baseURL)
 
-	url := cfg.Section("remote \"origin\"").Key("url").String()
-	log.Debugln("url:", url)
-	return url
+	cfg.projectPath = remote.Path
+	setProject(cfg, resolveProject(ctx, s, meta, *cfg))
+	log.Infoln("Project found: ", cfg.projectPath)
+	log.Infoln("Project found: ", cfg.projectID)
 }
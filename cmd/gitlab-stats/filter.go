@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+// filterOptions holds the raw values of the issue filter flags.
+type filterOptions struct {
+	labels           string
+	milestone        string
+	authorID         int
+	authorUsername   string
+	assigneeID       string
+	assigneeUsername string
+	search           string
+	createdAfter     string
+	createdBefore    string
+	updatedAfter     string
+	updatedBefore    string
+	confidential     string
+}
+
+func registerFilterFlags(fo *filterOptions) {
+	flag.StringVar(&fo.labels, "labels", "", "only count issues having all these comma separated labels")
+	flag.StringVar(&fo.milestone, "milestone", "", "only count issues of this milestone title (None, Any)")
+	flag.IntVar(&fo.authorID, "author-id", 0, "only count issues created by this user ID")
+	flag.StringVar(&fo.authorUsername, "author", "", "only count issues created by this username")
+	flag.StringVar(&fo.assigneeID, "assignee-id", "", "only count issues assigned to this user ID (None, Any)")
+	flag.StringVar(&fo.assigneeUsername, "assignee", "", "only count issues assigned to this username")
+	flag.StringVar(&fo.search, "search", "", "only count issues whose title or description match")
+	flag.StringVar(&fo.createdAfter, "created-after", "", "only count issues created after this date (YYYY-MM-DD or RFC3339)")
+	flag.StringVar(&fo.createdBefore, "created-before", "", "only count issues created before this date (YYYY-MM-DD or RFC3339)")
+	flag.StringVar(&fo.updatedAfter, "updated-after", "", "only count issues updated after this date (YYYY-MM-DD or RFC3339)")
+	flag.StringVar(&fo.updatedBefore, "updated-before", "", "only count issues updated before this date (YYYY-MM-DD or RFC3339)")
+	flag.StringVar(&fo.confidential, "confidential", "", "only count confidential (true) or public (false) issues")
+}
+
+// issueFilter converts the flag values to a validated GitLab issue filter.
+func (fo filterOptions) issueFilter() (gitlab.IssueFilter, error) {
+	f := gitlab.IssueFilter{
+		Milestone:        fo.milestone,
+		AuthorID:         fo.authorID,
+		AuthorUsername:   fo.authorUsername,
+		AssigneeID:       fo.assigneeID,
+		AssigneeUsername: fo.assigneeUsername,
+		Search:           fo.search,
+	}
+	for label := range strings.SplitSeq(fo.labels, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			f.Labels = append(f.Labels, label)
+		}
+	}
+	var err error
+	if f.CreatedAfter, err = parseDateFlag("created-after", fo.createdAfter); err != nil {
+		return f, err
+	}
+	if f.CreatedBefore, err = parseDateFlag("created-before", fo.createdBefore); err != nil {
+		return f, err
+	}
+	if f.UpdatedAfter, err = parseDateFlag("updated-after", fo.updatedAfter); err != nil {
+		return f, err
+	}
+	if f.UpdatedBefore, err = parseDateFlag("updated-before", fo.updatedBefore); err != nil {
+		return f, err
+	}
+	if fo.confidential != "" {
+		confidential, err := strconv.ParseBool(fo.confidential)
+		if err != nil {
+			return f, fmt.Errorf("invalid value for -confidential: %w", err)
+		}
+		f.Confidential = &confidential
+	}
+	if err := f.Validate(); err != nil {
+		return f, fmt.Errorf("invalid issue filter flags: %w", err)
+	}
+	return f, nil
+}
+
+// parseDateFlag parses a date given as YYYY-MM-DD or RFC3339, nil if empty.
+func parseDateFlag(name string, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil //nolint:nilnil // an empty flag means no date
+	}
+	for _, layout := range []string{time.DateOnly, time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid date for -%s: %q (expected YYYY-MM-DD or RFC3339)", name, value) //nolint:err113 // flag error
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-module/carbon/v2"
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+	"github.com/sgaunet/gitlab-stats/pkg/graphissues"
+	"github.com/sgaunet/gitlab-stats/pkg/leadtime"
+	"github.com/sgaunet/gitlab-stats/pkg/storage/sqlite"
+	"github.com/sirupsen/logrus"
+)
+
+// maxLabelSeries is the number of labels, those with the most closed issues,
+// whose lead time is drawn with -by-label.
+const maxLabelSeries = 5
+
+// collectLeadTimes computes the lead time percentiles of the issues closed
+// during the months of -from to -to, by default the previous month and the
+// current one, and stores them, replacing those of these months computed by a
+// previous run: the previous month is complete after the first run of the month.
+func collectLeadTimes(ctx context.Context, s *sqlite.Storage, l gitlab.IssueLister, cfg config) {
+	from, to := leadTimePeriod(cfg)
+	issues, err := l.ClosedIssues(ctx, scopeOf(cfg), from)
+	if err != nil {
+		exitOnCanceled(ctx)
+		exitOnAPIError(err, describeEntity(cfg))
+	}
+
+	closed := 0
+	var leadTimes []sqlite.LeadTime
+	for _, p := range leadtime.Monthly(issues, from, to, cfg.byLabel) {
+		if p.Label == "" {
+			closed += p.Closed
+		}
+		leadTimes = append(leadTimes, sqlite.LeadTime{
+			Month:  p.Month,
+			Label:  p.Label,
+			Closed: int64(p.Closed),
+			P50:    p.P50,
+			P85:    p.P85,
+			P95:    p.P95,
+		})
+	}
+	entityType, key := seriesEntity(cfg)
+	err = s.SaveLeadTimes(ctx, entityType, key,
+		carbon.CreateFromStdTime(from), carbon.CreateFromStdTime(to), leadTimes, carbon.Now())
+	if err != nil {
+		logrus.Errorln(err.Error())
+		os.Exit(exitError)
+	}
+	fmt.Printf("lead times of %d issues of %s closed from %s to %s stored\n",
+		closed, describeEntity(cfg), from.Format("2006-01"), to.Format("2006-01"))
+}
+
+// leadTimePeriod returns the first days of the first and last months of the
+// lead times to collect: those of -from and -to, by default the previous
+// month and the current one.
+func leadTimePeriod(cfg config) (time.Time, time.Time) {
+	firstDay := func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.Local)
+	}
+	to := firstDay(time.Now())
+	if !cfg.toDay.IsZero() {
+		to = firstDay(cfg.toDay)
+	}
+	from := to.AddDate(0, -1, 0)
+	if !cfg.fromDay.IsZero() {
+		from = firstDay(cfg.fromDay)
+	}
+	return from, to
+}
+
+// generateLeadTimeGraph draws the lead time percentiles next to the backlog:
+// p50, p85 and p95 of all the issues, or p85 of the issues of the labels
+// with the most closed issues with -by-label.
+func generateLeadTimeGraph(s *sqlite.Storage, cfg config) {
+	begindate := carbon.CreateFromStdTime(s.Now()).AddMonths(-cfg.sinceMonth).StartOfMonth()
+	enddate := carbon.CreateFromStdTime(s.Now()).StartOfMonth()
+
+	logrus.Infoln("retrieve enhanced stats and lead times from database")
+	var enhancedStats *sqlite.EnhancedStats
+	var err error
+	if cfg.projectID != 0 {
+		enhancedStats, err = s.GetEnhancedStatsByProjectID(cfg.projectKey, begindate, enddate)
+	} else {
+		enhancedStats, err = s.GetEnhancedStatsByGroupID(cfg.groupKey, begindate, enddate)
+	}
+	if err != nil {
+		logrus.Errorln("error when retrieving enhanced stats: ", err.Error())
+		os.Exit(exitError)
+	}
+	if enhancedStats == nil || len(enhancedStats.DateExecSeries) == 0 {
+		logrus.Errorf("No data found in database for %s. Please collect statistics first before generating a chart.",
+			describeEntity(cfg))
+		os.Exit(exitError)
+	}
+	entityType, key := seriesEntity(cfg)
+	leadTimes, err := s.GetLeadTimes(context.Background(), entityType, key, begindate, enddate)
+	if err != nil {
+		logrus.Errorln("error when retrieving lead times: ", err.Error())
+		os.Exit(exitError)
+	}
+	if len(leadTimes) == 0 {
+		logrus.Errorf("No lead time found in database for %s. Please collect them with -lead-time before generating a chart.",
+			describeEntity(cfg))
+		os.Exit(exitError)
+	}
+
+	err = graphissues.CreateLeadTimeGraph(
+		cfg.graphFilePath,
+		enhancedStats.TotalOpenedSeries,
+		enhancedStats.OpenedDuringPeriod,
+		enhancedStats.ClosedDuringPeriod,
+		leadTimeSeries(leadTimes, enhancedStats.DateExecSeries, cfg.byLabel),
+		enhancedStats.DateExecSeries,
+		enhancedStats.ReconstructedSeries,
+	)
+	if err != nil {
+		logrus.Errorln("error when creating lead time graph: ", err.Error())
+		os.Exit(exitError)
+	}
+}
+
+// leadTimeSeries returns the lead time series of the months of the backlog
+// series, NaN for the months without lead time.
+func leadTimeSeries(leadTimes []sqlite.LeadTime, months []time.Time, byLabel bool) []graphissues.LeadTimeSeries {
+	type key struct {
+		month string
+		label string
+	}
+	byMonth := make(map[key]sqlite.LeadTime, len(leadTimes))
+	closed := make(map[string]int64)
+	for _, leadTime := range leadTimes {
+		byMonth[key{month: leadTime.Month.Format("2006-01"), label: leadTime.Label}] = leadTime
+		if leadTime.Label != "" {
+			closed[leadTime.Label] += leadTime.Closed
+		}
+	}
+	serie := func(name string, label string, percentile func(sqlite.LeadTime) float64) graphissues.LeadTimeSeries {
+		days := make([]float64, 0, len(months))
+		for _, month := range months {
+			leadTime, ok := byMonth[key{month: month.Format("2006-01"), label: label}]
+			if !ok {
+				days = append(days, math.NaN())
+				continue
+			}
+			days = append(days, percentile(leadTime))
+		}
+		return graphissues.LeadTimeSeries{Name: name, Days: days}
+	}
+
+	if !byLabel {
+		return []graphissues.LeadTimeSeries{
+			serie("Lead Time p50 (days)", "", func(l sqlite.LeadTime) float64 { return l.P50 }),
+			serie("Lead Time p85 (days)", "", func(l sqlite.LeadTime) float64 { return l.P85 }),
+			serie("Lead Time p95 (days)", "", func(l sqlite.LeadTime) float64 { return l.P95 }),
+		}
+	}
+	labels := make([]string, 0, len(closed))
+	for label := range closed {
+		labels = append(labels, label)
+	}
+	slices.SortFunc(labels, func(a, b string) int {
+		if c := cmp.Compare(closed[b], closed[a]); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	series := make([]graphissues.LeadTimeSeries, 0, maxLabelSeries)
+	for _, label := range labels[:min(len(labels), maxLabelSeries)] {
+		series = append(series, serie("Lead Time p85 "+label+" (days)", label,
+			func(l sqlite.LeadTime) float64 { return l.P85 }))
+	}
+	return series
+}
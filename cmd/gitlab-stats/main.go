@@ -1,14 +1,24 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/golang-module/carbon/v2"
+	"github.com/sgaunet/gitlab-stats/pkg/collector"
 	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
 	"github.com/sgaunet/gitlab-stats/pkg/graphissues"
+	"github.com/sgaunet/gitlab-stats/pkg/provider"
 	"github.com/sgaunet/gitlab-stats/pkg/storage/sqlite"
 
 	// storage "github.com/sgaunet/gitlab-stats/pkg/storage/sqlite".
@@ -26,15 +36,51 @@ func printVersion() {
 type config struct {
 	debugLevel    string
 	projectID     int
+	projectKey    int64
+	projectPath   string
 	groupID       int
+	groupKey      int64
+	groupPath     string
 	vOption       bool
 	graphFilePath string
 	dbFile        string
 	sinceMonth    int
+	maxRetries    int
+	timeout       time.Duration
+	filter        gitlab.IssueFilter
+	seriesName    string
+	seriesID      int64
+	instance      bool
+	mergeRequests bool
+	crawl         bool
+	backfill      bool
+	fromDay       time.Time
+	toDay         time.Time
+	leadTime      bool
+	byLabel       bool
+	ages          bool
+	workers       int
+	remote        string
+	tokenFile     string
+	tokenCommand  string
+	tokenSource   gitlab.TokenSource
+	httpOptions   gitlab.HTTPClientOptions
+	httpClient    *http.Client
+	recordFile    string
+	replayFile    string
+	cacheDir      string
+	cache         *gitlab.Cache
+	strictAPI     bool
+	unknownFields string
+	providerName  string
+	provider      provider.Kind
+	baseURL       string
+	instanceID    int64
 }
 
 func parseAndValidateFlags() config {
 	cfg := config{}
+	fo := filterOptions{}
 	
 	// Parameters treatment
 	flag.StringVar(&cfg.graphFilePath, "o", "", "file path to generate statistic graph (do not fulfill DB)")
@@ -42,10 +88,64 @@ func parseAndValidateFlags() config {
 	defaultDBFile := os.Getenv("HOME") + "/.gitlab-stats/db.sqlite3"
 	flag.StringVar(&cfg.dbFile, "db", defaultDBFile, "DB file (default $HOME/.gitlab-stats/db.sqlite3))")
 	flag.BoolVar(&cfg.vOption, "v", false, "Get version")
-	flag.IntVar(&cfg.projectID, "p", 0, "Project ID to get issues from")
-	flag.IntVar(&cfg.groupID, "g", 0, "Group ID to get issues from (not compatible with -p option)")
+	flag.Var(entityFlag{id: &cfg.projectID, path: &cfg.projectPath}, "p",
+		"Project ID or full path (namespace/project) to get issues from")
+	flag.Var(entityFlag{id: &cfg.groupID, path: &cfg.groupPath}, "g",
+		"Group ID or full path (namespace/subgroup) to get issues from (not compatible with -p option)")
+	flag.StringVar(&cfg.tokenFile, "token-file", os.Getenv("GITLAB_TOKEN_FILE"),
+		"file containing the token of the forge (GitLab, GitHub, Gitea or Forgejo), readable only by its owner (default $GITLAB_TOKEN_FILE)")
+	flag.StringVar(&cfg.tokenCommand, "token-command", os.Getenv("GITLAB_TOKEN_COMMAND"),
+		"shell command printing the token of the forge (GitLab, GitHub, Gitea or Forgejo) on its standard output (default $GITLAB_TOKEN_COMMAND)")
+	flag.StringVar(&cfg.httpOptions.CAFile, "ca-file", "", "PEM bundle of the CAs of a self-hosted GitLab")
+	flag.StringVar(&cfg.httpOptions.CertFile, "client-cert", "", "PEM client certificate for mutual TLS")
+	flag.StringVar(&cfg.httpOptions.KeyFile, "client-key", "", "PEM key of the client certificate")
+	flag.BoolVar(&cfg.httpOptions.InsecureSkipVerify, "insecure", false,
+		"do not verify the certificate of GitLab (insecure, for tests only)")
+	flag.StringVar(&cfg.httpOptions.ProxyURL, "proxy", "",
+		"proxy URL of the GitLab API calls (default from HTTPS_PROXY/NO_PROXY)")
+	flag.StringVar(&cfg.recordFile, "record", "",
+		"record the GitLab API calls to a fixture file, with tokens redacted (to report a bug)")
+	flag.StringVar(&cfg.replayFile, "replay", "", "replay the GitLab API calls from a fixture file, without network access")
+	flag.StringVar(&cfg.cacheDir, "cache", "",
+		"directory of the HTTP cache: unchanged GitLab responses are not downloaded again (disabled by default)")
+	flag.BoolVar(&cfg.strictAPI, "strict-api", false,
+		"fail on fields of the GitLab responses unknown to gitlab-stats instead of logging a warning (contract tests)")
+	flag.StringVar(&cfg.unknownFields, "unknown-fields", "",
+		"append the raw values of the fields of the GitLab responses unknown to gitlab-stats to this JSON lines file")
+	flag.StringVar(&cfg.remote, "remote", "origin",
+		"git remote used to find the project when -p and -g options are not given")
+	flag.StringVar(&cfg.providerName, "provider", string(provider.GitLab),
+		"forge of the projects and groups: gitlab (GITLAB_URI), github (GITHUB_URI, default https://github.com), "+
+			"gitea (GITEA_URI) or forgejo (FORGEJO_URI)")
+	flag.BoolVar(&cfg.instance, "instance", false,
+		"get issues of the whole instance visible with the token (not compatible with -p and -g options)")
 	const defaultSinceMonths = 6
 	flag.IntVar(&cfg.sinceMonth, "s", defaultSinceMonths, "graph last X month")
+	flag.IntVar(&cfg.maxRetries, "retries", gitlab.DefaultRetryPolicy().MaxRetries,
+		"max retries of the API calls on rate limit and 5xx responses (0 to disable)")
+	const defaultTimeout = 5 * time.Minute
+	flag.DurationVar(&cfg.timeout, "timeout", defaultTimeout, "global timeout of the GitLab API calls (0 to disable)")
+	flag.BoolVar(&cfg.crawl, "crawl", false,
+		"with -g, also collect the statistics of every project of the group and its subgroups")
+	flag.BoolVar(&cfg.backfill, "backfill", false,
+		"rebuild the daily statistics of the project or group between -from and -to from the dates of its issues")
+	var fromDay, toDay string
+	flag.StringVar(&fromDay, "from", "",
+		"first day to rebuild with -backfill, or whose month starts the lead times with -lead-time (YYYY-MM-DD)")
+	flag.StringVar(&toDay, "to", "",
+		"last day to rebuild with -backfill (YYYY-MM-DD, default yesterday), or whose month ends the lead times with -lead-time (default today)")
+	flag.BoolVar(&cfg.leadTime, "lead-time", false,
+		"collect the lead time percentiles of the issues closed during the months of -from to -to "+
+			"(default the previous and current months), or graph them with -o")
+	flag.BoolVar(&cfg.byLabel, "by-label", false, "with -lead-time, also collect or graph the lead times per label")
+	flag.BoolVar(&cfg.ages, "ages", false,
+		"with -o, graph the age of the open issues instead of their number")
+	flag.IntVar(&cfg.workers, "workers", collector.DefaultWorkers,
+		"number of projects collected at the same time with -crawl")
+	flag.BoolVar(&cfg.mergeRequests, "mr", false, "collect or graph merge requests instead of issues")
+	flag.StringVar(&cfg.seriesName, "series", "",
+		"name of the series to collect or graph (required by issue filters, stored with its entity and filters)")
+	registerFilterFlags(&fo)
 	flag.Parse()
 
 	if cfg.vOption {
@@ -53,6 +153,27 @@ func parseAndValidateFlags() config {
 		os.Exit(0)
 	}
 
+	var err error
+	cfg.filter, err = fo.issueFilter()
+	if err != nil {
+		logrus.Errorln(err.Error())
+		flag.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+
+	if err := parseDayRange(&cfg, fromDay, toDay); err != nil {
+		logrus.Errorln(err.Error())
+		flag.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+
+	cfg.provider, err = provider.ParseKind(cfg.providerName)
+	if err != nil {
+		logrus.Errorln(err.Error())
+		flag.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+
 	validateConfig(cfg)
 	return cfg
 }
@@ -61,33 +182,183 @@ func validateConfig(cfg config) {
 	if cfg.sinceMonth < 1 {
 		logrus.Errorf("sinceMonth should be greater than 0\n")
 		flag.PrintDefaults()
-		os.Exit(1)
+		os.Exit(exitUsage)
+	}
+
+	if cfg.maxRetries < 0 {
+		logrus.Errorf("retries should be greater than or equal to 0\n")
+		flag.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+
+	if cfg.workers < 1 {
+		logrus.Errorf("workers should be greater than 0\n")
+		flag.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+
+	if cfg.timeout < 0 {
+		logrus.Errorf("timeout should be greater than or equal to 0\n")
+		flag.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+
+	if (cfg.httpOptions.CertFile == "") != (cfg.httpOptions.KeyFile == "") {
+		fmt.Fprintln(os.Stderr, "-client-cert and -client-key options must be given together")
+		flag.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+
+	if cfg.recordFile != "" && cfg.replayFile != "" {
+		fmt.Fprintln(os.Stderr, "-record and -replay options are incompatible")
+		flag.PrintDefaults()
+		os.Exit(exitUsage)
 	}
 
 	if cfg.debugLevel != "info" && cfg.debugLevel != "error" && cfg.debugLevel != "debug" {
 		logrus.Errorf("debuglevel should be info or error or debug\n")
 		flag.PrintDefaults()
-		os.Exit(1)
+		os.Exit(exitUsage)
 	}
 	
-	if cfg.projectID != 0 && cfg.groupID != 0 {
+	if cfg.hasProject() && cfg.hasGroup() {
 		fmt.Fprintln(os.Stderr, "-p and -g option are incompatible")
 		flag.PrintDefaults()
-		os.Exit(1)
+		os.Exit(exitUsage)
 	}
-}
 
-func setupEnvironment() {
-	if len(os.Getenv("GITLAB_TOKEN")) == 0 {
-		logrus.Errorf("Set GITLAB_TOKEN environment variable")
-		os.Exit(1)
+	if cfg.instance && (cfg.hasProject() || cfg.hasGroup()) {
+		fmt.Fprintln(os.Stderr, "-instance option is incompatible with -p and -g options")
+		flag.PrintDefaults()
+		os.Exit(exitUsage)
 	}
-	
+
+	if cfg.mergeRequests && (cfg.instance || cfg.seriesName != "") {
+		fmt.Fprintln(os.Stderr, "-mr option is incompatible with -instance and -series options")
+		flag.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+
+	if cfg.crawl && (!cfg.hasGroup() || cfg.seriesName != "" || cfg.graphFilePath != "") {
+		fmt.Fprintln(os.Stderr, "-crawl option requires -g and is incompatible with -series and -o options")
+		flag.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+
+	if cfg.backfill && (cfg.instance || cfg.mergeRequests || cfg.crawl || cfg.seriesName != "" || cfg.graphFilePath != "") {
+		fmt.Fprintln(os.Stderr, "-backfill option is incompatible with -instance, -mr, -crawl, -series and -o options")
+		flag.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+
+	if cfg.backfill && (cfg.fromDay.IsZero() || cfg.fromDay.After(cfg.toDay) ||
+		!cfg.toDay.Before(localDay(time.Now()))) {
+		fmt.Fprintln(os.Stderr, "-backfill option requires -from, not after -to, and -to must be before today")
+		flag.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+
+	if cfg.leadTime && (cfg.instance || cfg.mergeRequests || cfg.crawl || cfg.backfill || cfg.seriesName != "") {
+		fmt.Fprintln(os.Stderr, "-lead-time option is incompatible with -instance, -mr, -crawl, -backfill and -series options")
+		flag.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+
+	if cfg.ages && (cfg.graphFilePath == "" || cfg.mergeRequests || cfg.leadTime || cfg.backfill) {
+		fmt.Fprintln(os.Stderr, "-ages option requires -o and is incompatible with -mr, -lead-time and -backfill options")
+		flag.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+
+	if (!cfg.fromDay.IsZero() || !cfg.toDay.IsZero()) && !cfg.backfill && (!cfg.leadTime || cfg.graphFilePath != "") {
+		fmt.Fprintln(os.Stderr, "-from and -to options require -backfill or -lead-time without -o")
+		flag.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+
+	if cfg.leadTime && !cfg.fromDay.IsZero() && !cfg.toDay.IsZero() && cfg.fromDay.After(cfg.toDay) {
+		fmt.Fprintln(os.Stderr, "-from option must not be after -to")
+		flag.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+
+	if cfg.byLabel && !cfg.leadTime {
+		fmt.Fprintln(os.Stderr, "-by-label option requires -lead-time")
+		flag.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+
+	if cfg.provider != provider.GitLab && (cfg.instance || cfg.mergeRequests || cfg.backfill || cfg.leadTime || cfg.ages) {
+		fmt.Fprintln(os.Stderr, "-instance, -mr, -backfill, -lead-time and -ages options are only supported with GitLab")
+		flag.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+
+	if env := uriEnv(cfg.provider); (cfg.provider == provider.Gitea || cfg.provider == provider.Forgejo) &&
+		os.Getenv(env) == "" {
+		fmt.Fprintf(os.Stderr, "%s is required with -provider %s\n", env, cfg.provider)
+		flag.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+
+	if !cfg.filter.IsZero() && cfg.seriesName == "" {
+		fmt.Fprintln(os.Stderr, "issue filters require the -series option")
+		flag.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+}
+
+// uriEnv returns the environment variable of the URL of the instance of a forge.
+func uriEnv(kind provider.Kind) string {
+	return strings.ToUpper(string(kind)) + "_URI"
+}
+
+func setupEnvironment(cfg *config) {
 	if len(os.Getenv("GITLAB_URI")) == 0 {
 		if err := os.Setenv("GITLAB_URI", "https://gitlab.com"); err != nil {
 			logrus.Warnf("Failed to set GITLAB_URI: %v", err)
 		}
 	}
+	cfg.tokenSource = newTokenSource(*cfg)
+	cfg.baseURL = os.Getenv("GITLAB_URI")
+	switch cfg.provider {
+	case provider.GitHub:
+		cfg.baseURL = os.Getenv("GITHUB_URI")
+		if cfg.baseURL == "" {
+			cfg.baseURL = "https://github.com"
+		}
+	case provider.Gitea, provider.Forgejo:
+		cfg.baseURL = strings.TrimSuffix(os.Getenv(uriEnv(cfg.provider)), "/")
+	}
+
+	if cfg.httpOptions.InsecureSkipVerify {
+		fmt.Fprintln(os.Stderr, "WARNING: -insecure disables the verification of the GitLab certificate, "+
+			"the token can be intercepted: use -ca-file instead")
+	}
+	var err error
+	cfg.httpClient, err = gitlab.NewHTTPClient(cfg.httpOptions)
+	if err != nil {
+		logrus.Errorln(err.Error())
+		os.Exit(exitError)
+	}
+	if cfg.cacheDir != "" {
+		cfg.cache, err = gitlab.NewCache(cfg.cacheDir)
+		if err != nil {
+			logrus.Errorln(err.Error())
+			os.Exit(exitError)
+		}
+	}
+	switch {
+	case cfg.recordFile != "":
+		cfg.httpClient.Transport = gitlab.NewRecorder(cfg.httpClient.Transport, cfg.recordFile)
+	case cfg.replayFile != "":
+		replayer, err := gitlab.NewReplayer(cfg.replayFile)
+		if err != nil {
+			logrus.Errorln(err.Error())
+			os.Exit(exitError)
+		}
+		cfg.httpClient.Transport = replayer
+	}
 }
 
 func ensureDataDirectory() {
@@ -101,30 +372,6 @@ func ensureDataDirectory() {
 	}
 }
 
-func detectProjectIfNeeded(cfg *config) {
-	if cfg.groupID != 0 || cfg.projectID != 0 {
-		return
-	}
-	
-	// Try to find git repository and project
-	gitFolder, err := findGitRepository()
-	if err != nil {
-		logrus.Errorf("Folder .git not found")
-		os.Exit(1)
-	}
-	
-	remoteOrigin := GetRemoteOrigin(gitFolder + string(os.PathSeparator) + ".git" + string(os.PathSeparator) + "config")
-	project, err := findProject(remoteOrigin)
-	if err != nil {
-		logrus.Errorln(err.Error())
-		os.Exit(1)
-	}
-
-	logrus.Infoln("Project found: ", project.SSHURLToRepo)
-	logrus.Infoln("Project found: ", project.ID)
-	cfg.projectID = project.ID
-}
-
 func initializeDatabase(dbFile string) *sqlite.Storage {
 	// Check existence of DB file
 	_, err := os.Stat(dbFile)
@@ -150,6 +397,10 @@ func initializeDatabase(dbFile string) *sqlite.Storage {
 		logrus.Errorln(err.Error())
 		os.Exit(1)
 	}
+	if err := s.Migrate(); err != nil {
+		logrus.Errorln(err.Error())
+		os.Exit(1)
+	}
 	return s
 }
 
@@ -161,10 +412,15 @@ func generateGraph(s *sqlite.Storage, cfg config) {
 	var enhancedStats *sqlite.EnhancedStats
 	var err error
 	
-	if cfg.projectID != 0 {
-		enhancedStats, err = s.GetEnhancedStatsByProjectID(int64(cfg.projectID), begindate, enddate)
-	} else {
-		enhancedStats, err = s.GetEnhancedStatsByGroupID(int64(cfg.groupID), begindate, enddate)
+	switch {
+	case cfg.seriesName != "":
+		enhancedStats, err = s.GetEnhancedStatsBySeries(context.Background(), cfg.seriesName, begindate, enddate)
+	case cfg.projectID != 0:
+		enhancedStats, err = s.GetEnhancedStatsByProjectID(cfg.projectKey, begindate, enddate)
+	case cfg.instance:
+		enhancedStats, err = s.GetEnhancedStatsByInstanceID(context.Background(), cfg.instanceID, begindate, enddate)
+	default:
+		enhancedStats, err = s.GetEnhancedStatsByGroupID(cfg.groupKey, begindate, enddate)
 	}
 	if err != nil {
 		logrus.Errorln("error when retrieving enhanced stats: ", err.Error())
@@ -173,13 +429,8 @@ func generateGraph(s *sqlite.Storage, cfg config) {
 	
 	// Check if there's any data to generate a chart
 	if enhancedStats == nil || len(enhancedStats.DateExecSeries) == 0 {
-		entityType := "project"
-		entityID := cfg.projectID
-		if cfg.groupID != 0 {
-			entityType = "group"
-			entityID = cfg.groupID
-		}
-		logrus.Errorf("No data found in database for %s ID %d. Please collect statistics first before generating a chart.", entityType, entityID)
+		logrus.Errorf("No data found in database for %s. Please collect statistics first before generating a chart.",
+			describeEntity(cfg))
 		os.Exit(1)
 	}
 	
@@ -190,6 +441,7 @@ func generateGraph(s *sqlite.Storage, cfg config) {
 		enhancedStats.ClosedDuringPeriod,
 		enhancedStats.VelocitySeries,
 		enhancedStats.DateExecSeries,
+		enhancedStats.ReconstructedSeries,
 	)
 	if err != nil {
 		logrus.Errorln("error when creating enhanced graph: ", err.Error())
@@ -197,59 +449,211 @@ func generateGraph(s *sqlite.Storage, cfg config) {
 	}
 }
 
-func collectData(s *sqlite.Storage, cfg config) {
+// newGitlabService returns a GitLab client configured from the environment and the CLI options.
+func newGitlabService(cfg config) *gitlab.Service {
 	gs := gitlab.NewService()
-	var n *gitlab.ServiceStatistics
-	
-	if cfg.projectID != 0 {
-		n = gitlab.NewProjectStatistics(cfg.projectID)
-	} else {
-		n = gitlab.NewGroupStatistics(cfg.groupID)
+	gs.SetGitlabEndpoint(gitlabAPIEndpoint(cfg.baseURL))
+	gs.SetTokenSource(cfg.tokenSource)
+	gs.SetHTTPClient(cfg.httpClient)
+	gs.SetCache(cfg.cache)
+	gs.SetStrictDecoding(cfg.strictAPI)
+	gs.SetSchemaDriftHandler(func(drift gitlab.SchemaDrift) {
+		warnSchemaDrift(drift)
+		if cfg.unknownFields != "" {
+			saveSchemaDrift(cfg.unknownFields, drift)
+		}
+	})
+	gs.SetRetryPolicy(retryPolicy(cfg))
+	return gs
+}
+
+// retryPolicy returns the retry policy of the API calls, with -retries retries.
+func retryPolicy(cfg config) gitlab.RetryPolicy {
+	policy := gitlab.DefaultRetryPolicy()
+	policy.MaxRetries = cfg.maxRetries
+	return policy
+}
+
+// warnSchemaDrift logs the fields of a GitLab response unknown to gitlab-stats,
+// usually added by a GitLab upgrade, once per endpoint. Their raw values are
+// logged in debug level.
+func warnSchemaDrift(drift gitlab.SchemaDrift) {
+	if len(drift.New) == 0 {
+		return
 	}
-	
-	statistics, err := n.GetStatistics(gs)
+	logrus.Warnf("GitLab API %s returned unknown fields (ignored): %s",
+		drift.Endpoint, strings.Join(drift.New, ", "))
+	for _, name := range drift.New {
+		logrus.Debugf("unknown field %s: %s", name, drift.Fields[name])
+	}
+}
+
+// saveSchemaDrift appends the raw unknown fields of a GitLab response to the
+// JSON lines file path, one line per response. Failures are only logged: the
+// collection does not depend on the unknown fields.
+func saveSchemaDrift(path string, drift gitlab.SchemaDrift) {
+	if err := appendSchemaDrift(path, drift); err != nil {
+		logrus.Warnf("unknown fields of GitLab API %s not saved: %v", drift.Endpoint, err)
+	}
+}
+
+func appendSchemaDrift(path string, drift gitlab.SchemaDrift) error {
+	line, err := json.Marshal(struct {
+		Date     time.Time                  `json:"date"`
+		Endpoint string                     `json:"endpoint"`
+		Fields   map[string]json.RawMessage `json:"fields"`
+	}{time.Now(), drift.Endpoint, drift.Fields})
 	if err != nil {
-		logrus.Errorln(err.Error())
-		os.Exit(1)
+		return fmt.Errorf("failed to encode unknown fields: %w", err)
 	}
-	
-	if cfg.projectID != 0 {
-		err = s.AddProjectStats(
-			int64(cfg.projectID), 
-			int64(statistics.Statistics.Counts.Opened), 
-			int64(statistics.Statistics.Counts.Closed), 
-			int64(statistics.Statistics.Counts.All), 
-			carbon.Now(),
-		)
-	} else {
-		err = s.AddGroupStats(
-			int64(cfg.groupID), 
-			int64(statistics.Statistics.Counts.Opened), 
-			int64(statistics.Statistics.Counts.Closed), 
-			int64(statistics.Statistics.Counts.All), 
-			carbon.Now(),
-		)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open unknown fields file: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write unknown fields: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close unknown fields file: %w", err)
+	}
+	return nil
+}
+
+// gitlabAPIEndpoint returns the API endpoint of a GitLab instance URI.
+func gitlabAPIEndpoint(gitlabURI string) string {
+	gitlabURI = strings.TrimSuffix(gitlabURI, "/")
+	if gitlabURI == "" || strings.HasSuffix(gitlabURI, "/api/v4") {
+		return gitlabURI
+	}
+	return gitlabURI + "/api/v4"
+}
+
+func collectData(ctx context.Context, s *sqlite.Storage, f gitlab.StatisticsFetcher, cfg config) {
+	runJob(ctx, issueJob(f, s, cfg), cfg)
+}
+
+// issueJob returns the job collecting the issue statistics of the entity of cfg.
+func issueJob(f gitlab.StatisticsFetcher, s *sqlite.Storage, cfg config) collector.Job {
+	return collector.Job{
+		Name: describeEntity(cfg),
+		Fetch: func(ctx context.Context) (collector.Store, error) {
+			statistics, err := f.IssueStatistics(ctx, scopeOf(cfg), cfg.filter)
+			if err != nil {
+				return nil, err
+			}
+			dateExec := carbon.Now()
+			opened := int64(statistics.Statistics.Counts.Opened)
+			closed := int64(statistics.Statistics.Counts.Closed)
+			total := int64(statistics.Statistics.Counts.All)
+			// other forges do not filter the issues by creation date
+			var ages *sqlite.OpenIssueAges
+			if cfg.provider == provider.GitLab {
+				ages = openIssueAges(ctx, f, cfg)
+			}
+
+			// the summary of a crawl counts the responses served from the cache
+			if statistics.FromCache && !cfg.crawl {
+				fmt.Printf("%s unchanged since the last run, snapshot duplicated from the cache\n", describeEntity(cfg))
+			}
+
+			return func(ctx context.Context) error {
+				switch {
+				case ages != nil:
+					entityType, key := agesEntity(cfg)
+					snapshot := sqlite.Snapshot{Opened: opened, Closed: closed, Total: total, DateExec: dateExec.StdTime()}
+					return s.AddSnapshotWithAges(ctx, entityType, key, snapshot, *ages)
+				case cfg.seriesName != "":
+					return s.AddSeriesStats(ctx, cfg.seriesName, opened, closed, total, dateExec)
+				case cfg.instance:
+					return s.AddInstanceStatsByID(ctx, cfg.instanceID, opened, closed, total, dateExec)
+				case cfg.projectID != 0:
+					return s.AddProjectStatsWithContext(ctx, cfg.instanceID, int64(cfg.projectID), opened, closed, total, dateExec)
+				default:
+					return s.AddGroupStatsWithContext(ctx, cfg.instanceID, int64(cfg.groupID), opened, closed, total, dateExec)
+				}
+			}, nil
+		},
+	}
+}
+
+// scopeOf returns the GitLab scope of the entity of cfg.
+func scopeOf(cfg config) gitlab.Scope {
+	switch {
+	case cfg.instance:
+		return gitlab.InstanceScope()
+	case cfg.projectID != 0:
+		return gitlab.ProjectScope(cfg.projectID)
+	default:
+		return gitlab.GroupScope(cfg.groupID)
+	}
+}
+
+// runJob fetches and stores the statistics of a single entity, exiting on the first error.
+func runJob(ctx context.Context, job collector.Job, cfg config) {
+	store, err := job.Fetch(ctx)
+	if err == nil {
+		err = store(ctx)
 	}
-	
 	if err != nil {
-		logrus.Errorln(err.Error())
-		os.Exit(1)
+		exitOnCanceled(ctx)
+		exitOnAPIError(err, describeEntity(cfg))
+	}
+}
+
+// exitOnCanceled exits if the context was interrupted or timed out.
+// No snapshot is written in that case: storage writes are transactional.
+func exitOnCanceled(ctx context.Context) {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		logrus.Errorln("timeout reached, no statistics recorded")
+		os.Exit(exitError)
+	case ctx.Err() != nil:
+		logrus.Errorln("interrupted, no statistics recorded")
+		os.Exit(exitError)
 	}
 }
 
 func main() {
 	cfg := parseAndValidateFlags()
 	initTrace(cfg.debugLevel)
-	setupEnvironment()
+	setupEnvironment(&cfg)
 	ensureDataDirectory()
-	detectProjectIfNeeded(&cfg)
-	
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if cfg.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
+		defer cancel()
+	}
+
 	s := initializeDatabase(cfg.dbFile)
+	p := newProvider(cfg)
+	registerInstance(ctx, s, p, &cfg)
+	resolveEntities(ctx, s, p, &cfg)
+	resolveSeries(ctx, s, p, &cfg)
+	detectProjectIfNeeded(ctx, s, p, &cfg)
 	
-	if cfg.graphFilePath != "" {
+	switch {
+	case cfg.crawl:
+		crawlGroup(ctx, s, p, cfg)
+	case cfg.backfill:
+		backfillIssues(ctx, s, issueLister(p), cfg)
+	case cfg.leadTime && cfg.graphFilePath != "":
+		generateLeadTimeGraph(s, cfg)
+	case cfg.leadTime:
+		collectLeadTimes(ctx, s, issueLister(p), cfg)
+	case cfg.ages:
+		generateOpenIssueAgesGraph(s, cfg)
+	case cfg.mergeRequests && cfg.graphFilePath != "":
+		generateMergeRequestGraph(s, cfg)
+	case cfg.mergeRequests:
+		collectMergeRequests(ctx, s, p, cfg)
+	case cfg.graphFilePath != "":
 		generateGraph(s, cfg)
-	} else {
-		collectData(s, cfg)
+	default:
+		collectData(ctx, s, p, cfg)
 	}
 }
 
@@ -0,0 +1,653 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-module/carbon/v2"
+	"github.com/sgaunet/gitlab-stats/pkg/gitea/giteatest"
+	"github.com/sgaunet/gitlab-stats/pkg/github/githubtest"
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab/gitlabtest"
+	"github.com/sgaunet/gitlab-stats/pkg/storage/sqlite"
+)
+
+// TestMain runs the CLI instead of the tests when the test binary is started by runCLI.
+func TestMain(m *testing.M) {
+	if os.Getenv("GITLAB_STATS_RUN_CLI") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+type cliEnv struct {
+	dir  string
+	home string
+	db   string
+	srv  *gitlabtest.Server
+	ca   string
+	env  []string
+}
+
+func newCLIEnv(t *testing.T) *cliEnv {
+	t.Helper()
+	e := &cliEnv{
+		dir:  t.TempDir(),
+		home: t.TempDir(),
+		srv:  gitlabtest.NewServer(t),
+	}
+	e.db = filepath.Join(e.home, "db.sqlite3")
+	e.ca = filepath.Join(e.home, "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: e.srv.Certificate().Raw})
+	if err := os.WriteFile(e.ca, cert, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	gitConfig := "[remote \"origin\"]\n\turl = git@gitlab.example.com:grp/proj.git\n"
+	if err := os.MkdirAll(filepath.Join(e.dir, ".git"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(e.dir, ".git", "config"), []byte(gitConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	e.srv.RequireToken("secret")
+	e.srv.AddProject(gitlabtest.Project{ID: 42, Name: "proj", PathWithNamespace: "grp/proj"})
+	e.srv.AddIssues(42, gitlabtest.Issue{}, gitlabtest.Issue{}, gitlabtest.Issue{State: "closed"})
+	return e
+}
+
+// run runs the CLI in the git repository and returns its output and exit code.
+func (e *cliEnv) run(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+	args = append([]string{"-db", e.db, "-ca-file", e.ca}, args...)
+	cmd := exec.Command(os.Args[0], args...) //nolint:gosec // test binary
+	cmd.Dir = e.dir
+	cmd.Env = append(os.Environ(),
+		"GITLAB_STATS_RUN_CLI=1",
+		"HOME="+e.home,
+		"GITLAB_URI="+e.srv.URL,
+		"GITLAB_TOKEN=secret",
+	)
+	cmd.Env = append(cmd.Env, e.env...)
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(out), exitErr.ExitCode()
+	}
+	if err != nil {
+		t.Fatalf("failed to run the CLI: %v", err)
+	}
+	return string(out), 0
+}
+
+func TestCLICollectAndGraph(t *testing.T) {
+	e := newCLIEnv(t)
+
+	// the project is detected from the remote of the git repository
+	out, code := e.run(t, "-d", "info")
+	if code != 0 {
+		t.Fatalf("collect exited with %d:\n%s", code, out)
+	}
+	if !strings.Contains(out, "GitLab token read from GITLAB_TOKEN environment variable") {
+		t.Errorf("collect output does not give the source of the token:\n%s", out)
+	}
+
+	ctx := context.Background()
+	s, err := sqlite.NewStorage(e.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	project, err := s.GetProjectByPath(ctx, 0, "grp/proj")
+	if err != nil {
+		t.Fatalf("project not cached: %v", err)
+	}
+	if project.ID != 42 {
+		t.Errorf("cached project = %+v, want project 42", project)
+	}
+	stats, err := s.GetEnhancedStatsByProjectID(project.Key, carbon.Now().SubMonth(), carbon.Now().AddMonth())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.TotalOpenedSeries) != 1 || stats.TotalOpenedSeries[0] != 2 {
+		t.Errorf("collected opened issues = %v, want [2]", stats.TotalOpenedSeries)
+	}
+	// graphs end at the start of the current month: add the snapshot of last month
+	if err := s.AddProjectStats(project.InstanceID, project.ID, 1, 0, 1, carbon.Now().SubMonth()); err != nil {
+		t.Fatal(err)
+	}
+	_ = s.Close()
+
+	graph := filepath.Join(e.home, "graph.png")
+	if out, code := e.run(t, "-o", graph); code != 0 {
+		t.Fatalf("graph exited with %d:\n%s", code, out)
+	}
+	if stat, err := os.Stat(graph); err != nil || stat.Size() == 0 {
+		t.Errorf("graph not generated: %v", err)
+	}
+
+	// a new series is created for the project of the git remote
+	if out, code := e.run(t, "-series", "bugs", "-labels", "bug"); code != 0 {
+		t.Fatalf("collect of a new series exited with %d:\n%s", code, out)
+	}
+	s, err = sqlite.NewStorage(e.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Close() }()
+	series, err := s.GetSeries(ctx, "bugs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if series.EntityType != sqlite.EntityProject || series.EntityID != project.Key {
+		t.Errorf("series entity = %s %d, want project %d", series.EntityType, series.EntityID, project.Key)
+	}
+}
+
+func TestCLIExitCodes(t *testing.T) {
+	e := newCLIEnv(t)
+
+	for _, args := range [][]string{{"-unknown-option"}, {"-s", "0"}, {"-p", "42", "-g", "7"}} {
+		if out, code := e.run(t, args...); code != exitUsage {
+			t.Errorf("%v exited with %d, want %d:\n%s", args, code, exitUsage, out)
+		}
+	}
+	if out, code := e.run(t, "-p", "grp/unknown"); code != exitNotFound {
+		t.Errorf("unknown project exited with %d, want %d:\n%s", code, exitNotFound, out)
+	}
+	e.srv.Fail("projects/42/issues_statistics", 500, 10)
+	if out, code := e.run(t, "-p", "42", "-retries", "0"); code != exitServerError {
+		t.Errorf("server error exited with %d, want %d:\n%s", code, exitServerError, out)
+	}
+}
+
+func TestCLIInstances(t *testing.T) {
+	e := newCLIEnv(t)
+	other := gitlabtest.NewServer(t)
+	other.RequireToken("secret")
+	other.AddProject(gitlabtest.Project{ID: 42, Name: "other", PathWithNamespace: "team/other"})
+	other.AddIssues(42, gitlabtest.Issue{}, gitlabtest.Issue{}, gitlabtest.Issue{}, gitlabtest.Issue{})
+
+	// project 42 of both instances is collected in the same database
+	if out, code := e.run(t, "-p", "42"); code != 0 {
+		t.Fatalf("collect exited with %d:\n%s", code, out)
+	}
+	e.env = []string{"GITLAB_URI=" + other.URL}
+	if out, code := e.run(t, "-p", "42"); code != 0 {
+		t.Fatalf("collect of the other instance exited with %d:\n%s", code, out)
+	}
+	if out, code := e.run(t, "-p", "42", "-series", "other"); code != 0 {
+		t.Fatalf("collect of a series of the other instance exited with %d:\n%s", code, out)
+	}
+
+	ctx := context.Background()
+	s, err := sqlite.NewStorage(e.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for uri, want := range map[string]float64{e.srv.URL: 2, other.URL: 4} {
+		instanceID, err := s.GetInstanceID(ctx, uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		project, err := s.GetProject(ctx, instanceID, 42)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stats, err := s.GetEnhancedStatsByProjectID(project.Key, carbon.Now().SubMonth(), carbon.Now().AddMonth())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stats.TotalOpenedSeries) != 1 || stats.TotalOpenedSeries[0] != want {
+			t.Errorf("opened issues of project 42 of %s = %v, want [%v]", uri, stats.TotalOpenedSeries, want)
+		}
+	}
+	_ = s.Close()
+
+	// the series of the other instance is not collected from the first one
+	e.env = nil
+	if out, code := e.run(t, "-series", "other"); code != exitError {
+		t.Errorf("series of another instance exited with %d, want %d:\n%s", code, exitError, out)
+	}
+
+	// graphs do not register the instance of GITLAB_URI
+	unknown := gitlabtest.NewServer(t)
+	e.env = []string{"GITLAB_URI=" + unknown.URL}
+	if out, code := e.run(t, "-p", "42", "-o", filepath.Join(e.home, "graph.png")); code != exitError {
+		t.Errorf("graph of an instance never collected exited with %d, want %d:\n%s", code, exitError, out)
+	}
+	s, err = sqlite.NewStorage(e.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Close() }()
+	if _, err := s.GetInstanceID(ctx, unknown.URL); !errors.Is(err, sqlite.ErrInstanceNotFound) {
+		t.Errorf("GetInstanceID() of the graphed instance error = %v, want %v", err, sqlite.ErrInstanceNotFound)
+	}
+}
+
+func TestCLIBackfill(t *testing.T) {
+	e := newCLIEnv(t)
+	begin := carbon.Now().SubMonths(2).StartOfMonth()
+	closed := begin.Copy().AddDays(3).StdTime()
+	e.srv.AddIssues(42,
+		gitlabtest.Issue{CreatedAt: begin.Copy().AddHours(10).StdTime()},
+		gitlabtest.Issue{State: "closed", CreatedAt: begin.Copy().AddDay().StdTime(), ClosedAt: &closed},
+	)
+	from := begin.ToDateString()
+	to := begin.Copy().EndOfMonth().ToDateString()
+
+	// backfilling twice stores the same snapshots
+	for range 2 {
+		out, code := e.run(t, "-p", "grp/proj", "-backfill", "-from", from, "-to", to)
+		if code != 0 {
+			t.Fatalf("backfill exited with %d:\n%s", code, out)
+		}
+		if !strings.Contains(out, fmt.Sprintf("%d daily snapshots", begin.DaysInMonth())) {
+			t.Errorf("backfill output = %q, want %d snapshots", out, begin.DaysInMonth())
+		}
+	}
+
+	ctx := context.Background()
+	s, err := sqlite.NewStorage(e.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Close() }()
+	project, err := s.GetProjectByPath(ctx, 0, "grp/proj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := s.GetEnhancedStatsByProjectID(project.Key, begin, carbon.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the 3 issues created today are not in the range
+	if len(stats.TotalOpenedSeries) != 1 || stats.TotalOpenedSeries[0] != 2 || stats.ClosedDuringPeriod[0] != 1 {
+		t.Errorf("backfilled month = %+v, want 2 open issues and 1 closed", stats)
+	}
+
+	if out, code := e.run(t, "-p", "42", "-backfill", "-from", carbon.Now().ToDateString()); code != exitUsage {
+		t.Errorf("backfill of today exited with %d, want %d:\n%s", code, exitUsage, out)
+	}
+}
+
+func TestCLILeadTime(t *testing.T) {
+	e := newCLIEnv(t)
+	lastMonth := carbon.Now().SubMonthNoOverflow().StartOfMonth()
+	closedAfter := func(days int) *time.Time {
+		d := lastMonth.Copy().AddDays(days).StdTime()
+		return &d
+	}
+	e.srv.AddIssues(42,
+		gitlabtest.Issue{State: "closed", Labels: []string{"bug"}, CreatedAt: lastMonth.StdTime(), ClosedAt: closedAfter(2)},
+		gitlabtest.Issue{State: "closed", Labels: []string{"ui"}, CreatedAt: lastMonth.StdTime(), ClosedAt: closedAfter(6)},
+		// closed before the previous month
+		gitlabtest.Issue{State: "closed", CreatedAt: lastMonth.Copy().SubYear().StdTime(), ClosedAt: closedAfter(-3)},
+	)
+
+	out, code := e.run(t, "-p", "grp/proj", "-lead-time", "-by-label")
+	if code != 0 {
+		t.Fatalf("lead time collect exited with %d:\n%s", code, out)
+	}
+	if !strings.Contains(out, "lead times of 2 issues") {
+		t.Errorf("lead time output = %q, want 2 closed issues", out)
+	}
+
+	ctx := context.Background()
+	s, err := sqlite.NewStorage(e.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Close() }()
+	project, err := s.GetProjectByPath(ctx, 0, "grp/proj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	leadTimes, err := s.GetLeadTimes(ctx, sqlite.EntityProject, project.Key, lastMonth, carbon.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leadTimes) != 3 || leadTimes[0].Label != "" || leadTimes[0].Closed != 2 ||
+		leadTimes[0].P50 != 2 || leadTimes[0].P95 != 6 || leadTimes[1].Label != "bug" || leadTimes[2].Label != "ui" {
+		t.Errorf("lead times = %+v, want those of all the issues, of bug and of ui", leadTimes)
+	}
+
+	// the backlog of the previous month is drawn next to the lead times
+	from := lastMonth.ToDateString()
+	to := lastMonth.Copy().EndOfMonth().ToDateString()
+	if out, code := e.run(t, "-p", "42", "-backfill", "-from", from, "-to", to); code != 0 {
+		t.Fatalf("backfill exited with %d:\n%s", code, out)
+	}
+	for _, args := range [][]string{{"-lead-time"}, {"-lead-time", "-by-label"}} {
+		graph := filepath.Join(e.home, "lead-time.png")
+		if out, code := e.run(t, append([]string{"-p", "42", "-o", graph}, args...)...); code != 0 {
+			t.Fatalf("%v graph exited with %d:\n%s", args, code, out)
+		}
+		if stat, err := os.Stat(graph); err != nil || stat.Size() == 0 {
+			t.Errorf("%v graph not written: %v", args, err)
+		}
+	}
+
+	if out, code := e.run(t, "-p", "42", "-by-label"); code != exitUsage {
+		t.Errorf("-by-label without -lead-time exited with %d, want %d:\n%s", code, exitUsage, out)
+	}
+
+	// the previous month alone is recomputed without labels: its label rows are replaced
+	out, code = e.run(t, "-p", "42", "-lead-time", "-from", from, "-to", to)
+	if code != 0 {
+		t.Fatalf("lead time collect of a period exited with %d:\n%s", code, out)
+	}
+	period := lastMonth.StdTime().Format("2006-01")
+	if !strings.Contains(out, "closed from "+period+" to "+period) {
+		t.Errorf("lead time output = %q, want the period %s", out, period)
+	}
+	leadTimes, err = s.GetLeadTimes(ctx, sqlite.EntityProject, project.Key, lastMonth, carbon.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leadTimes) != 1 || leadTimes[0].Label != "" || leadTimes[0].Closed != 2 {
+		t.Errorf("lead times = %+v, want those of all the issues only", leadTimes)
+	}
+	if out, code := e.run(t, "-p", "42", "-from", from); code != exitUsage {
+		t.Errorf("-from without -backfill or -lead-time exited with %d, want %d:\n%s", code, exitUsage, out)
+	}
+}
+
+func TestCLIOpenIssueAges(t *testing.T) {
+	e := newCLIEnv(t)
+	e.srv.AddIssues(42,
+		gitlabtest.Issue{CreatedAt: time.Now().AddDate(0, 0, -10)},
+		gitlabtest.Issue{CreatedAt: time.Now().AddDate(0, 0, -400)},
+	)
+
+	// the ages are recorded on every collection
+	for _, args := range [][]string{{"-p", "grp/proj"}, {"-instance"}, {"-series", "all", "-p", "grp/proj"}} {
+		if out, code := e.run(t, args...); code != 0 {
+			t.Fatalf("%v collect exited with %d:\n%s", args, code, out)
+		}
+	}
+	ctx := context.Background()
+	s, err := sqlite.NewStorage(e.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Close() }()
+	project, err := s.GetProjectByPath(ctx, 0, "grp/proj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	series, err := s.GetSeries(ctx, "all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	begin, end := carbon.Now().StartOfMonth(), carbon.Now().AddDay()
+	instanceID, err := s.GetInstanceID(ctx, e.srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entity := range []struct {
+		entityType string
+		key        int64
+	}{{sqlite.EntityProject, project.Key}, {sqlite.EntityInstance, instanceID}, {sqlite.EntitySeries, series.ID}} {
+		ages, err := s.GetOpenIssueAges(ctx, entity.entityType, entity.key, begin, end)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the 2 open issues of newCLIEnv are created now
+		if len(ages.DateExecSeries) != 1 || ages.UnderWeek[0] != 2 || ages.WeekToMonth[0] != 1 || ages.OverYear[0] != 1 {
+			t.Errorf("open issue ages of the %s = %+v, want 2 issues under 7 days, 1 under 30 and 1 over a year",
+				entity.entityType, ages)
+		}
+	}
+
+	// the graph shows the completed months
+	lastMonth := carbon.Now().SubMonthNoOverflow().StartOfMonth()
+	ages := sqlite.OpenIssueAges{UnderWeek: 1, WeekToMonth: 2, OverYear: 3}
+	snapshot := sqlite.Snapshot{Opened: 6, Total: 6, DateExec: lastMonth.StdTime()}
+	for _, entity := range []struct {
+		entityType string
+		key        int64
+	}{{sqlite.EntityProject, project.Key}, {sqlite.EntitySeries, series.ID}} {
+		if err := s.AddSnapshotWithAges(ctx, entity.entityType, entity.key, snapshot, ages); err != nil {
+			t.Fatal(err)
+		}
+	}
+	graph := filepath.Join(e.home, "ages.png")
+	if out, code := e.run(t, "-p", "42", "-ages", "-o", graph); code != 0 {
+		t.Fatalf("ages graph exited with %d:\n%s", code, out)
+	}
+	if stat, err := os.Stat(graph); err != nil || stat.Size() == 0 {
+		t.Errorf("ages graph not written: %v", err)
+	}
+
+	if out, code := e.run(t, "-series", "all", "-ages", "-o", graph); code != 0 {
+		t.Fatalf("ages graph of the series exited with %d:\n%s", code, out)
+	}
+
+	if out, code := e.run(t, "-p", "42", "-ages", "-mr", "-o", graph); code != exitUsage {
+		t.Errorf("-ages with -mr exited with %d, want %d:\n%s", code, exitUsage, out)
+	}
+	if out, code := e.run(t, "-p", "42", "-ages"); code != exitUsage {
+		t.Errorf("-ages without -o exited with %d, want %d:\n%s", code, exitUsage, out)
+	}
+}
+
+func TestCLIGitHub(t *testing.T) {
+	e := newCLIEnv(t)
+	gh := githubtest.NewServer(t)
+	gh.RequireToken("gh-secret")
+	gh.AddOrg(githubtest.Org{ID: 5, Login: "grp"})
+	gh.AddRepo(githubtest.Repo{ID: 500, FullName: "grp/proj"})
+	gh.AddIssues(500, githubtest.Issue{State: "open"}, githubtest.Issue{State: "open", PullRequest: true})
+	e.env = []string{"GITHUB_URI=" + gh.URL, "GITHUB_TOKEN=gh-secret"}
+
+	// the same path is collected on GitLab and on GitHub
+	if out, code := e.run(t, "-p", "grp/proj"); code != 0 {
+		t.Fatalf("GitLab collect exited with %d:\n%s", code, out)
+	}
+	if out, code := e.run(t, "-provider", "github", "-p", "grp/proj"); code != 0 {
+		t.Fatalf("GitHub collect exited with %d:\n%s", code, out)
+	}
+	if out, code := e.run(t, "-provider", "github", "-g", "grp", "-crawl"); code != 0 {
+		t.Fatalf("GitHub crawl exited with %d:\n%s", code, out)
+	}
+
+	ctx := context.Background()
+	s, err := sqlite.NewStorage(e.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Close() }()
+	instanceID, err := s.RegisterInstance(ctx, "github", gh.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	project, err := s.GetProjectByPath(ctx, instanceID, "grp/proj")
+	if err != nil || project.ID != 500 {
+		t.Fatalf("GitHub project = %+v, %v, want repository 500", project, err)
+	}
+	if project, err := s.GetProjectByPath(ctx, 0, "grp/proj"); err != nil || project.ID != 42 {
+		t.Errorf("GitLab project = %+v, %v, want project 42", project, err)
+	}
+	stats, err := s.GetEnhancedStatsByProjectID(project.Key, carbon.Now().SubMonth(), carbon.Now().AddMonth())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.TotalOpenedSeries) != 1 || stats.TotalOpenedSeries[0] != 1 {
+		t.Errorf("collected open issues = %v, want [1]", stats.TotalOpenedSeries)
+	}
+	group, err := s.GetGroupByPath(ctx, instanceID, "grp")
+	if err != nil || group.ID != 5 {
+		t.Fatalf("GitHub organisation = %+v, %v, want organisation 5", group, err)
+	}
+	members, err := s.GetGroupProjects(ctx, group.Key)
+	if err != nil || len(members) != 1 || members[0].Key != project.Key {
+		t.Errorf("crawled repositories = %+v, %v, want 1", members, err)
+	}
+
+	if out, code := e.run(t, "-provider", "github", "-mr", "-p", "grp/proj"); code != exitUsage {
+		t.Errorf("-mr with GitHub exited with %d, want %d:\n%s", code, exitUsage, out)
+	}
+}
+
+func TestCLIForgejo(t *testing.T) {
+	e := newCLIEnv(t)
+	srv := giteatest.NewServer(t)
+	srv.RequireToken("fj-secret")
+	srv.AddOrg(giteatest.Org{ID: 7, Name: "grp"})
+	srv.AddRepo(giteatest.Repo{ID: 700, FullName: "grp/proj"})
+	srv.AddIssues(700, giteatest.Issue{State: "open"}, giteatest.Issue{State: "closed"}, giteatest.Issue{State: "closed"})
+	e.env = []string{"FORGEJO_URI=" + srv.URL, "FORGEJO_TOKEN=fj-secret"}
+
+	if out, code := e.run(t, "-provider", "forgejo", "-g", "grp", "-crawl"); code != 0 {
+		t.Fatalf("crawl exited with %d:\n%s", code, out)
+	}
+
+	ctx := context.Background()
+	s, err := sqlite.NewStorage(e.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Close() }()
+	instanceID, err := s.RegisterInstance(ctx, "forgejo", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group, err := s.GetGroupByPath(ctx, instanceID, "grp"); err != nil || group.ID != 7 {
+		t.Fatalf("Forgejo organisation = %+v, %v, want organisation 7", group, err)
+	}
+	project, err := s.GetProject(ctx, instanceID, 700)
+	if err != nil || project.FullPath != "grp/proj" {
+		t.Fatalf("Forgejo repository = %+v, %v, want grp/proj", project, err)
+	}
+	stats, err := s.GetEnhancedStatsByProjectID(project.Key, carbon.Now().SubMonth(), carbon.Now().AddMonth())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.TotalOpenedSeries) != 1 || stats.TotalOpenedSeries[0] != 1 {
+		t.Errorf("collected open issues = %v, want [1]", stats.TotalOpenedSeries)
+	}
+
+	e.env = nil
+	if out, code := e.run(t, "-provider", "gitea", "-p", "grp/proj"); code != exitUsage {
+		t.Errorf("gitea without GITEA_URI exited with %d, want %d:\n%s", code, exitUsage, out)
+	}
+}
+
+// fakeFetcher returns fixed statistics without any HTTP server.
+type fakeFetcher struct {
+	counts gitlab.Counts
+	err    error
+	scopes []gitlab.Scope
+}
+
+func (f *fakeFetcher) IssueStatistics(_ context.Context, scope gitlab.Scope, _ gitlab.IssueFilter) (gitlab.Statistics, error) {
+	f.scopes = append(f.scopes, scope)
+	return gitlab.Statistics{Statistics: gitlab.Statistic{Counts: f.counts}}, f.err
+}
+
+func (f *fakeFetcher) MergeRequestStatistics(_ context.Context, scope gitlab.Scope) (gitlab.MergeRequestCounts, error) {
+	f.scopes = append(f.scopes, scope)
+	return gitlab.MergeRequestCounts{All: f.counts.All, Opened: f.counts.Opened, Closed: f.counts.Closed}, f.err
+}
+
+func TestIssueJob(t *testing.T) {
+	ctx := context.Background()
+	s, err := sqlite.NewStorage(filepath.Join(t.TempDir(), "db.sqlite3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Close() }()
+	if err := s.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fakeFetcher{counts: gitlab.Counts{All: 5, Closed: 2, Opened: 3}}
+	key, err := s.GroupKey(ctx, 0, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := issueJob(f, s, config{groupID: 7, groupKey: key}).Fetch(ctx)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if err := store(ctx); err != nil {
+		t.Fatalf("store error = %v", err)
+	}
+	if len(f.scopes) != 1 || f.scopes[0] != gitlab.GroupScope(7) {
+		t.Errorf("fetched scopes = %v, want [group 7]", f.scopes)
+	}
+	stats, err := s.GetEnhancedStatsByGroupID(key, carbon.Now().SubMonth(), carbon.Now().AddMonth())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.TotalOpenedSeries) != 1 || stats.TotalOpenedSeries[0] != 3 {
+		t.Errorf("stored opened issues = %v, want [3]", stats.TotalOpenedSeries)
+	}
+
+	f.err = gitlab.ErrNotFound
+	if _, err := issueJob(f, s, config{projectID: 1}).Fetch(ctx); !errors.Is(err, gitlab.ErrNotFound) {
+		t.Errorf("Fetch() error = %v, want %v", err, gitlab.ErrNotFound)
+	}
+}
+
+func TestSaveSchemaDrift(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "unknown.jsonl")
+	for _, value := range []string{"1", `{"a":2}`} {
+		saveSchemaDrift(path, gitlab.SchemaDrift{
+			Endpoint: "groups/7/issues_statistics",
+			Fields:   map[string]json.RawMessage{"statistics.counts.archived": json.RawMessage(value)},
+		})
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("saved lines = %q, want 2", lines)
+	}
+	var saved struct {
+		Endpoint string                     `json:"endpoint"`
+		Fields   map[string]json.RawMessage `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Endpoint != "groups/7/issues_statistics" || string(saved.Fields["statistics.counts.archived"]) != `{"a":2}` {
+		t.Errorf("saved drift = %+v, want the raw value of statistics.counts.archived", saved)
+	}
+}
+
+func TestEntityFlag(t *testing.T) {
+	for _, tt := range []struct {
+		value string
+		id    int
+		path  string
+		err   error
+	}{
+		{value: "42", id: 42},
+		{value: "/grp/sub/", path: "grp/sub"},
+		{value: "grp/2024", path: "grp/2024"},
+		{value: "0", err: errInvalidID},
+		{value: "-3", err: errInvalidID},
+		{value: "/2024", err: errDigitsPath},
+		{value: " / ", err: errEmptyPath},
+	} {
+		var id int
+		var path string
+		err := entityFlag{id: &id, path: &path}.Set(tt.value)
+		if !errors.Is(err, tt.err) || id != tt.id || path != tt.path {
+			t.Errorf("Set(%q) = %d, %q, %v, want %d, %q, %v", tt.value, id, path, err, tt.id, tt.path, tt.err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/golang-module/carbon/v2"
+	"github.com/sgaunet/gitlab-stats/pkg/collector"
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+	"github.com/sgaunet/gitlab-stats/pkg/graphissues"
+	"github.com/sgaunet/gitlab-stats/pkg/storage/sqlite"
+	"github.com/sirupsen/logrus"
+)
+
+func collectMergeRequests(ctx context.Context, s *sqlite.Storage, f gitlab.StatisticsFetcher, cfg config) {
+	runJob(ctx, mergeRequestJob(f, s, cfg), cfg)
+}
+
+// mergeRequestJob returns the job collecting the merge request statistics of the entity of cfg.
+func mergeRequestJob(f gitlab.StatisticsFetcher, s *sqlite.Storage, cfg config) collector.Job {
+	return collector.Job{
+		Name: describeEntity(cfg),
+		Fetch: func(ctx context.Context) (collector.Store, error) {
+			counts, err := f.MergeRequestStatistics(ctx, scopeOf(cfg))
+			if err != nil {
+				return nil, err
+			}
+			dateExec := carbon.Now()
+			stats := sqlite.MergeRequestStats{
+				Total:  int64(counts.All),
+				Opened: int64(counts.Opened),
+				Merged: int64(counts.Merged),
+				Closed: int64(counts.Closed),
+				Locked: int64(counts.Locked),
+			}
+
+			return func(ctx context.Context) error {
+				if cfg.projectID != 0 {
+					return s.AddProjectMergeRequestStats(ctx, cfg.projectKey, stats, dateExec)
+				}
+				return s.AddGroupMergeRequestStats(ctx, cfg.groupKey, stats, dateExec)
+			}, nil
+		},
+	}
+}
+
+func generateMergeRequestGraph(s *sqlite.Storage, cfg config) {
+	begindate := carbon.CreateFromStdTime(s.Now()).AddMonths(-cfg.sinceMonth).StartOfMonth()
+	enddate := carbon.CreateFromStdTime(s.Now()).StartOfMonth()
+
+	logrus.Infoln("retrieve merge request stats from database")
+	var series *sqlite.MergeRequestSeries
+	var err error
+	if cfg.projectID != 0 {
+		series, err = s.GetMergeRequestStatsByProjectID(context.Background(), cfg.projectKey, begindate, enddate)
+	} else {
+		series, err = s.GetMergeRequestStatsByGroupID(context.Background(), cfg.groupKey, begindate, enddate)
+	}
+	if err != nil {
+		logrus.Errorln("error when retrieving merge request stats: ", err.Error())
+		os.Exit(exitError)
+	}
+	if series == nil || len(series.DateExecSeries) == 0 {
+		logrus.Errorf("No merge request data found in database for %s. Please collect statistics first before generating a chart.",
+			describeEntity(cfg))
+		os.Exit(exitError)
+	}
+
+	err = graphissues.CreateMergeRequestGraph(
+		cfg.graphFilePath,
+		series.OpenedSeries,
+		series.MergedDuringPeriod,
+		series.ClosedDuringPeriod,
+		series.DateExecSeries,
+	)
+	if err != nil {
+		logrus.Errorln("error when creating merge request graph: ", err.Error())
+		os.Exit(exitError)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitea"
+	"github.com/sgaunet/gitlab-stats/pkg/github"
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+	"github.com/sgaunet/gitlab-stats/pkg/provider"
+	"github.com/sgaunet/gitlab-stats/pkg/storage/sqlite"
+	"github.com/sirupsen/logrus"
+)
+
+// newProvider returns the forge given by -provider, configured from the
+// environment and the CLI options.
+func newProvider(cfg config) provider.Provider {
+	switch cfg.provider {
+	case provider.GitHub:
+		c := github.NewClient()
+		c.SetEndpoint(github.APIEndpointOf(cfg.baseURL))
+		c.SetHTTPClient(cfg.httpClient)
+		c.SetRetryPolicy(retryPolicy(cfg))
+		if token, ok := forgeToken(cfg, "GITHUB_TOKEN", "GH_TOKEN"); ok {
+			c.SetToken(token)
+		}
+		return provider.New(provider.GitHub, cfg.baseURL, c)
+	case provider.Gitea, provider.Forgejo:
+		c := gitea.NewClient(cfg.baseURL)
+		c.SetHTTPClient(cfg.httpClient)
+		if token, ok := forgeToken(cfg, strings.ToUpper(string(cfg.provider))+"_TOKEN", "GITEA_TOKEN"); ok {
+			c.SetToken(token)
+		}
+		return provider.New(cfg.provider, cfg.baseURL, c)
+	default:
+		return provider.New(provider.GitLab, cfg.baseURL, newGitlabService(cfg))
+	}
+}
+
+// issueLister returns the client of the provider to list issues, which only
+// GitLab does: the options that need it are rejected for other forges.
+func issueLister(p provider.Provider) gitlab.IssueLister {
+	l, ok := provider.IssueLister(p)
+	if !ok {
+		logrus.Errorf("listing issues is not supported with -provider %s", p.Kind())
+		os.Exit(exitUsage)
+	}
+	return l
+}
+
+// forgeToken returns the token of another forge than GitLab, read from the
+// sources of newForgeTokenSource. Without token, requests are anonymous.
+func forgeToken(cfg config, envs ...string) (string, bool) {
+	token, err := newForgeTokenSource(cfg, envs...).Token()
+	switch {
+	case errors.Is(err, gitlab.ErrNoToken):
+		logrus.Warnf("no %s token found, requests are anonymous: set %s, -token-file or -token-command", cfg.provider, envs[0])
+		return "", false
+	case err != nil:
+		logrus.Errorf("failed to get %s token: %v", cfg.provider, err)
+		os.Exit(exitError)
+	}
+	logrus.Infof("%s token read from %s", cfg.provider, token.Source)
+	return token.Value, true
+}
+
+// newForgeTokenSource returns the sources of the token of another forge than
+// GitLab in order of precedence: -token-file, -token-command, then the
+// environment variables envs.
+func newForgeTokenSource(cfg config, envs ...string) gitlab.TokenSource {
+	var sources []gitlab.TokenSource
+	if cfg.tokenFile != "" {
+		sources = append(sources, gitlab.NewFileTokenSource(cfg.tokenFile))
+	}
+	if cfg.tokenCommand != "" {
+		sources = append(sources, gitlab.NewCommandTokenSource(cfg.tokenCommand))
+	}
+	for _, env := range slices.Compact(envs) {
+		sources = append(sources, gitlab.NewEnvTokenSource(env))
+	}
+	return gitlab.NewChainTokenSource(sources...)
+}
+
+// registerInstance records the instance of the provider, so that the projects
+// and groups are stored with the forge and base URL they belong to. Graphs
+// only read the database: the instance must have been collected before.
+func registerInstance(ctx context.Context, s *sqlite.Storage, p provider.Provider, cfg *config) {
+	var err error
+	if cfg.graphFilePath != "" {
+		cfg.instanceID, err = s.FindInstance(ctx, string(p.Kind()), p.BaseURL())
+		if errors.Is(err, sqlite.ErrInstanceNotFound) {
+			logrus.Errorf("No statistics of %s found in database. Please collect statistics first before generating a chart.",
+				p.BaseURL())
+			os.Exit(exitError)
+		}
+	} else {
+		cfg.instanceID, err = s.RegisterInstance(ctx, string(p.Kind()), p.BaseURL())
+	}
+	if err != nil {
+		logrus.Errorln(err.Error())
+		os.Exit(exitError)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+	"github.com/sgaunet/gitlab-stats/pkg/storage/sqlite"
+	"github.com/sirupsen/logrus"
+)
+
+var errSeriesOfAnotherInstance = errors.New("series of another instance")
+
+// resolveSeries completes the configuration with the series given by -series.
+// The entity and the filter of an existing series are used when they are not
+// given on the command line, and another filter is refused. In collection
+// mode, the series is created, so that the next runs only need -series; without
+// -p, -g or -instance, it is created for the project of the git remote.
+func resolveSeries(ctx context.Context, s *sqlite.Storage, meta gitlab.MetadataFetcher, cfg *config) {
+	if cfg.seriesName == "" {
+		return
+	}
+	series, err := s.GetSeries(ctx, cfg.seriesName)
+	if err != nil && !errors.Is(err, sqlite.ErrSeriesNotFound) {
+		logrus.Errorln(err.Error())
+		os.Exit(exitError)
+	}
+	exists := err == nil
+	cfg.seriesID = series.ID
+
+	if !cfg.hasProject() && !cfg.hasGroup() && !cfg.instance {
+		switch {
+		case !exists && cfg.graphFilePath != "":
+			logrus.Errorf("series %q not found: collect statistics first", cfg.seriesName)
+			os.Exit(exitError)
+		case !exists:
+			detectProjectIfNeeded(ctx, s, meta, cfg)
+		default:
+			if err := setSeriesEntity(ctx, s, cfg, series); err != nil {
+				logrus.Errorln(err.Error())
+				os.Exit(exitError)
+			}
+		}
+	}
+	if cfg.filter.IsZero() && exists {
+		if err := json.Unmarshal([]byte(series.Filter), &cfg.filter); err != nil {
+			logrus.Errorf("invalid filter of series %q: %v", cfg.seriesName, err)
+			os.Exit(exitError)
+		}
+	}
+	if cfg.graphFilePath != "" {
+		if !exists {
+			logrus.Errorf("series %q not found: collect statistics first", cfg.seriesName)
+			os.Exit(exitError)
+		}
+		return
+	}
+
+	filter, err := json.Marshal(cfg.filter)
+	if err != nil {
+		logrus.Errorln(err.Error())
+		os.Exit(exitError)
+	}
+	entityType, entityID := seriesEntity(*cfg)
+	series, err = s.SaveSeries(ctx, cfg.seriesName, entityType, entityID, string(filter))
+	if err != nil {
+		logrus.Errorln(err.Error())
+		os.Exit(exitError)
+	}
+	cfg.seriesID = series.ID
+	logrus.Infof("series %q: %s %d with filter %s", cfg.seriesName, entityType, entityID, filter)
+}
+
+// setSeriesEntity sets the entity of cfg to the entity of the series, which
+// must belong to the instance of cfg.
+func setSeriesEntity(ctx context.Context, s *sqlite.Storage, cfg *config, series sqlite.Series) error {
+	instanceID := series.EntityID
+	switch series.EntityType {
+	case sqlite.EntityProject:
+		project, err := s.GetProjectByKey(ctx, series.EntityID)
+		if err != nil {
+			return fmt.Errorf("invalid project of series %q: %w", series.Name, err)
+		}
+		setProject(cfg, project)
+		instanceID = project.InstanceID
+	case sqlite.EntityGroup:
+		group, err := s.GetGroupByKey(ctx, series.EntityID)
+		if err != nil {
+			return fmt.Errorf("invalid group of series %q: %w", series.Name, err)
+		}
+		setGroup(cfg, group)
+		instanceID = group.InstanceID
+	case sqlite.EntityInstance:
+		cfg.instance = true
+	}
+	if instanceID == cfg.instanceID {
+		return nil
+	}
+	instance, err := s.GetInstance(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: series %q belongs to %s, not to %s",
+		errSeriesOfAnotherInstance, series.Name, instance.URI, cfg.baseURL)
+}
+
+func seriesEntity(cfg config) (string, int64) {
+	switch {
+	case cfg.instance:
+		return sqlite.EntityInstance, cfg.instanceID
+	case cfg.projectID != 0:
+		return sqlite.EntityProject, cfg.projectKey
+	default:
+		return sqlite.EntityGroup, cfg.groupKey
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"sync"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+	"github.com/sirupsen/logrus"
+)
+
+// newTokenSource returns the sources of the GitLab token in order of precedence:
+// -token-file, -token-command, GITLAB_TOKEN, CI_JOB_TOKEN and the glab configuration
+// of the host of GITLAB_URI. The token is read once, on the first request, and
+// its source is printed.
+func newTokenSource(cfg config) gitlab.TokenSource {
+	var sources []gitlab.TokenSource
+	if cfg.tokenFile != "" {
+		sources = append(sources, gitlab.NewFileTokenSource(cfg.tokenFile))
+	}
+	if cfg.tokenCommand != "" {
+		sources = append(sources, gitlab.NewCommandTokenSource(cfg.tokenCommand))
+	}
+	sources = append(sources, gitlab.NewEnvTokenSource("GITLAB_TOKEN"), gitlab.NewJobTokenSource())
+	if u, err := url.Parse(os.Getenv("GITLAB_URI")); err == nil && u.Hostname() != "" {
+		sources = append(sources, gitlab.NewGlabTokenSource("", u.Hostname()))
+	}
+	return &onceTokenSource{source: gitlab.NewChainTokenSource(sources...)}
+}
+
+// onceTokenSource reads the token once, so that a token command is not run
+// for each GitLab service of the run.
+type onceTokenSource struct {
+	source gitlab.TokenSource
+	once   sync.Once
+	token  gitlab.Token
+	err    error
+}
+
+func (s *onceTokenSource) Token() (gitlab.Token, error) {
+	s.once.Do(func() {
+		s.token, s.err = s.source.Token()
+		switch {
+		case errors.Is(s.err, gitlab.ErrNoToken):
+			logrus.Warnln("no GitLab token found, requests are anonymous: " +
+				"set GITLAB_TOKEN, -token-file or -token-command")
+		case s.err != nil:
+			logrus.Errorf("failed to get GitLab token: %v", s.err)
+		default:
+			logrus.Infof("GitLab token read from %s", s.token.Source)
+		}
+	})
+	return s.token, s.err
+}
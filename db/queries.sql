@@ -19,16 +19,6 @@ SELECT id,project_name FROM projects WHERE id=?;
 -- name: GetGroup :one
 SELECT id,group_name FROM groups WHERE id=?;
 
--- name: InsertNewProject :one
-INSERT INTO projects (id,project_name)
-VALUES(?,?)
-RETURNING id;
-
--- name: InsertNewGroup :one
-INSERT INTO groups (id,group_name)
-VALUES(?,?)
-RETURNING id;
-
 -- name: GetStatsOfProject :many
 SELECT id,total,closed,opened,date_exec FROM stats
 WHERE id IN (SELECT statsId FROM stats_projects WHERE projectId=?)
@@ -73,7 +63,8 @@ SELECT
   MAX(closed) as current_closed,
   LAG(MAX(total), 1, 0) OVER(ORDER BY strftime('%Y-%m', date_exec)) as prev_total,
   LAG(MAX(closed), 1, 0) OVER(ORDER BY strftime('%Y-%m', date_exec)) as prev_closed,
-  MAX(date_exec) as date_exec
+  MAX(date_exec) as date_exec,
+  MAX(reconstructed) as reconstructed
 FROM stats
 WHERE 
   date_exec >= sqlc.arg(begindate) AND date_exec <= sqlc.arg(enddate)
@@ -89,10 +80,237 @@ SELECT
   MAX(closed) as current_closed,
   LAG(MAX(total), 1, 0) OVER(ORDER BY strftime('%Y-%m', date_exec)) as prev_total,
   LAG(MAX(closed), 1, 0) OVER(ORDER BY strftime('%Y-%m', date_exec)) as prev_closed,
-  MAX(date_exec) as date_exec
+  MAX(date_exec) as date_exec,
+  MAX(reconstructed) as reconstructed
 FROM stats
 WHERE 
   date_exec >= sqlc.arg(begindate) AND date_exec <= sqlc.arg(enddate)
   AND id IN (SELECT statsId FROM stats_groups WHERE groupId=sqlc.arg(groupId))
 GROUP BY strftime('%Y-%m', date_exec)
 ORDER BY period;
+
+-- name: GetSeriesByName :one
+SELECT id,name,entity_type,entity_id,filter FROM series WHERE name=?;
+
+-- name: ListSeries :many
+SELECT id,name,entity_type,entity_id,filter FROM series ORDER BY name;
+
+-- name: InsertNewSeries :one
+INSERT INTO series (name,entity_type,entity_id,filter)
+VALUES(?,?,?,?)
+RETURNING id;
+
+-- name: InsertStatsSeries :one
+INSERT INTO stats_series (seriesId,statsId)
+VALUES(?,?)
+RETURNING id;
+
+-- name: GetEnhancedStatsBySeriesID :many
+SELECT 
+  strftime('%Y-%m', date_exec) as period,
+  MAX(total) as total_opened,
+  MAX(opened) as current_opened,
+  MAX(closed) as current_closed,
+  LAG(MAX(total), 1, 0) OVER(ORDER BY strftime('%Y-%m', date_exec)) as prev_total,
+  LAG(MAX(closed), 1, 0) OVER(ORDER BY strftime('%Y-%m', date_exec)) as prev_closed,
+  MAX(date_exec) as date_exec,
+  MAX(reconstructed) as reconstructed
+FROM stats
+WHERE 
+  date_exec >= sqlc.arg(begindate) AND date_exec <= sqlc.arg(enddate)
+  AND id IN (SELECT statsId FROM stats_series WHERE seriesId=sqlc.arg(seriesId))
+GROUP BY strftime('%Y-%m', date_exec)
+ORDER BY period;
+
+-- name: GetInstanceByURI :one
+SELECT id,uri,provider FROM instances WHERE uri=?;
+
+-- name: InsertNewInstance :one
+INSERT INTO instances (uri,provider)
+VALUES(?,?)
+RETURNING id;
+
+-- name: InsertStatsInstances :one
+INSERT INTO stats_instances (instanceId,statsId)
+VALUES(?,?)
+RETURNING id;
+
+-- name: GetEnhancedStatsByInstanceID :many
+SELECT 
+  strftime('%Y-%m', date_exec) as period,
+  MAX(total) as total_opened,
+  MAX(opened) as current_opened,
+  MAX(closed) as current_closed,
+  LAG(MAX(total), 1, 0) OVER(ORDER BY strftime('%Y-%m', date_exec)) as prev_total,
+  LAG(MAX(closed), 1, 0) OVER(ORDER BY strftime('%Y-%m', date_exec)) as prev_closed,
+  MAX(date_exec) as date_exec,
+  MAX(reconstructed) as reconstructed
+FROM stats
+WHERE 
+  date_exec >= sqlc.arg(begindate) AND date_exec <= sqlc.arg(enddate)
+  AND id IN (SELECT statsId FROM stats_instances WHERE instanceId=sqlc.arg(instanceId))
+GROUP BY strftime('%Y-%m', date_exec)
+ORDER BY period;
+
+-- name: InsertNewMRStats :one
+INSERT INTO mr_stats (total,opened,merged,closed,locked,date_exec)
+VALUES(?,?,?,?,?,?)
+RETURNING id;
+
+-- name: InsertMRStatsProjects :one
+INSERT INTO mr_stats_projects (projectId,mrStatsId)
+VALUES(?,?)
+RETURNING id;
+
+-- name: InsertMRStatsGroups :one
+INSERT INTO mr_stats_groups (groupId,mrStatsId)
+VALUES(?,?)
+RETURNING id;
+
+-- name: GetMRStatsByProjectID :many
+SELECT 
+  strftime('%Y-%m', date_exec) as period,
+  MAX(opened) as current_opened,
+  MAX(merged) as current_merged,
+  MAX(closed) as current_closed,
+  LAG(MAX(merged), 1, 0) OVER(ORDER BY strftime('%Y-%m', date_exec)) as prev_merged,
+  LAG(MAX(closed), 1, 0) OVER(ORDER BY strftime('%Y-%m', date_exec)) as prev_closed,
+  MAX(date_exec) as date_exec
+FROM mr_stats
+WHERE 
+  date_exec >= sqlc.arg(begindate) AND date_exec <= sqlc.arg(enddate)
+  AND id IN (SELECT mrStatsId FROM mr_stats_projects WHERE projectId=sqlc.arg(projectId))
+GROUP BY strftime('%Y-%m', date_exec)
+ORDER BY period;
+
+-- name: GetMRStatsByGroupID :many
+SELECT 
+  strftime('%Y-%m', date_exec) as period,
+  MAX(opened) as current_opened,
+  MAX(merged) as current_merged,
+  MAX(closed) as current_closed,
+  LAG(MAX(merged), 1, 0) OVER(ORDER BY strftime('%Y-%m', date_exec)) as prev_merged,
+  LAG(MAX(closed), 1, 0) OVER(ORDER BY strftime('%Y-%m', date_exec)) as prev_closed,
+  MAX(date_exec) as date_exec
+FROM mr_stats
+WHERE 
+  date_exec >= sqlc.arg(begindate) AND date_exec <= sqlc.arg(enddate)
+  AND id IN (SELECT mrStatsId FROM mr_stats_groups WHERE groupId=sqlc.arg(groupId))
+GROUP BY strftime('%Y-%m', date_exec)
+ORDER BY period;
+
+-- name: UpsertGroupProject :exec
+INSERT INTO group_projects (groupId,projectId,last_seen)
+VALUES(?,?,?)
+ON CONFLICT(groupId,projectId) DO UPDATE SET last_seen=excluded.last_seen;
+
+-- name: GetGroupProjects :many
+SELECT p.id,p.instanceId,p.remote_id,p.project_name,p.full_path,gp.last_seen FROM group_projects gp
+INNER JOIN projects p ON p.id=gp.projectId
+WHERE gp.groupId=?
+ORDER BY p.remote_id;
+
+-- name: UpsertProject :one
+INSERT INTO projects (instanceId,remote_id,project_name,full_path)
+VALUES(?,?,?,?)
+ON CONFLICT(instanceId,remote_id) DO UPDATE SET project_name=excluded.project_name, full_path=excluded.full_path
+RETURNING id;
+
+-- name: UpsertGroup :one
+INSERT INTO groups (instanceId,remote_id,group_name,full_path)
+VALUES(?,?,?,?)
+ON CONFLICT(instanceId,remote_id) DO UPDATE SET group_name=excluded.group_name, full_path=excluded.full_path
+RETURNING id;
+
+-- name: GetProjectByKey :one
+SELECT id,instanceId,remote_id,project_name,full_path FROM projects WHERE id=?;
+
+-- name: GetGroupByKey :one
+SELECT id,instanceId,remote_id,group_name,full_path FROM groups WHERE id=?;
+
+-- name: GetProjectByRemoteID :one
+SELECT id,instanceId,remote_id,project_name,full_path FROM projects WHERE instanceId=? AND remote_id=?;
+
+-- name: GetGroupByRemoteID :one
+SELECT id,instanceId,remote_id,group_name,full_path FROM groups WHERE instanceId=? AND remote_id=?;
+
+-- name: GetProjectByPath :one
+SELECT id,instanceId,remote_id,project_name,full_path FROM projects
+WHERE instanceId=? AND full_path=? COLLATE NOCASE
+ORDER BY id LIMIT 1;
+
+-- name: GetGroupByPath :one
+SELECT id,instanceId,remote_id,group_name,full_path FROM groups
+WHERE instanceId=? AND full_path=? COLLATE NOCASE
+ORDER BY id LIMIT 1;
+
+-- name: GetFirstInstanceOfProvider :one
+SELECT id,uri,provider FROM instances WHERE provider=? ORDER BY id LIMIT 1;
+
+-- name: GetInstanceByID :one
+SELECT id,uri,provider FROM instances WHERE id=?;
+
+-- name: UpdateInstanceURI :exec
+UPDATE instances SET uri=? WHERE id=?;
+
+-- name: InsertReconstructedStats :one
+INSERT INTO stats (total,closed,opened,date_exec,reconstructed)
+VALUES(?,?,?,?,1)
+RETURNING id;
+
+-- name: GetSnapshotsOfProject :many
+SELECT s.id,s.date_exec,s.reconstructed FROM stats s
+INNER JOIN stats_projects sp ON sp.statsId=s.id
+WHERE sp.projectId=?
+ORDER BY s.date_exec;
+
+-- name: GetSnapshotsOfGroup :many
+SELECT s.id,s.date_exec,s.reconstructed FROM stats s
+INNER JOIN stats_groups sg ON sg.statsId=s.id
+WHERE sg.groupId=?
+ORDER BY s.date_exec;
+
+-- name: DeleteStats :exec
+DELETE FROM stats WHERE id=?;
+
+-- name: DeleteStatsProjectsOfStats :exec
+DELETE FROM stats_projects WHERE statsId=?;
+
+-- name: DeleteStatsGroupsOfStats :exec
+DELETE FROM stats_groups WHERE statsId=?;
+
+-- name: UpsertLeadTime :exec
+INSERT INTO lead_times (entity_type,entity_id,period,label,closed,p50,p85,p95,date_exec)
+VALUES(?,?,?,?,?,?,?,?,?)
+ON CONFLICT(entity_type,entity_id,period,label) DO UPDATE SET closed=excluded.closed,
+  p50=excluded.p50, p85=excluded.p85, p95=excluded.p95, date_exec=excluded.date_exec;
+
+-- name: DeleteLeadTimes :exec
+DELETE FROM lead_times
+WHERE entity_type=sqlc.arg(entityType) AND entity_id=sqlc.arg(entityId)
+  AND period >= sqlc.arg(beginperiod) AND period <= sqlc.arg(endperiod);
+
+-- name: GetLeadTimes :many
+SELECT period,label,closed,p50,p85,p95 FROM lead_times
+WHERE entity_type=sqlc.arg(entityType) AND entity_id=sqlc.arg(entityId)
+  AND period >= sqlc.arg(beginperiod) AND period <= sqlc.arg(endperiod)
+ORDER BY period,label;
+
+-- name: InsertOpenIssueAges :one
+INSERT INTO stats_open_issue_ages (statsId,under_7_days,days_7_to_30,days_30_to_90,days_90_to_365,over_365_days)
+VALUES(?,?,?,?,?,?)
+RETURNING id;
+
+-- name: GetOpenIssueAges :many
+SELECT a.under_7_days,a.days_7_to_30,a.days_30_to_90,a.days_90_to_365,a.over_365_days,s.date_exec
+FROM stats_open_issue_ages a
+INNER JOIN stats s ON s.id=a.statsId
+WHERE
+  s.date_exec >= sqlc.arg(begindate) AND s.date_exec <= sqlc.arg(enddate)
+  AND a.statsId IN (
+    SELECT statsId FROM stats_projects WHERE sqlc.arg(entityType)='project' AND projectId=sqlc.arg(entityId)
+    UNION SELECT statsId FROM stats_groups WHERE sqlc.arg(entityType)='group' AND groupId=sqlc.arg(entityId)
+    UNION SELECT statsId FROM stats_instances WHERE sqlc.arg(entityType)='instance' AND instanceId=sqlc.arg(entityId)
+    UNION SELECT statsId FROM stats_series WHERE sqlc.arg(entityType)='series' AND seriesId=sqlc.arg(entityId)
+  )
+ORDER BY s.date_exec;
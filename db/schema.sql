@@ -1,21 +1,11 @@
 CREATE TABLE IF NOT EXISTS "schema_migrations" (version varchar(128) primary key);
-CREATE TABLE projects (
-    id integer PRIMARY KEY NOT NULL,
-    project_name character varying(255) NOT NULL
-);
-CREATE INDEX projects_id_idx       ON projects (id) ;
-CREATE TABLE groups (
-    id integer PRIMARY KEY NOT NULL,
-    group_name character varying(255) NOT NULL
-);
-CREATE INDEX groups_id_idx       ON groups (id) ;
 CREATE TABLE stats (
     id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
     date_exec timestamp NOT NULL,
     total integer NOT NULL,
     closed integer NOT NULL,
     opened integer NOT NULL
-);
+, reconstructed boolean NOT NULL DEFAULT 0);
 CREATE INDEX stats_id_idx       ON stats (id) ;
 CREATE TABLE stats_projects (
     id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
@@ -41,6 +31,157 @@ CREATE TABLE stats_groups (
 	  REFERENCES stats(id)
 );
 CREATE INDEX stats_groups_id_idx       ON stats_groups (id) ;
+CREATE TABLE series (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name character varying(255) NOT NULL UNIQUE,
+    entity_type character varying(16) NOT NULL,
+    entity_id integer NOT NULL,
+    filter text NOT NULL
+);
+CREATE INDEX series_name_idx       ON series (name) ;
+CREATE TABLE stats_series (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    seriesId integer NOT NULL,
+    statsId integer NOT NULL,
+    CONSTRAINT fk_seriesid
+      FOREIGN KEY(seriesId)
+	  REFERENCES series(id),
+    CONSTRAINT fk_statsid
+      FOREIGN KEY(statsId)
+	  REFERENCES stats(id)
+);
+CREATE INDEX stats_series_id_idx       ON stats_series (id) ;
+CREATE TABLE instances (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    uri character varying(255) NOT NULL UNIQUE
+, provider character varying(16) NOT NULL DEFAULT 'gitlab');
+CREATE INDEX instances_uri_idx       ON instances (uri) ;
+CREATE TABLE stats_instances (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    instanceId integer NOT NULL,
+    statsId integer NOT NULL,
+    CONSTRAINT fk_instanceid
+      FOREIGN KEY(instanceId)
+	  REFERENCES instances(id),
+    CONSTRAINT fk_statsid
+      FOREIGN KEY(statsId)
+	  REFERENCES stats(id)
+);
+CREATE INDEX stats_instances_id_idx       ON stats_instances (id) ;
+CREATE TABLE mr_stats (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    date_exec timestamp NOT NULL,
+    total integer NOT NULL,
+    opened integer NOT NULL,
+    merged integer NOT NULL,
+    closed integer NOT NULL,
+    locked integer NOT NULL
+);
+CREATE INDEX mr_stats_id_idx       ON mr_stats (id) ;
+CREATE TABLE mr_stats_projects (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    projectId integer NOT NULL,
+    mrStatsId integer NOT NULL,
+    CONSTRAINT fk_projectid
+      FOREIGN KEY(projectId)
+	  REFERENCES projects(id),
+    CONSTRAINT fk_mrstatsid
+      FOREIGN KEY(mrStatsId)
+	  REFERENCES mr_stats(id)
+);
+CREATE INDEX mr_stats_projects_id_idx       ON mr_stats_projects (id) ;
+CREATE TABLE mr_stats_groups (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    groupId integer NOT NULL,
+    mrStatsId integer NOT NULL,
+    CONSTRAINT fk_groupid
+      FOREIGN KEY(groupId)
+	  REFERENCES groups(id),
+    CONSTRAINT fk_mrstatsid
+      FOREIGN KEY(mrStatsId)
+	  REFERENCES mr_stats(id)
+);
+CREATE INDEX mr_stats_groups_id_idx       ON mr_stats_groups (id) ;
+CREATE TABLE group_projects (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    groupId integer NOT NULL,
+    projectId integer NOT NULL,
+    last_seen timestamp NOT NULL,
+    CONSTRAINT fk_groupid
+      FOREIGN KEY(groupId) 
+	  REFERENCES groups(id),
+    CONSTRAINT fk_projectid
+      FOREIGN KEY(projectId) 
+	  REFERENCES projects(id),
+    CONSTRAINT uq_group_project
+      UNIQUE(groupId, projectId)
+);
+CREATE INDEX group_projects_groupid_idx       ON group_projects (groupId) ;
+CREATE TABLE IF NOT EXISTS "projects" (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    instanceId integer NOT NULL,
+    remote_id integer NOT NULL,
+    project_name character varying(255) NOT NULL,
+    full_path character varying(255) NOT NULL DEFAULT '',
+    CONSTRAINT fk_instanceid
+      FOREIGN KEY(instanceId)
+	  REFERENCES instances(id),
+    CONSTRAINT uq_instance_project
+      UNIQUE(instanceId, remote_id)
+);
+CREATE INDEX projects_id_idx       ON projects (id) ;
+CREATE INDEX projects_full_path_idx       ON projects (full_path) ;
+CREATE TABLE IF NOT EXISTS "groups" (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    instanceId integer NOT NULL,
+    remote_id integer NOT NULL,
+    group_name character varying(255) NOT NULL,
+    full_path character varying(255) NOT NULL DEFAULT '',
+    CONSTRAINT fk_instanceid
+      FOREIGN KEY(instanceId)
+	  REFERENCES instances(id),
+    CONSTRAINT uq_instance_group
+      UNIQUE(instanceId, remote_id)
+);
+CREATE INDEX groups_id_idx       ON groups (id) ;
+CREATE INDEX groups_full_path_idx       ON groups (full_path) ;
+CREATE TABLE lead_times (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    entity_type character varying(16) NOT NULL,
+    entity_id integer NOT NULL,
+    period character varying(7) NOT NULL,
+    label character varying(255) NOT NULL DEFAULT '',
+    closed integer NOT NULL,
+    p50 real NOT NULL,
+    p85 real NOT NULL,
+    p95 real NOT NULL,
+    date_exec timestamp NOT NULL,
+    CONSTRAINT uq_lead_time
+      UNIQUE(entity_type, entity_id, period, label)
+);
+CREATE TABLE stats_open_issue_ages (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    statsId integer NOT NULL UNIQUE,
+    under_7_days integer NOT NULL,
+    days_7_to_30 integer NOT NULL,
+    days_30_to_90 integer NOT NULL,
+    days_90_to_365 integer NOT NULL,
+    over_365_days integer NOT NULL,
+    CONSTRAINT fk_statsid
+      FOREIGN KEY(statsId) 
+	  REFERENCES stats(id)
+);
 -- Dbmate schema migrations
 INSERT INTO "schema_migrations" (version) VALUES
-  ('20231125210000');
+  ('20231125210000'),
+  ('20261017100000'),
+  ('20261017110000'),
+  ('20261017115000'),
+  ('20261017120000'),
+  ('20261017130000'),
+  ('20261017140000'),
+  ('20261017150000'),
+  ('20261017160000'),
+  ('20261017170000'),
+  ('20261017180000'),
+  ('20261017190000');
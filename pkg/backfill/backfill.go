@@ -0,0 +1,65 @@
+// Package backfill rebuilds the past daily snapshots of the issues of a
+// project or group from the creation and closing dates of its issues, for
+// the days on which no statistics were collected.
+//
+// The reconstruction is an approximation: the issues deleted or moved to
+// another project since are missing, and a reopened issue is counted as
+// closed from its last closing date only.
+package backfill
+
+import (
+	"slices"
+	"time"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+// Snapshot is the number of issues at the end of a day.
+type Snapshot struct {
+	// Date is the last instant of the day.
+	Date   time.Time
+	Opened int
+	Closed int
+	Total  int
+}
+
+// Reconstruct returns a snapshot per day, from the day of from to the day of
+// to included, in the location of from. Closed issues without closing date,
+// closed before GitLab recorded it, are counted as closed since their creation.
+func Reconstruct(issues []gitlab.Issue, from time.Time, to time.Time) []Snapshot {
+	created := make([]time.Time, 0, len(issues))
+	closed := make([]time.Time, 0, len(issues))
+	for _, issue := range issues {
+		created = append(created, issue.CreatedAt)
+		switch {
+		case issue.ClosedAt != nil:
+			closed = append(closed, *issue.ClosedAt)
+		case issue.State == "closed":
+			closed = append(closed, issue.CreatedAt)
+		}
+	}
+	slices.SortFunc(created, time.Time.Compare)
+	slices.SortFunc(closed, time.Time.Compare)
+
+	var snapshots []Snapshot
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	last := to.In(from.Location())
+	nCreated, nClosed := 0, 0
+	for !day.After(last) {
+		next := day.AddDate(0, 0, 1)
+		for nCreated < len(created) && created[nCreated].Before(next) {
+			nCreated++
+		}
+		for nClosed < len(closed) && closed[nClosed].Before(next) {
+			nClosed++
+		}
+		snapshots = append(snapshots, Snapshot{
+			Date:   next.Add(-time.Nanosecond),
+			Opened: nCreated - nClosed,
+			Closed: nClosed,
+			Total:  nCreated,
+		})
+		day = next
+	}
+	return snapshots
+}
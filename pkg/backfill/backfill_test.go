@@ -0,0 +1,49 @@
+package backfill_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-stats/pkg/backfill"
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+func TestReconstruct(t *testing.T) {
+	date := func(day int, hour int) time.Time {
+		return time.Date(2024, 3, day, hour, 0, 0, 0, time.UTC)
+	}
+	closedOn := func(day int) *time.Time {
+		d := date(day, 18)
+		return &d
+	}
+	issues := []gitlab.Issue{
+		{State: "closed", CreatedAt: date(2, 10), ClosedAt: closedOn(3)},
+		{State: "opened", CreatedAt: date(1, 8)},
+		{State: "opened", CreatedAt: date(3, 23)},
+		// closed before GitLab recorded the closing date
+		{State: "closed", CreatedAt: date(4, 9)},
+		// created after the range
+		{State: "opened", CreatedAt: date(10, 9)},
+	}
+
+	got := backfill.Reconstruct(issues, date(1, 12), date(4, 0))
+	want := []backfill.Snapshot{
+		{Opened: 1, Closed: 0, Total: 1},
+		{Opened: 2, Closed: 0, Total: 2},
+		{Opened: 2, Closed: 1, Total: 3},
+		{Opened: 2, Closed: 2, Total: 4},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Reconstruct() returned %d snapshots, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		want[i].Date = date(i+2, 0).Add(-time.Nanosecond)
+		if got[i] != want[i] {
+			t.Errorf("Reconstruct()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if got := backfill.Reconstruct(issues, date(5, 0), date(4, 0)); len(got) != 0 {
+		t.Errorf("Reconstruct() of an empty range = %+v, want none", got)
+	}
+}
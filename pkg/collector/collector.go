@@ -0,0 +1,133 @@
+// Package collector runs the collection of many entities concurrently.
+//
+// Jobs are fetched by a bounded pool of workers, which should share a single
+// gitlab.Service so that they throttle together on its rate limiter. The
+// results are written by a single writer, one at a time, so the storage never
+// sees concurrent transactions. A failing job does not stop the others: the
+// outcome of every job is reported in the Summary.
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// DefaultWorkers is the number of workers used when none is configured.
+const DefaultWorkers = 4
+
+// Store writes the result of a job.
+type Store func(ctx context.Context) error
+
+// Job collects the statistics of one entity.
+type Job struct {
+	// Name identifies the entity in the summary, for instance "project 1234".
+	Name string
+	// Fetch retrieves the statistics and returns how to store them.
+	// It is called concurrently with the Fetch of other jobs.
+	Fetch func(ctx context.Context) (Store, error)
+}
+
+// Result is the outcome of a job.
+type Result struct {
+	Name string
+	Err  error
+}
+
+// Summary holds the results of a run, in the order of the jobs.
+type Summary struct {
+	Results []Result
+}
+
+// Succeeded returns the number of jobs which were fetched and stored.
+func (s Summary) Succeeded() int {
+	n := 0
+	for _, r := range s.Results {
+		if r.Err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed returns the results of the jobs which failed.
+func (s Summary) Failed() []Result {
+	var failed []Result
+	for _, r := range s.Results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// Collector runs jobs with a bounded number of workers.
+type Collector struct {
+	workers int
+}
+
+// New creates a new Collector running at most workers jobs at a time.
+func New(workers int) *Collector {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Collector{workers: workers}
+}
+
+type fetched struct {
+	index int
+	store Store
+	err   error
+}
+
+// Run runs all jobs and returns their results once they are all done.
+// Jobs which have not started when the context is done fail with its error.
+func (c *Collector) Run(ctx context.Context, jobs []Job) Summary {
+	results := make([]Result, len(jobs))
+	for i, job := range jobs {
+		results[i].Name = job.Name
+	}
+
+	indexes := make(chan int)
+	out := make(chan fetched)
+	var wg sync.WaitGroup
+	for range min(c.workers, len(jobs)) {
+		wg.Go(func() {
+			for i := range indexes {
+				store, err := fetch(ctx, jobs[i])
+				out <- fetched{index: i, store: store, err: err}
+			}
+		})
+	}
+	go func() {
+		for i := range jobs {
+			indexes <- i
+		}
+		close(indexes)
+		wg.Wait()
+		close(out)
+	}()
+
+	// single writer
+	for f := range out {
+		if f.err == nil && f.store != nil {
+			f.err = write(ctx, f.store)
+		}
+		results[f.index].Err = f.err
+	}
+	return Summary{Results: results}
+}
+
+func fetch(ctx context.Context, job Job) (Store, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("job not started: %w", err)
+	}
+	return job.Fetch(ctx)
+}
+
+func write(ctx context.Context, store Store) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("result not stored: %w", err)
+	}
+	return store(ctx)
+}
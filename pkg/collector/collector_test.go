@@ -0,0 +1,102 @@
+package collector_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-stats/pkg/collector"
+)
+
+var errFetch = errors.New("fetch failed")
+
+func TestRunBoundsWorkersAndSerializesWrites(t *testing.T) {
+	const workers = 3
+	var running, maxRunning, writing atomic.Int32
+	var stored atomic.Int32
+	jobs := make([]collector.Job, 20)
+	for i := range jobs {
+		jobs[i] = collector.Job{
+			Name: fmt.Sprintf("project %d", i),
+			Fetch: func(_ context.Context) (collector.Store, error) {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					m := maxRunning.Load()
+					if n <= m || maxRunning.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				return func(_ context.Context) error {
+					if writing.Add(1) != 1 {
+						t.Errorf("concurrent writes")
+					}
+					defer writing.Add(-1)
+					stored.Add(1)
+					return nil
+				}, nil
+			},
+		}
+	}
+
+	summary := collector.New(workers).Run(context.Background(), jobs)
+	if got := maxRunning.Load(); got > workers {
+		t.Errorf("Run() ran %d jobs at a time, want at most %d", got, workers)
+	}
+	if summary.Succeeded() != len(jobs) || stored.Load() != int32(len(jobs)) {
+		t.Errorf("Run() succeeded = %d, stored = %d, want %d", summary.Succeeded(), stored.Load(), len(jobs))
+	}
+}
+
+func TestRunReportsFailures(t *testing.T) {
+	errStore := errors.New("store failed")
+	jobs := []collector.Job{
+		{Name: "ok", Fetch: func(_ context.Context) (collector.Store, error) {
+			return func(_ context.Context) error { return nil }, nil
+		}},
+		{Name: "fetch", Fetch: func(_ context.Context) (collector.Store, error) {
+			return nil, errFetch
+		}},
+		{Name: "store", Fetch: func(_ context.Context) (collector.Store, error) {
+			return func(_ context.Context) error { return errStore }, nil
+		}},
+	}
+
+	summary := collector.New(2).Run(context.Background(), jobs)
+	if summary.Succeeded() != 1 {
+		t.Errorf("Succeeded() = %d, want 1", summary.Succeeded())
+	}
+	failed := summary.Failed()
+	if len(failed) != 2 {
+		t.Fatalf("Failed() = %v, want 2 failures", failed)
+	}
+	if failed[0].Name != "fetch" || !errors.Is(failed[0].Err, errFetch) {
+		t.Errorf("Failed()[0] = %v, want fetch failure", failed[0])
+	}
+	if failed[1].Name != "store" || !errors.Is(failed[1].Err, errStore) {
+		t.Errorf("Failed()[1] = %v, want store failure", failed[1])
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	jobs := []collector.Job{{Name: "project 1", Fetch: func(_ context.Context) (collector.Store, error) {
+		called = true
+		return nil, nil
+	}}}
+
+	summary := collector.New(1).Run(ctx, jobs)
+	if called {
+		t.Errorf("Run() started a job with a canceled context")
+	}
+	failed := summary.Failed()
+	if len(failed) != 1 || !errors.Is(failed[0].Err, context.Canceled) {
+		t.Errorf("Failed() = %v, want a canceled job", failed)
+	}
+}
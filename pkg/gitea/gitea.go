@@ -0,0 +1,138 @@
+// Package gitea collects issue statistics from Gitea and Forgejo, which share
+// the same API. Counts are read from the X-Total-Count header of the issue
+// lists. The Client implements the fetcher interfaces of the gitlab package:
+// repositories are projects and organisations are groups.
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+// APIPath is the path of the API from the base URL of an instance.
+const APIPath = "/api/v1"
+
+// DefaultHTTPTimeout is the timeout of the HTTP client created by NewClient.
+const DefaultHTTPTimeout = 60 * time.Second
+
+// ErrNoTotalCount is returned when a list response has no valid X-Total-Count header.
+var ErrNoTotalCount = errors.New("response without X-Total-Count header")
+
+const (
+	httpOK         = 200
+	maxMessageSize = 64 * 1024
+	// pageSize is the default maximum page size of Gitea (MAX_RESPONSE_ITEMS).
+	pageSize = 50
+)
+
+// Client provides access to the API of a Gitea or Forgejo instance.
+type Client struct {
+	baseURL    string
+	endpoint   string
+	token      string
+	httpClient *http.Client
+
+	mu sync.Mutex
+	// repoNames and orgNames map the IDs seen in responses to names,
+	// as the issue endpoints only accept names.
+	repoNames map[int]string
+	orgNames  map[int]string
+}
+
+var _ gitlab.Fetcher = (*Client)(nil)
+
+// NewClient returns a client of the instance at baseURL, for instance
+// https://codeberg.org, authenticated with the GITEA_TOKEN environment
+// variable, anonymous without token.
+func NewClient(baseURL string) *Client {
+	baseURL = strings.TrimSuffix(strings.TrimSpace(baseURL), "/")
+	return &Client{
+		baseURL:    baseURL,
+		endpoint:   baseURL + APIPath,
+		token:      os.Getenv("GITEA_TOKEN"),
+		httpClient: &http.Client{Timeout: DefaultHTTPTimeout},
+		repoNames:  map[int]string{},
+		orgNames:   map[int]string{},
+	}
+}
+
+// SetToken sets the access token.
+// default: GITEA_TOKEN env variable
+func (c *Client) SetToken(token string) {
+	c.token = token
+}
+
+// SetHTTPClient sets the HTTP client to use for requests.
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+// get sends a GET request to the given path and decodes the JSON response into
+// v, unless v is nil. It returns the headers of the response.
+// Errors are returned as *gitlab.APIError, so that they match the sentinel
+// errors of the gitlab package.
+func (c *Client) get(ctx context.Context, path string, v any) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint+"/"+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GET request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "token "+c.token)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute GET request: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close() // ignore error
+	}()
+	if resp.StatusCode != httpOK {
+		return nil, newAPIError(resp)
+	}
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			return nil, fmt.Errorf("failed to decode JSON response: %w", err)
+		}
+	}
+	return resp.Header, nil
+}
+
+// count returns the X-Total-Count header of a list.
+func (c *Client) count(ctx context.Context, path string) (int, error) {
+	header, err := c.get(ctx, path, nil)
+	if err != nil {
+		return 0, err
+	}
+	total, err := strconv.Atoi(header.Get("X-Total-Count"))
+	if err != nil || total < 0 {
+		return 0, fmt.Errorf("%w: %s", ErrNoTotalCount, path)
+	}
+	return total, nil
+}
+
+// newAPIError builds an error from a non-200 response.
+func newAPIError(resp *http.Response) *gitlab.APIError {
+	apiErr := &gitlab.APIError{StatusCode: resp.StatusCode}
+	if resp.Request != nil && resp.Request.URL != nil {
+		apiErr.Path = resp.Request.URL.Path
+	}
+	var body struct {
+		Message string `json:"message"`
+	}
+	if json.NewDecoder(io.LimitReader(resp.Body, maxMessageSize)).Decode(&body) == nil {
+		apiErr.Message = body.Message
+	}
+	return apiErr
+}
@@ -0,0 +1,171 @@
+package gitea_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitea"
+	"github.com/sgaunet/gitlab-stats/pkg/gitea/giteatest"
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+func newTestServer(t *testing.T) *giteatest.Server {
+	t.Helper()
+	srv := giteatest.NewServer(t)
+	srv.AddOrg(giteatest.Org{ID: 10, Name: "acme", FullName: "Acme Corp"})
+	srv.AddRepo(giteatest.Repo{ID: 100, FullName: "acme/api"})
+	srv.AddRepo(giteatest.Repo{ID: 101, FullName: "acme/web"})
+	srv.AddRepo(giteatest.Repo{ID: 200, FullName: "jdoe/dotfiles"})
+	srv.AddIssues(100,
+		giteatest.Issue{State: "open", Labels: []string{"bug"}},
+		giteatest.Issue{State: "open"},
+		giteatest.Issue{State: "closed", Labels: []string{"bug"}},
+		giteatest.Issue{State: "open", PullRequest: true},
+	)
+	srv.AddIssues(101, giteatest.Issue{State: "closed"})
+	srv.AddIssues(200, giteatest.Issue{State: "open"})
+	return srv
+}
+
+func TestIssueStatistics(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	c := srv.NewClient()
+
+	tests := []struct {
+		name   string
+		scope  gitlab.Scope
+		filter gitlab.IssueFilter
+		want   gitlab.Counts
+	}{
+		{name: "repository", scope: gitlab.ProjectScope(100), want: gitlab.Counts{All: 3, Closed: 1, Opened: 2}},
+		{name: "organisation", scope: gitlab.GroupScope(10), want: gitlab.Counts{All: 4, Closed: 2, Opened: 2}},
+		{
+			name:   "labels",
+			scope:  gitlab.ProjectScope(100),
+			filter: gitlab.IssueFilter{Labels: []string{"bug"}},
+			want:   gitlab.Counts{All: 2, Closed: 1, Opened: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := c.IssueStatistics(ctx, tt.scope, tt.filter)
+			if err != nil {
+				t.Fatalf("IssueStatistics() error = %v", err)
+			}
+			if stats.Statistics.Counts != tt.want {
+				t.Errorf("IssueStatistics() = %+v, want %+v", stats.Statistics.Counts, tt.want)
+			}
+		})
+	}
+
+	for _, filter := range []gitlab.IssueFilter{
+		{AuthorID: 1},
+		{Labels: []string{"None"}},
+		{AuthorUsername: "jdoe"}, // on an organisation
+	} {
+		_, err := c.IssueStatistics(ctx, gitlab.GroupScope(10), filter)
+		if !errors.Is(err, gitea.ErrUnsupportedFilter) {
+			t.Errorf("IssueStatistics(%+v) error = %v, want %v", filter, err, gitea.ErrUnsupportedFilter)
+		}
+	}
+	if _, err := c.IssueStatistics(ctx, gitlab.ProjectScope(999), gitlab.IssueFilter{}); !errors.Is(err, gitlab.ErrNotFound) {
+		t.Errorf("IssueStatistics(unknown) error = %v, want %v", err, gitlab.ErrNotFound)
+	}
+	if _, err := c.MergeRequestStatistics(ctx, gitlab.ProjectScope(100)); !errors.Is(err, gitlab.ErrUnsupportedScope) {
+		t.Errorf("MergeRequestStatistics() error = %v, want %v", err, gitlab.ErrUnsupportedScope)
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	c := srv.NewClient()
+
+	project, err := c.Project(ctx, "acme/api")
+	if err != nil {
+		t.Fatalf("Project() error = %v", err)
+	}
+	if project.ID != 100 || project.Name != "api" || project.PathWithNamespace != "acme/api" {
+		t.Errorf("Project() = %+v", project)
+	}
+	if project, err := c.Project(ctx, "101"); err != nil || project.PathWithNamespace != "acme/web" {
+		t.Errorf("Project(101) = %+v, %v", project, err)
+	}
+	group, err := c.Group(ctx, "10")
+	if err != nil {
+		t.Fatalf("Group() error = %v", err)
+	}
+	if group.ID != 10 || group.FullPath != "acme" || group.Name != "Acme Corp" || group.WebURL != srv.URL+"/acme" {
+		t.Errorf("Group() = %+v", group)
+	}
+	projects, err := c.GroupProjects(ctx, 10)
+	if err != nil {
+		t.Fatalf("GroupProjects() error = %v", err)
+	}
+	if len(projects) != 2 || projects[0].PathWithNamespace != "acme/api" || projects[1].PathWithNamespace != "acme/web" {
+		t.Errorf("GroupProjects() = %+v", projects)
+	}
+	if _, err := c.Group(ctx, "99"); !errors.Is(err, gitlab.ErrNotFound) {
+		t.Errorf("Group(99) error = %v, want %v", err, gitlab.ErrNotFound)
+	}
+}
+
+func TestGroupProjectsPagination(t *testing.T) {
+	srv := giteatest.NewServer(t)
+	srv.AddOrg(giteatest.Org{ID: 1, Name: "big"})
+	const n = giteatest.MaxLimit + 10
+	for i := 1; i <= n; i++ {
+		srv.AddRepo(giteatest.Repo{ID: i, FullName: fmt.Sprintf("big/repo%d", i)})
+	}
+	projects, err := srv.NewClient().GroupProjects(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GroupProjects() error = %v", err)
+	}
+	if len(projects) != n {
+		t.Errorf("GroupProjects() returned %d projects, want %d", len(projects), n)
+	}
+}
+
+func TestToken(t *testing.T) {
+	srv := newTestServer(t)
+	srv.RequireToken("secret")
+	c := srv.NewClient()
+	c.SetToken("wrong")
+	if _, err := c.Project(context.Background(), "acme/api"); !errors.Is(err, gitlab.ErrUnauthorized) {
+		t.Errorf("Project() error = %v, want %v", err, gitlab.ErrUnauthorized)
+	}
+	c.SetToken("secret")
+	if _, err := c.Project(context.Background(), "acme/api"); err != nil {
+		t.Errorf("Project() error = %v", err)
+	}
+}
+
+func TestInstanceScopeUnsupported(t *testing.T) {
+	c := newTestServer(t).NewClient()
+	_, err := c.IssueStatistics(context.Background(), gitlab.InstanceScope(), gitlab.IssueFilter{})
+	if !errors.Is(err, gitlab.ErrUnsupportedScope) {
+		t.Errorf("IssueStatistics() error = %v, want %v", err, gitlab.ErrUnsupportedScope)
+	}
+}
+
+func TestMissingTotalCount(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/repositories/100" {
+			_, _ = w.Write([]byte(`{"id":100,"full_name":"acme/api"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer ts.Close()
+	c := gitea.NewClient(ts.URL)
+	c.SetHTTPClient(ts.Client())
+	_, err := c.IssueStatistics(context.Background(), gitlab.ProjectScope(100), gitlab.IssueFilter{})
+	if !errors.Is(err, gitea.ErrNoTotalCount) {
+		t.Errorf("IssueStatistics() error = %v, want %v", err, gitea.ErrNoTotalCount)
+	}
+}
@@ -0,0 +1,315 @@
+// Package giteatest provides an in-process fake Gitea (or Forgejo) API for tests.
+//
+// The server is programmed with organisations, repositories and issues, and
+// serves the endpoints used by the gitea package: repositories by name or ID,
+// organisations, the repositories of an organisation, and the issue lists of a
+// repository and of the issue search with their X-Total-Count header.
+package giteatest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitea"
+)
+
+// MaxLimit is the maximum page size of the server, the default of Gitea.
+const MaxLimit = 50
+
+// Org is an organisation of the fake server.
+type Org struct {
+	ID       int
+	Name     string
+	FullName string
+}
+
+// Repo is a repository of the fake server.
+type Repo struct {
+	ID int
+	// FullName is "owner/name"; the owner is an organisation name or a user.
+	FullName string
+}
+
+// Issue is an issue or a pull request of the fake server.
+type Issue struct {
+	State       string // open or closed
+	Labels      []string
+	PullRequest bool
+}
+
+// Server is a fake Gitea API. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	token    string
+	orgs     map[int]Org
+	repos    map[int]Repo
+	issues   map[int][]Issue
+	requests []string
+}
+
+// NewServer starts a fake Gitea API over TLS, closed at the end of the test.
+func NewServer(tb testing.TB) *Server {
+	tb.Helper()
+	s := &Server{
+		orgs:   map[int]Org{},
+		repos:  map[int]Repo{},
+		issues: map[int][]Issue{},
+	}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// NewClient returns a gitea.Client using the server.
+func (s *Server) NewClient() *gitea.Client {
+	c := gitea.NewClient(s.URL)
+	c.SetHTTPClient(s.Client())
+	return c
+}
+
+// RequireToken makes the server answer 401 to requests without this access token.
+func (s *Server) RequireToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+}
+
+// AddOrg adds an organisation.
+func (s *Server) AddOrg(o Org) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orgs[o.ID] = o
+}
+
+// AddRepo adds a repository.
+func (s *Server) AddRepo(r Repo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.repos[r.ID] = r
+}
+
+// AddIssues adds issues or pull requests to a repository.
+func (s *Server) AddIssues(repoID int, issues ...Issue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.issues[repoID] = append(s.issues[repoID], issues...)
+}
+
+// Requests returns the path and query of the requests received so far.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.URL.RequestURI())
+	if s.token != "" && r.Header.Get("Authorization") != "token "+s.token {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "user does not exist"})
+		return
+	}
+	path, ok := strings.CutPrefix(r.URL.Path, gitea.APIPath+"/")
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(segments) == 3 && segments[0] == "repos" && segments[1] == "issues" && segments[2] == "search":
+		s.searchIssues(w, r)
+	case len(segments) == 4 && segments[0] == "repos" && segments[3] == "issues":
+		repo := s.findRepo(func(repo Repo) bool { return strings.EqualFold(repo.FullName, segments[1]+"/"+segments[2]) })
+		if repo == nil {
+			writeNotFound(w)
+			return
+		}
+		s.writeIssues(w, r, []int{repo.ID})
+	case len(segments) == 3 && segments[0] == "repos":
+		s.writeRepo(w, s.findRepo(func(repo Repo) bool { return strings.EqualFold(repo.FullName, segments[1]+"/"+segments[2]) }))
+	case len(segments) == 2 && segments[0] == "repositories":
+		id, _ := strconv.Atoi(segments[1])
+		s.writeRepo(w, s.findRepo(func(repo Repo) bool { return repo.ID == id }))
+	case len(segments) == 1 && segments[0] == "orgs":
+		s.listOrgs(w, r)
+	case len(segments) == 2 && segments[0] == "orgs":
+		s.writeOrg(w, s.findOrg(segments[1]))
+	case len(segments) == 3 && segments[0] == "orgs" && segments[2] == "repos":
+		s.listOrgRepos(w, r, segments[1])
+	default:
+		writeNotFound(w)
+	}
+}
+
+func (s *Server) findRepo(match func(Repo) bool) *Repo {
+	for _, repo := range s.repos {
+		if match(repo) {
+			return &repo
+		}
+	}
+	return nil
+}
+
+func (s *Server) findOrg(name string) *Org {
+	for _, o := range s.orgs {
+		if strings.EqualFold(o.Name, name) {
+			return &o
+		}
+	}
+	return nil
+}
+
+// ownedRepos returns the IDs of the repositories of an owner, sorted.
+func (s *Server) ownedRepos(owner string) []int {
+	var ids []int
+	for _, repo := range s.repos {
+		if o, _, _ := strings.Cut(repo.FullName, "/"); strings.EqualFold(o, owner) {
+			ids = append(ids, repo.ID)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func (s *Server) writeRepo(w http.ResponseWriter, repo *Repo) {
+	if repo == nil {
+		writeNotFound(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.repoJSON(*repo))
+}
+
+func (s *Server) writeOrg(w http.ResponseWriter, o *Org) {
+	if o == nil {
+		writeNotFound(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, orgJSON(*o))
+}
+
+func orgJSON(o Org) map[string]any {
+	return map[string]any{"id": o.ID, "name": o.Name, "username": o.Name, "full_name": o.FullName}
+}
+
+func (s *Server) repoJSON(repo Repo) map[string]any {
+	_, name, _ := strings.Cut(repo.FullName, "/")
+	return map[string]any{
+		"id":        repo.ID,
+		"name":      name,
+		"full_name": repo.FullName,
+		"html_url":  s.URL + "/" + repo.FullName,
+		"clone_url": s.URL + "/" + repo.FullName + ".git",
+		"ssh_url":   "git@" + strings.TrimPrefix(s.URL, "https://") + ":" + repo.FullName + ".git",
+	}
+}
+
+// listOrgs serves the organisations sorted by ID.
+func (s *Server) listOrgs(w http.ResponseWriter, r *http.Request) {
+	var orgs []Org
+	for _, o := range s.orgs {
+		orgs = append(orgs, o)
+	}
+	slices.SortFunc(orgs, func(a, b Org) int { return a.ID - b.ID })
+	writePage(w, r, orgs, orgJSON)
+}
+
+// listOrgRepos serves the repositories of an organisation sorted by ID.
+func (s *Server) listOrgRepos(w http.ResponseWriter, r *http.Request, name string) {
+	if s.findOrg(name) == nil {
+		writeNotFound(w)
+		return
+	}
+	var repos []Repo
+	for _, id := range s.ownedRepos(name) {
+		repos = append(repos, s.repos[id])
+	}
+	writePage(w, r, repos, s.repoJSON)
+}
+
+// searchIssues serves the issues of the repositories of the owner parameter,
+// or of all the repositories without owner.
+func (s *Server) searchIssues(w http.ResponseWriter, r *http.Request) {
+	owner := r.URL.Query().Get("owner")
+	if owner == "" {
+		var ids []int
+		for id := range s.repos {
+			ids = append(ids, id)
+		}
+		slices.Sort(ids)
+		s.writeIssues(w, r, ids)
+		return
+	}
+	if s.findOrg(owner) == nil {
+		writeNotFound(w)
+		return
+	}
+	s.writeIssues(w, r, s.ownedRepos(owner))
+}
+
+// writeIssues serves the issues of the repositories matching the state
+// (default open), type and labels parameters. Other parameters are ignored.
+func (s *Server) writeIssues(w http.ResponseWriter, r *http.Request, repoIDs []int) {
+	q := r.URL.Query()
+	state := q.Get("state")
+	if state == "" {
+		state = "open"
+	}
+	var labels []string
+	if q.Get("labels") != "" {
+		labels = strings.Split(q.Get("labels"), ",")
+	}
+	var issues []Issue
+	for _, id := range repoIDs {
+		for _, issue := range s.issues[id] {
+			switch {
+			case state != "all" && issue.State != state,
+				q.Get("type") == "issues" && issue.PullRequest,
+				q.Get("type") == "pulls" && !issue.PullRequest:
+				continue
+			}
+			if slices.ContainsFunc(labels, func(l string) bool { return !slices.Contains(issue.Labels, l) }) {
+				continue
+			}
+			issues = append(issues, issue)
+		}
+	}
+	writePage(w, r, issues, func(issue Issue) map[string]any {
+		return map[string]any{"state": issue.State, "labels": issue.Labels}
+	})
+}
+
+// writePage writes a page of items given by the page and limit parameters,
+// with the total in the X-Total-Count header.
+func writePage[T any](w http.ResponseWriter, r *http.Request, items []T, toJSON func(T) map[string]any) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	page = max(page, 1)
+	if limit <= 0 || limit > MaxLimit {
+		limit = MaxLimit
+	}
+	body := []map[string]any{}
+	for i := (page - 1) * limit; i < len(items) && i < page*limit; i++ {
+		body = append(body, toJSON(items[i]))
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(items)))
+	writeJSON(w, http.StatusOK, body)
+}
+
+func writeNotFound(w http.ResponseWriter) {
+	writeJSON(w, http.StatusNotFound, map[string]string{"message": "The target couldn't be found."})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v) // ignore error
+}
@@ -0,0 +1,174 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+// repository is a Gitea repository.
+type repository struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	HTMLURL  string `json:"html_url"`
+	SSHURL   string `json:"ssh_url"`
+	CloneURL string `json:"clone_url"`
+}
+
+// organization is a Gitea organisation. Name is the login of the organisation.
+type organization struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+}
+
+func (r repository) project() gitlab.Project {
+	return gitlab.Project{
+		ID:                r.ID,
+		Name:              r.Name,
+		PathWithNamespace: r.FullName,
+		SSHURLToRepo:      r.SSHURL,
+		HTTPURLToRepo:     r.CloneURL,
+		WebURL:            r.HTMLURL,
+	}
+}
+
+func (c *Client) group(org organization) gitlab.Group {
+	name := org.FullName
+	if name == "" {
+		name = org.Name
+	}
+	return gitlab.Group{ID: org.ID, Name: name, FullPath: org.Name, FullName: name, WebURL: c.baseURL + "/" + org.Name}
+}
+
+// Project returns a repository from its ID or its full name, for instance "owner/repo".
+func (c *Client) Project(ctx context.Context, idOrPath string) (gitlab.Project, error) {
+	idOrPath = strings.Trim(idOrPath, "/")
+	path := "repos/" + idOrPath
+	if _, err := strconv.Atoi(idOrPath); err == nil {
+		path = "repositories/" + idOrPath
+	}
+	var repo repository
+	if _, err := c.get(ctx, path, &repo); err != nil {
+		return gitlab.Project{}, err
+	}
+	c.remember(c.repoNames, repo.ID, repo.FullName)
+	return repo.project(), nil
+}
+
+// Group returns an organisation from its ID or its name. As the API has no
+// endpoint to get an organisation by ID, it is searched in the list of the
+// organisations.
+func (c *Client) Group(ctx context.Context, idOrPath string) (gitlab.Group, error) {
+	idOrPath = strings.Trim(idOrPath, "/")
+	if id, err := strconv.Atoi(idOrPath); err == nil {
+		return c.findOrg(ctx, id)
+	}
+	var org organization
+	if _, err := c.get(ctx, "orgs/"+url.PathEscape(idOrPath), &org); err != nil {
+		return gitlab.Group{}, err
+	}
+	c.remember(c.orgNames, org.ID, org.Name)
+	return c.group(org), nil
+}
+
+// findOrg searches an organisation by ID in the organisations visible with the token.
+func (c *Client) findOrg(ctx context.Context, id int) (gitlab.Group, error) {
+	var found *organization
+	err := list(ctx, c, "orgs", func(page []organization) {
+		for _, org := range page {
+			c.remember(c.orgNames, org.ID, org.Name)
+			if org.ID == id {
+				found = &org
+			}
+		}
+	})
+	if err != nil {
+		return gitlab.Group{}, fmt.Errorf("failed to list organisations: %w", err)
+	}
+	if found == nil {
+		return gitlab.Group{}, &gitlab.APIError{StatusCode: http.StatusNotFound, Path: "orgs", Message: fmt.Sprintf("organisation %d not found", id)}
+	}
+	return c.group(*found), nil
+}
+
+// GroupProjects returns the repositories of an organisation.
+func (c *Client) GroupProjects(ctx context.Context, groupID int) ([]gitlab.Project, error) {
+	org, err := c.orgName(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	var projects []gitlab.Project
+	err = list(ctx, c, "orgs/"+url.PathEscape(org)+"/repos", func(page []repository) {
+		for _, repo := range page {
+			c.remember(c.repoNames, repo.ID, repo.FullName)
+			projects = append(projects, repo.project())
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories of %s: %w", org, err)
+	}
+	return projects, nil
+}
+
+// list calls fn with each page of a list until the X-Total-Count header is
+// reached, as the instance may return less items than asked per page.
+func list[T any](ctx context.Context, c *Client, path string, fn func([]T)) error {
+	seen := 0
+	for page := 1; ; page++ {
+		var items []T
+		header, err := c.get(ctx, fmt.Sprintf("%s?limit=%d&page=%d", path, pageSize, page), &items)
+		if err != nil {
+			return err
+		}
+		fn(items)
+		seen += len(items)
+		total, err := strconv.Atoi(header.Get("X-Total-Count"))
+		if len(items) == 0 || (err == nil && seen >= total) || (err != nil && len(items) < pageSize) {
+			return nil
+		}
+	}
+}
+
+// repoName returns the full name of a repository from its ID.
+func (c *Client) repoName(ctx context.Context, id int) (string, error) {
+	if name, ok := c.lookup(c.repoNames, id); ok {
+		return name, nil
+	}
+	project, err := c.Project(ctx, strconv.Itoa(id))
+	if err != nil {
+		return "", err
+	}
+	return project.PathWithNamespace, nil
+}
+
+// orgName returns the name of an organisation from its ID.
+func (c *Client) orgName(ctx context.Context, id int) (string, error) {
+	if name, ok := c.lookup(c.orgNames, id); ok {
+		return name, nil
+	}
+	group, err := c.Group(ctx, strconv.Itoa(id))
+	if err != nil {
+		return "", err
+	}
+	return group.FullPath, nil
+}
+
+func (c *Client) remember(names map[int]string, id int, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names[id] = name
+}
+
+func (c *Client) lookup(names map[int]string, id int) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	name, ok := names[id]
+	return name, ok
+}
@@ -0,0 +1,137 @@
+package gitea
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+// ErrUnsupportedFilter is returned when an issue filter has no equivalent in
+// the Gitea API, for instance a filter on the creation date.
+var ErrUnsupportedFilter = errors.New("filter not supported by Gitea")
+
+// IssueStatistics counts the open and closed issues of a repository (project
+// scope) or an organisation (group scope). Pull requests are not counted.
+// See: https://gitea.com/api/swagger#/issue
+func (c *Client) IssueStatistics(
+	ctx context.Context,
+	scope gitlab.Scope,
+	filter gitlab.IssueFilter,
+) (gitlab.Statistics, error) {
+	if err := filter.Validate(); err != nil {
+		return gitlab.Statistics{}, err
+	}
+	params, err := issueParams(filter, scope.Kind == gitlab.ScopeProject)
+	if err != nil {
+		return gitlab.Statistics{}, err
+	}
+	var path string
+	switch scope.Kind {
+	case gitlab.ScopeProject:
+		name, err := c.repoName(ctx, scope.ID)
+		if err != nil {
+			return gitlab.Statistics{}, err
+		}
+		path = "repos/" + name + "/issues"
+	case gitlab.ScopeGroup:
+		name, err := c.orgName(ctx, scope.ID)
+		if err != nil {
+			return gitlab.Statistics{}, err
+		}
+		path = "repos/issues/search"
+		params.Set("owner", name)
+	default:
+		return gitlab.Statistics{}, fmt.Errorf("%w: %s", gitlab.ErrUnsupportedScope, scope)
+	}
+	params.Set("type", "issues")
+	params.Set("limit", "1")
+
+	var counts gitlab.Counts
+	for _, s := range []struct {
+		state string
+		count *int
+	}{
+		{"open", &counts.Opened},
+		{"closed", &counts.Closed},
+	} {
+		params.Set("state", s.state)
+		if *s.count, err = c.count(ctx, path+"?"+params.Encode()); err != nil {
+			return gitlab.Statistics{}, err
+		}
+	}
+	counts.All = counts.Opened + counts.Closed
+	return gitlab.Statistics{Statistics: gitlab.Statistic{Counts: counts}}, nil
+}
+
+// MergeRequestStatistics is not supported on Gitea.
+func (c *Client) MergeRequestStatistics(_ context.Context, scope gitlab.Scope) (gitlab.MergeRequestCounts, error) {
+	return gitlab.MergeRequestCounts{}, fmt.Errorf("%w: pull requests of Gitea %s", gitlab.ErrUnsupportedScope, scope)
+}
+
+// issueParams translates an issue filter to the parameters of the issue lists.
+// The author and assignee filters only exist on the issues of a repository.
+func issueParams(f gitlab.IssueFilter, repository bool) (url.Values, error) {
+	params := url.Values{}
+	unsupported := func(what string) (url.Values, error) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFilter, what)
+	}
+	for _, label := range f.Labels {
+		if label == "None" || label == "Any" || strings.Contains(label, ",") {
+			return unsupported(fmt.Sprintf("label %q", label))
+		}
+	}
+	if len(f.Labels) > 0 {
+		params.Set("labels", strings.Join(f.Labels, ","))
+	}
+	switch f.Milestone {
+	case "":
+	case "None", "Any":
+		return unsupported(strings.ToLower(f.Milestone) + " milestone")
+	default:
+		params.Set("milestones", f.Milestone)
+	}
+	if f.Scope != "" && f.Scope != "all" {
+		return unsupported("scope " + f.Scope)
+	}
+	if f.AuthorID != 0 {
+		return unsupported("author ID, use the author username")
+	}
+	if f.AssigneeID != "" {
+		return unsupported("assignee ID, use the assignee username")
+	}
+	if (f.AuthorUsername != "" || f.AssigneeUsername != "") && !repository {
+		return unsupported("author and assignee outside of a repository")
+	}
+	if f.AuthorUsername != "" {
+		params.Set("created_by", f.AuthorUsername)
+	}
+	if f.AssigneeUsername != "" {
+		params.Set("assigned_by", f.AssigneeUsername)
+	}
+	if f.Search != "" {
+		if f.In != "" && f.In != "title,description" {
+			return unsupported("search in " + f.In)
+		}
+		params.Set("q", f.Search)
+	}
+	if f.CreatedAfter != nil || f.CreatedBefore != nil {
+		return unsupported("creation date")
+	}
+	setTime(params, "since", f.UpdatedAfter)
+	setTime(params, "before", f.UpdatedBefore)
+	if f.Confidential != nil && *f.Confidential {
+		return unsupported("confidential issues")
+	}
+	return params, nil
+}
+
+func setTime(params url.Values, name string, t *time.Time) {
+	if t != nil {
+		params.Set(name, t.UTC().Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,170 @@
+// Package github collects issue statistics from GitHub with the search API.
+// The Client implements the fetcher interfaces of the gitlab package:
+// repositories are projects and organisations are groups.
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+// APIEndpoint is the API endpoint of github.com.
+const APIEndpoint = "https://api.github.com"
+
+// RateLimitHeaders are the rate limit headers of GitHub. The search API has
+// its own limit of 30 requests per minute, reported by the same headers.
+var RateLimitHeaders = gitlab.RateLimitHeaders{Remaining: "X-RateLimit-Remaining", Reset: "X-RateLimit-Reset"}
+
+// DefaultHTTPTimeout is the timeout of the HTTP client created by NewClient.
+const DefaultHTTPTimeout = 60 * time.Second
+
+const (
+	httpOK         = 200
+	perPage        = 100
+	maxMessageSize = 64 * 1024
+)
+
+// Client provides access to the GitHub API.
+type Client struct {
+	endpoint   string
+	token      string
+	httpClient *http.Client
+	retrier    *gitlab.Retrier
+
+	mu sync.Mutex
+	// repoNames and orgNames map the IDs seen in responses to names,
+	// as the search API only accepts names.
+	repoNames map[int]string
+	orgNames  map[int]string
+}
+
+var _ gitlab.Fetcher = (*Client)(nil)
+
+// NewClient returns a client of github.com authenticated with the GITHUB_TOKEN
+// (or GH_TOKEN) environment variable, anonymous without token.
+func NewClient() *Client {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+	return &Client{
+		endpoint:   APIEndpoint,
+		token:      token,
+		httpClient: &http.Client{Timeout: DefaultHTTPTimeout},
+		retrier:    gitlab.NewRetrier(RateLimitHeaders, isRetryable),
+		repoNames:  map[int]string{},
+		orgNames:   map[int]string{},
+	}
+}
+
+// APIEndpointOf returns the API endpoint of a GitHub instance from its web URL:
+// api.github.com for github.com, /api/v3 for GitHub Enterprise Server.
+func APIEndpointOf(baseURL string) string {
+	baseURL = strings.TrimSuffix(strings.TrimSpace(baseURL), "/")
+	u, err := url.Parse(baseURL)
+	if baseURL == "" || (err == nil && (u.Host == "github.com" || u.Host == "www.github.com")) {
+		return APIEndpoint
+	}
+	if strings.HasSuffix(baseURL, "/api/v3") {
+		return baseURL
+	}
+	return baseURL + "/api/v3"
+}
+
+// SetEndpoint sets the GitHub API endpoint.
+// default: https://api.github.com
+func (c *Client) SetEndpoint(endpoint string) {
+	if endpoint != "" {
+		c.endpoint = strings.TrimSuffix(endpoint, "/")
+	}
+}
+
+// SetToken sets the GitHub token.
+// default: GITHUB_TOKEN or GH_TOKEN env variable
+func (c *Client) SetToken(token string) {
+	c.token = token
+}
+
+// SetHTTPClient sets the HTTP client to use for requests.
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+// SetRetryPolicy sets the retry policy of the requests rejected by a rate
+// limit or a server error.
+// default: gitlab.DefaultRetryPolicy()
+func (c *Client) SetRetryPolicy(policy gitlab.RetryPolicy) {
+	c.retrier.SetPolicy(policy)
+}
+
+// get sends a GET request to the given path and decodes the JSON response into v.
+// The request waits for the rate limit window to reset when it is exhausted,
+// and is retried on rate limit and server errors. Errors are returned as *gitlab.APIError, so that they match the sentinel
+// errors of the gitlab package.
+func (c *Client) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint+"/"+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create GET request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.retrier.Do(c.httpClient, req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close() // ignore error
+	}()
+	if resp.StatusCode != httpOK {
+		return newAPIError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode JSON response: %w", err)
+	}
+	return nil
+}
+
+// isRateLimited tells whether GitHub rejected a request for exceeding the
+// primary rate limit (no remaining request) or a secondary one (Retry-After).
+func isRateLimited(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode == http.StatusForbidden &&
+			(resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.Header.Get("Retry-After") != ""))
+}
+
+// isRetryable tells whether a request is retried: on rate limit and server errors.
+func isRetryable(resp *http.Response) bool {
+	return isRateLimited(resp) || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// newAPIError builds an error from a non-200 response. GitHub answers 403
+// when a rate limit is exceeded: it is reported as 429 like GitLab does.
+func newAPIError(resp *http.Response) *gitlab.APIError {
+	apiErr := &gitlab.APIError{StatusCode: resp.StatusCode}
+	if resp.Request != nil && resp.Request.URL != nil {
+		apiErr.Path = resp.Request.URL.Path
+	}
+	if isRateLimited(resp) {
+		apiErr.StatusCode = http.StatusTooManyRequests
+	}
+	var body struct {
+		Message string `json:"message"`
+	}
+	if json.NewDecoder(io.LimitReader(resp.Body, maxMessageSize)).Decode(&body) == nil {
+		apiErr.Message = body.Message
+	}
+	return apiErr
+}
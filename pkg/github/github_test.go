@@ -0,0 +1,179 @@
+package github_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-stats/pkg/github"
+	"github.com/sgaunet/gitlab-stats/pkg/github/githubtest"
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+func newTestServer(t *testing.T) *githubtest.Server {
+	t.Helper()
+	srv := githubtest.NewServer(t)
+	srv.AddOrg(githubtest.Org{ID: 10, Login: "acme", Name: "Acme Corp"})
+	srv.AddRepo(githubtest.Repo{ID: 100, FullName: "acme/api"})
+	srv.AddRepo(githubtest.Repo{ID: 101, FullName: "acme/web"})
+	srv.AddIssues(100,
+		githubtest.Issue{State: "open", Labels: []string{"bug"}},
+		githubtest.Issue{State: "open"},
+		githubtest.Issue{State: "closed", Labels: []string{"bug"}},
+		githubtest.Issue{State: "open", PullRequest: true},
+	)
+	srv.AddIssues(101, githubtest.Issue{State: "closed"})
+	return srv
+}
+
+func TestIssueStatistics(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	c := srv.NewClient()
+
+	tests := []struct {
+		name   string
+		scope  gitlab.Scope
+		filter gitlab.IssueFilter
+		want   gitlab.Counts
+	}{
+		{name: "repository", scope: gitlab.ProjectScope(100), want: gitlab.Counts{All: 3, Closed: 1, Opened: 2}},
+		{name: "organisation", scope: gitlab.GroupScope(10), want: gitlab.Counts{All: 4, Closed: 2, Opened: 2}},
+		{
+			name:   "labels",
+			scope:  gitlab.GroupScope(10),
+			filter: gitlab.IssueFilter{Labels: []string{"bug"}},
+			want:   gitlab.Counts{All: 2, Closed: 1, Opened: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := c.IssueStatistics(ctx, tt.scope, tt.filter)
+			if err != nil {
+				t.Fatalf("IssueStatistics() error = %v", err)
+			}
+			if stats.Statistics.Counts != tt.want {
+				t.Errorf("IssueStatistics() = %+v, want %+v", stats.Statistics.Counts, tt.want)
+			}
+		})
+	}
+
+	if _, err := c.IssueStatistics(ctx, gitlab.InstanceScope(), gitlab.IssueFilter{}); !errors.Is(err, gitlab.ErrUnsupportedScope) {
+		t.Errorf("IssueStatistics(instance) error = %v, want %v", err, gitlab.ErrUnsupportedScope)
+	}
+	_, err := c.IssueStatistics(ctx, gitlab.ProjectScope(100), gitlab.IssueFilter{AuthorID: 1})
+	if !errors.Is(err, github.ErrUnsupportedFilter) {
+		t.Errorf("IssueStatistics(author ID) error = %v, want %v", err, github.ErrUnsupportedFilter)
+	}
+	if _, err := c.IssueStatistics(ctx, gitlab.ProjectScope(999), gitlab.IssueFilter{}); !errors.Is(err, gitlab.ErrNotFound) {
+		t.Errorf("IssueStatistics(unknown) error = %v, want %v", err, gitlab.ErrNotFound)
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	c := srv.NewClient()
+
+	project, err := c.Project(ctx, "acme/api")
+	if err != nil {
+		t.Fatalf("Project() error = %v", err)
+	}
+	if project.ID != 100 || project.Name != "api" || project.PathWithNamespace != "acme/api" {
+		t.Errorf("Project() = %+v", project)
+	}
+	group, err := c.Group(ctx, "10")
+	if err != nil {
+		t.Fatalf("Group() error = %v", err)
+	}
+	if group.ID != 10 || group.FullPath != "acme" || group.Name != "Acme Corp" {
+		t.Errorf("Group() = %+v", group)
+	}
+	projects, err := c.GroupProjects(ctx, 10)
+	if err != nil {
+		t.Fatalf("GroupProjects() error = %v", err)
+	}
+	if len(projects) != 2 || projects[0].PathWithNamespace != "acme/api" || projects[1].PathWithNamespace != "acme/web" {
+		t.Errorf("GroupProjects() = %+v", projects)
+	}
+	if _, err := c.Project(ctx, "acme/unknown"); !errors.Is(err, gitlab.ErrNotFound) {
+		t.Errorf("Project(unknown) error = %v, want %v", err, gitlab.ErrNotFound)
+	}
+}
+
+func TestToken(t *testing.T) {
+	srv := newTestServer(t)
+	srv.RequireToken("secret")
+	c := srv.NewClient()
+	c.SetToken("wrong")
+	if _, err := c.Project(context.Background(), "acme/api"); !errors.Is(err, gitlab.ErrUnauthorized) {
+		t.Errorf("Project() error = %v, want %v", err, gitlab.ErrUnauthorized)
+	}
+	c.SetToken("secret")
+	if _, err := c.Project(context.Background(), "acme/api"); err != nil {
+		t.Errorf("Project() error = %v", err)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"message":"API rate limit exceeded"}`))
+	}))
+	defer ts.Close()
+	c := github.NewClient()
+	c.SetHTTPClient(ts.Client())
+	c.SetEndpoint(ts.URL)
+	c.SetRetryPolicy(gitlab.RetryPolicy{MaxRetries: 0})
+	if _, err := c.Project(context.Background(), "acme/api"); !errors.Is(err, gitlab.ErrRateLimited) {
+		t.Errorf("Project() error = %v, want %v", err, gitlab.ErrRateLimited)
+	}
+}
+
+func TestRateLimitRetried(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// secondary rate limit of the search API
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"You have exceeded a secondary rate limit"}`))
+			return
+		}
+		w.Header().Set("X-RateLimit-Remaining", "29")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10))
+		_, _ = w.Write([]byte(`{"id":100,"full_name":"acme/api"}`))
+	}))
+	defer ts.Close()
+	c := github.NewClient()
+	c.SetHTTPClient(ts.Client())
+	c.SetEndpoint(ts.URL)
+	c.SetRetryPolicy(gitlab.RetryPolicy{MaxRetries: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+	project, err := c.Project(context.Background(), "acme/api")
+	if err != nil {
+		t.Fatalf("Project() error = %v", err)
+	}
+	if project.ID != 100 || calls.Load() != 2 {
+		t.Errorf("Project() = %d after %d calls, want 100 after 2", project.ID, calls.Load())
+	}
+}
+
+func TestAPIEndpointOf(t *testing.T) {
+	tests := map[string]string{
+		"":                               github.APIEndpoint,
+		"https://github.com/":            github.APIEndpoint,
+		"https://ghe.example.com":        "https://ghe.example.com/api/v3",
+		"https://ghe.example.com/api/v3": "https://ghe.example.com/api/v3",
+	}
+	for baseURL, want := range tests {
+		if got := github.APIEndpointOf(baseURL); got != want {
+			t.Errorf("APIEndpointOf(%q) = %q, want %q", baseURL, got, want)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+// Package githubtest provides an in-process fake GitHub API for tests.
+//
+// The server is programmed with organisations, repositories and issues, and
+// serves the endpoints used by the github package: repositories and
+// organisations by name or ID, the repositories of an organisation and the
+// totals of the issue search.
+package githubtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sgaunet/gitlab-stats/pkg/github"
+)
+
+// APIPath is the path of the API on the server, as on GitHub Enterprise Server.
+const APIPath = "/api/v3"
+
+// Org is an organisation of the fake server.
+type Org struct {
+	ID    int
+	Login string
+	Name  string
+}
+
+// Repo is a repository of the fake server.
+type Repo struct {
+	ID int
+	// FullName is "owner/name"; the owner is an organisation login or a user.
+	FullName string
+}
+
+// Issue is an issue or a pull request of the fake server.
+type Issue struct {
+	State       string // open or closed
+	Labels      []string
+	PullRequest bool
+}
+
+// Server is a fake GitHub API. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	token    string
+	orgs     map[int]Org
+	repos    map[int]Repo
+	issues   map[int][]Issue
+	requests []string
+}
+
+// NewServer starts a fake GitHub API over TLS, closed at the end of the test.
+func NewServer(tb testing.TB) *Server {
+	tb.Helper()
+	s := &Server{
+		orgs:   map[int]Org{},
+		repos:  map[int]Repo{},
+		issues: map[int][]Issue{},
+	}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// Endpoint returns the API endpoint of the server, to use with Client.SetEndpoint.
+func (s *Server) Endpoint() string {
+	return s.URL + APIPath
+}
+
+// NewClient returns a github.Client using the server.
+func (s *Server) NewClient() *github.Client {
+	c := github.NewClient()
+	c.SetHTTPClient(s.Client())
+	c.SetEndpoint(s.Endpoint())
+	return c
+}
+
+// RequireToken makes the server answer 401 to requests without this bearer token.
+func (s *Server) RequireToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+}
+
+// AddOrg adds an organisation.
+func (s *Server) AddOrg(o Org) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orgs[o.ID] = o
+}
+
+// AddRepo adds a repository.
+func (s *Server) AddRepo(r Repo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.repos[r.ID] = r
+}
+
+// AddIssues adds issues or pull requests to a repository.
+func (s *Server) AddIssues(repoID int, issues ...Issue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.issues[repoID] = append(s.issues[repoID], issues...)
+}
+
+// Requests returns the path and query of the requests received so far.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.URL.RequestURI())
+	if s.token != "" && r.Header.Get("Authorization") != "Bearer "+s.token {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Bad credentials"})
+		return
+	}
+	path, ok := strings.CutPrefix(r.URL.Path, APIPath+"/")
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(segments) == 2 && segments[0] == "search" && segments[1] == "issues":
+		s.search(w, r)
+	case len(segments) == 3 && segments[0] == "repos":
+		s.writeRepo(w, s.findRepo(func(repo Repo) bool { return strings.EqualFold(repo.FullName, segments[1]+"/"+segments[2]) }))
+	case len(segments) == 2 && segments[0] == "repositories":
+		id, _ := strconv.Atoi(segments[1])
+		s.writeRepo(w, s.findRepo(func(repo Repo) bool { return repo.ID == id }))
+	case len(segments) == 2 && segments[0] == "orgs":
+		s.writeOrg(w, s.findOrg(func(o Org) bool { return strings.EqualFold(o.Login, segments[1]) }))
+	case len(segments) == 2 && segments[0] == "organizations":
+		id, _ := strconv.Atoi(segments[1])
+		s.writeOrg(w, s.findOrg(func(o Org) bool { return o.ID == id }))
+	case len(segments) == 3 && segments[0] == "orgs" && segments[2] == "repos":
+		s.listOrgRepos(w, r, segments[1])
+	default:
+		writeNotFound(w)
+	}
+}
+
+func (s *Server) findRepo(match func(Repo) bool) *Repo {
+	for _, repo := range s.repos {
+		if match(repo) {
+			return &repo
+		}
+	}
+	return nil
+}
+
+func (s *Server) findOrg(match func(Org) bool) *Org {
+	for _, o := range s.orgs {
+		if match(o) {
+			return &o
+		}
+	}
+	return nil
+}
+
+func (s *Server) writeRepo(w http.ResponseWriter, repo *Repo) {
+	if repo == nil {
+		writeNotFound(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.repoJSON(*repo))
+}
+
+func (s *Server) writeOrg(w http.ResponseWriter, o *Org) {
+	if o == nil {
+		writeNotFound(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id":       o.ID,
+		"login":    o.Login,
+		"name":     o.Name,
+		"html_url": s.URL + "/" + o.Login,
+	})
+}
+
+func (s *Server) repoJSON(repo Repo) map[string]any {
+	_, name, _ := strings.Cut(repo.FullName, "/")
+	return map[string]any{
+		"id":        repo.ID,
+		"name":      name,
+		"full_name": repo.FullName,
+		"html_url":  s.URL + "/" + repo.FullName,
+		"clone_url": s.URL + "/" + repo.FullName + ".git",
+		"ssh_url":   "git@" + strings.TrimPrefix(s.URL, "https://") + ":" + repo.FullName + ".git",
+	}
+}
+
+// listOrgRepos serves the repositories of an organisation sorted by full name,
+// paginated with the page and per_page parameters.
+func (s *Server) listOrgRepos(w http.ResponseWriter, r *http.Request, login string) {
+	if s.findOrg(func(o Org) bool { return strings.EqualFold(o.Login, login) }) == nil {
+		writeNotFound(w)
+		return
+	}
+	var repos []Repo
+	for _, repo := range s.repos {
+		if owner, _, _ := strings.Cut(repo.FullName, "/"); strings.EqualFold(owner, login) {
+			repos = append(repos, repo)
+		}
+	}
+	slices.SortFunc(repos, func(a, b Repo) int { return strings.Compare(a.FullName, b.FullName) })
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+	page = max(page, 1)
+	if perPage <= 0 {
+		perPage = 30
+	}
+	items := []map[string]any{}
+	for i := (page - 1) * perPage; i < len(repos) && i < page*perPage; i++ {
+		items = append(items, s.repoJSON(repos[i]))
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// search answers the total of the issues matching the qualifiers repo:, org:,
+// is:issue, is:pr, is:open, is:closed, label: and no:label. Other terms are ignored.
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	terms, err := splitQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "Validation Failed"})
+		return
+	}
+	var repoIDs []int
+	filtered := false
+	for _, term := range terms {
+		qualifier, value, _ := strings.Cut(term, ":")
+		switch qualifier {
+		case "repo":
+			filtered = true
+			if repo := s.findRepo(func(r Repo) bool { return strings.EqualFold(r.FullName, value) }); repo != nil {
+				repoIDs = append(repoIDs, repo.ID)
+			}
+		case "org":
+			filtered = true
+			for _, repo := range s.repos {
+				if owner, _, _ := strings.Cut(repo.FullName, "/"); strings.EqualFold(owner, value) {
+					repoIDs = append(repoIDs, repo.ID)
+				}
+			}
+		}
+	}
+	if filtered && len(repoIDs) == 0 {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{
+			"message": "The listed users and repositories cannot be searched",
+		})
+		return
+	}
+	total := 0
+	for _, id := range repoIDs {
+		for _, issue := range s.issues[id] {
+			if matches(issue, terms) {
+				total++
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"total_count": total, "incomplete_results": false, "items": []any{}})
+}
+
+func matches(issue Issue, terms []string) bool {
+	for _, term := range terms {
+		qualifier, value, _ := strings.Cut(term, ":")
+		switch {
+		case term == "is:issue" && issue.PullRequest,
+			term == "is:pr" && !issue.PullRequest,
+			term == "is:open" && issue.State != "open",
+			term == "is:closed" && issue.State != "closed",
+			term == "no:label" && len(issue.Labels) > 0,
+			qualifier == "label" && !slices.Contains(issue.Labels, value):
+			return false
+		}
+	}
+	return true
+}
+
+// splitQuery splits a search query on spaces, keeping quoted values together
+// and unquoting them: `label:"good first issue"` gives `label:good first issue`.
+func splitQuery(q string) ([]string, error) {
+	var terms []string
+	var term strings.Builder
+	quoted := false
+	for i := 0; i < len(q); i++ {
+		switch c := q[i]; {
+		case c == '"':
+			quoted = !quoted
+		case c == '\\' && quoted && i+1 < len(q):
+			i++
+			term.WriteByte(q[i])
+		case c == ' ' && !quoted:
+			if term.Len() > 0 {
+				terms = append(terms, term.String())
+				term.Reset()
+			}
+		default:
+			term.WriteByte(c)
+		}
+	}
+	if quoted {
+		return nil, strconv.ErrSyntax
+	}
+	if term.Len() > 0 {
+		terms = append(terms, term.String())
+	}
+	return terms, nil
+}
+
+func writeNotFound(w http.ResponseWriter) {
+	writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v) // ignore error
+}
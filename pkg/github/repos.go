@@ -0,0 +1,134 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+// repository is a GitHub repository.
+type repository struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	HTMLURL  string `json:"html_url"`
+	SSHURL   string `json:"ssh_url"`
+	CloneURL string `json:"clone_url"`
+}
+
+// organization is a GitHub organisation.
+type organization struct {
+	ID      int    `json:"id"`
+	Login   string `json:"login"`
+	Name    string `json:"name"`
+	HTMLURL string `json:"html_url"`
+}
+
+func (r repository) project() gitlab.Project {
+	return gitlab.Project{
+		ID:                r.ID,
+		Name:              r.Name,
+		PathWithNamespace: r.FullName,
+		SSHURLToRepo:      r.SSHURL,
+		HTTPURLToRepo:     r.CloneURL,
+		WebURL:            r.HTMLURL,
+	}
+}
+
+// Project returns a repository from its ID or its full name, for instance "owner/repo".
+func (c *Client) Project(ctx context.Context, idOrPath string) (gitlab.Project, error) {
+	idOrPath = strings.Trim(idOrPath, "/")
+	path := "repos/" + idOrPath
+	if _, err := strconv.Atoi(idOrPath); err == nil {
+		path = "repositories/" + idOrPath
+	}
+	var repo repository
+	if err := c.get(ctx, path, &repo); err != nil {
+		return gitlab.Project{}, err
+	}
+	c.remember(c.repoNames, repo.ID, repo.FullName)
+	return repo.project(), nil
+}
+
+// Group returns an organisation from its ID or its login.
+func (c *Client) Group(ctx context.Context, idOrPath string) (gitlab.Group, error) {
+	idOrPath = strings.Trim(idOrPath, "/")
+	path := "orgs/" + url.PathEscape(idOrPath)
+	if _, err := strconv.Atoi(idOrPath); err == nil {
+		path = "organizations/" + idOrPath
+	}
+	var org organization
+	if err := c.get(ctx, path, &org); err != nil {
+		return gitlab.Group{}, err
+	}
+	c.remember(c.orgNames, org.ID, org.Login)
+	name := org.Name
+	if name == "" {
+		name = org.Login
+	}
+	return gitlab.Group{ID: org.ID, Name: name, FullPath: org.Login, FullName: name, WebURL: org.HTMLURL}, nil
+}
+
+// GroupProjects returns the repositories of an organisation, forks included.
+func (c *Client) GroupProjects(ctx context.Context, groupID int) ([]gitlab.Project, error) {
+	org, err := c.orgName(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	var projects []gitlab.Project
+	for page := 1; ; page++ {
+		var repos []repository
+		path := fmt.Sprintf("orgs/%s/repos?type=all&sort=full_name&per_page=%d&page=%d", url.PathEscape(org), perPage, page)
+		if err := c.get(ctx, path, &repos); err != nil {
+			return nil, fmt.Errorf("failed to list repositories of %s: %w", org, err)
+		}
+		for _, repo := range repos {
+			c.remember(c.repoNames, repo.ID, repo.FullName)
+			projects = append(projects, repo.project())
+		}
+		if len(repos) < perPage {
+			return projects, nil
+		}
+	}
+}
+
+// repoName returns the full name of a repository from its ID.
+func (c *Client) repoName(ctx context.Context, id int) (string, error) {
+	if name, ok := c.lookup(c.repoNames, id); ok {
+		return name, nil
+	}
+	project, err := c.Project(ctx, strconv.Itoa(id))
+	if err != nil {
+		return "", err
+	}
+	return project.PathWithNamespace, nil
+}
+
+// orgName returns the login of an organisation from its ID.
+func (c *Client) orgName(ctx context.Context, id int) (string, error) {
+	if name, ok := c.lookup(c.orgNames, id); ok {
+		return name, nil
+	}
+	group, err := c.Group(ctx, strconv.Itoa(id))
+	if err != nil {
+		return "", err
+	}
+	return group.FullPath, nil
+}
+
+func (c *Client) remember(names map[int]string, id int, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names[id] = name
+}
+
+func (c *Client) lookup(names map[int]string, id int) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	name, ok := names[id]
+	return name, ok
+}
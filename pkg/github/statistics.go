@@ -0,0 +1,153 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+// ErrUnsupportedFilter is returned when an issue filter has no equivalent in
+// the GitHub search syntax, for instance a filter on the author ID.
+var ErrUnsupportedFilter = errors.New("filter not supported by GitHub")
+
+// searchResult is the response of the search API. Only the total is used.
+type searchResult struct {
+	TotalCount int `json:"total_count"`
+}
+
+// IssueStatistics counts the open and closed issues of a repository (project
+// scope) or an organisation (group scope) with the totals of the search API.
+// Pull requests are not counted.
+// See: https://docs.github.com/en/search-github/searching-on-github/searching-issues-and-pull-requests
+func (c *Client) IssueStatistics(
+	ctx context.Context,
+	scope gitlab.Scope,
+	filter gitlab.IssueFilter,
+) (gitlab.Statistics, error) {
+	if err := filter.Validate(); err != nil {
+		return gitlab.Statistics{}, err
+	}
+	qualifiers, err := searchQualifiers(filter)
+	if err != nil {
+		return gitlab.Statistics{}, err
+	}
+	var target string
+	switch scope.Kind {
+	case gitlab.ScopeProject:
+		name, err := c.repoName(ctx, scope.ID)
+		if err != nil {
+			return gitlab.Statistics{}, err
+		}
+		target = "repo:" + name
+	case gitlab.ScopeGroup:
+		name, err := c.orgName(ctx, scope.ID)
+		if err != nil {
+			return gitlab.Statistics{}, err
+		}
+		target = "org:" + name
+	default:
+		return gitlab.Statistics{}, fmt.Errorf("%w: issues of the whole GitHub %s", gitlab.ErrUnsupportedScope, scope)
+	}
+
+	var counts gitlab.Counts
+	for _, s := range []struct {
+		state string
+		count *int
+	}{
+		{"is:open", &counts.Opened},
+		{"is:closed", &counts.Closed},
+	} {
+		query := strings.Join(append([]string{target, "is:issue", s.state}, qualifiers...), " ")
+		var result searchResult
+		if err := c.get(ctx, "search/issues?"+url.Values{"q": {query}, "per_page": {"1"}}.Encode(), &result); err != nil {
+			return gitlab.Statistics{}, err
+		}
+		*s.count = result.TotalCount
+	}
+	counts.All = counts.Opened + counts.Closed
+	return gitlab.Statistics{Statistics: gitlab.Statistic{Counts: counts}}, nil
+}
+
+// MergeRequestStatistics is not supported on GitHub.
+func (c *Client) MergeRequestStatistics(_ context.Context, scope gitlab.Scope) (gitlab.MergeRequestCounts, error) {
+	return gitlab.MergeRequestCounts{}, fmt.Errorf("%w: pull requests of GitHub %s", gitlab.ErrUnsupportedScope, scope)
+}
+
+// searchQualifiers translates an issue filter to GitHub search qualifiers.
+func searchQualifiers(f gitlab.IssueFilter) ([]string, error) {
+	var q []string
+	unsupported := func(what string) ([]string, error) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFilter, what)
+	}
+	for _, label := range f.Labels {
+		switch label {
+		case "None":
+			q = append(q, "no:label")
+		case "Any":
+			return unsupported("any label")
+		default:
+			q = append(q, "label:"+strconv.Quote(label))
+		}
+	}
+	switch f.Milestone {
+	case "":
+	case "None":
+		q = append(q, "no:milestone")
+	case "Any":
+		return unsupported("any milestone")
+	default:
+		q = append(q, "milestone:"+strconv.Quote(f.Milestone))
+	}
+	if f.Scope != "" && f.Scope != "all" {
+		return unsupported("scope " + f.Scope)
+	}
+	if f.AuthorID != 0 {
+		return unsupported("author ID, use the author username")
+	}
+	if f.AuthorUsername != "" {
+		q = append(q, "author:"+f.AuthorUsername)
+	}
+	switch f.AssigneeID {
+	case "":
+	case "None":
+		q = append(q, "no:assignee")
+	default:
+		return unsupported("assignee ID, use the assignee username")
+	}
+	if f.AssigneeUsername != "" {
+		q = append(q, "assignee:"+f.AssigneeUsername)
+	}
+	if f.Search != "" {
+		q = append(q, f.Search)
+		if f.In != "" {
+			q = append(q, "in:"+strings.ReplaceAll(f.In, "description", "body"))
+		}
+	}
+	q = appendDateRange(q, "created", f.CreatedAfter, f.CreatedBefore)
+	q = appendDateRange(q, "updated", f.UpdatedAfter, f.UpdatedBefore)
+	if f.Confidential != nil && *f.Confidential {
+		return unsupported("confidential issues")
+	}
+	return q, nil
+}
+
+// appendDateRange adds a range qualifier, bounds included.
+func appendDateRange(q []string, name string, after, before *time.Time) []string {
+	const layout = "2006-01-02T15:04:05Z"
+	switch {
+	case after != nil && before != nil:
+		return append(q, fmt.Sprintf("%s:%s..%s", name, after.UTC().Format(layout), before.UTC().Format(layout)))
+	case after != nil:
+		return append(q, fmt.Sprintf("%s:>=%s", name, after.UTC().Format(layout)))
+	case before != nil:
+		return append(q, fmt.Sprintf("%s:<=%s", name, before.UTC().Format(layout)))
+	default:
+		return q
+	}
+}
@@ -0,0 +1,150 @@
+package gitlab
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+)
+
+// CacheHeader is set to "HIT" on the responses served from the cache after
+// GitLab answered 304 Not Modified: the data is unchanged since the last request.
+const CacheHeader = "X-Gitlab-Stats-Cache"
+
+// Cache is an on-disk cache of GET responses with an ETag or a Last-Modified
+// header. Cached requests are sent with If-None-Match and If-Modified-Since,
+// and a 304 Not Modified answer is served from the cache.
+// Entries are specific to the token, as the visible data depends on it.
+type Cache struct {
+	dir    string
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+type cacheEntry struct {
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	Header       http.Header `json:"header"`
+	Body         []byte      `json:"body"`
+}
+
+// NewCache returns a cache storing its entries in dir, created if needed.
+func NewCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// Hits returns the number of responses served from the cache because GitLab answered 304.
+func (c *Cache) Hits() int64 {
+	return c.hits.Load()
+}
+
+// Misses returns the number of cacheable requests which returned new data.
+func (c *Cache) Misses() int64 {
+	return c.misses.Load()
+}
+
+// FromCache reports whether a response was served from the cache.
+func FromCache(resp *http.Response) bool {
+	return resp.Header.Get(CacheHeader) == "HIT"
+}
+
+func (c *Cache) path(req *http.Request) string {
+	h := sha256.New()
+	_, _ = io.WriteString(h, req.URL.String())
+	for _, name := range []string{PrivateTokenHeader, JobTokenHeader} {
+		_, _ = io.WriteString(h, "\n"+req.Header.Get(name))
+	}
+	return filepath.Join(c.dir, hex.EncodeToString(h.Sum(nil))+".json")
+}
+
+func (c *Cache) load(req *http.Request) (*cacheEntry, bool) {
+	content, err := os.ReadFile(c.path(req))
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(content, &entry); err != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// prepare adds the validators of the cached entry to the request.
+func (c *Cache) prepare(req *http.Request) *cacheEntry {
+	entry, ok := c.load(req)
+	if !ok {
+		return nil
+	}
+	if entry.ETag != "" {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+	if entry.LastModified != "" {
+		req.Header.Set("If-Modified-Since", entry.LastModified)
+	}
+	return entry
+}
+
+// handle serves 304 responses from the cached entry and stores the new cacheable responses.
+func (c *Cache) handle(req *http.Request, resp *http.Response, entry *cacheEntry) (*http.Response, error) {
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		_ = resp.Body.Close() // ignore error
+		c.hits.Add(1)
+		header := entry.Header.Clone()
+		// the rate limit headers of the 304 are the current ones
+		for name, values := range resp.Header {
+			header[name] = values
+		}
+		header.Set(CacheHeader, "HIT")
+		resp.StatusCode = http.StatusOK
+		resp.Status = "200 OK"
+		resp.Header = header
+		resp.Body = io.NopCloser(bytes.NewReader(entry.Body))
+		resp.ContentLength = int64(len(entry.Body))
+		return resp, nil
+	}
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if resp.StatusCode != http.StatusOK || (etag == "" && lastModified == "") {
+		return resp, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close() // ignore error
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	c.misses.Add(1)
+	c.store(req, &cacheEntry{ETag: etag, LastModified: lastModified, Header: resp.Header.Clone(), Body: body})
+	return resp, nil
+}
+
+// store writes the entry atomically. Errors are ignored: the cache is an optimization.
+func (c *Cache) store(req *http.Request, entry *cacheEntry) {
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(c.dir, "entry-*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), c.path(req)); err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
@@ -0,0 +1,110 @@
+package gitlab_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+func TestCacheETag(t *testing.T) {
+	requests := 0
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.Header().Set("ETag", `W/"v1"`)
+			if r.Header.Get("If-None-Match") == `W/"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			fmt.Fprintln(w, `{"statistics":{"counts":{"all":3,"closed":1,"opened":2}}}`)
+		}))
+	defer ts.Close()
+
+	cache, err := gitlab.NewCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	s := gitlab.NewService()
+	s.SetHTTPClient(ts.Client())
+	s.SetGitlabEndpoint(ts.URL)
+	s.SetToken("token")
+	s.SetCache(cache)
+
+	for i := range 2 {
+		res, err := gitlab.NewProjectStatistics(1).GetStatistics(s)
+		if err != nil {
+			t.Fatalf("GetStatistics() #%d error = %v", i, err)
+		}
+		if res.Statistics.Counts.Opened != 2 {
+			t.Errorf("GetStatistics() #%d = %+v, want 2 opened issues", i, res.Statistics.Counts)
+		}
+		if res.FromCache != (i == 1) {
+			t.Errorf("GetStatistics() #%d FromCache = %v, want %v", i, res.FromCache, i == 1)
+		}
+	}
+	if cache.Hits() != 1 || cache.Misses() != 1 {
+		t.Errorf("cache hits = %d, misses = %d, want 1 and 1", cache.Hits(), cache.Misses())
+	}
+
+	resp, err := s.Get("projects/1/issues_statistics?")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer func() {
+		_ = resp.Body.Close() // ignore error
+	}()
+	body, _ := io.ReadAll(resp.Body)
+	if !gitlab.FromCache(resp) || resp.StatusCode != http.StatusOK || len(body) == 0 {
+		t.Errorf("Get() = %d %q, want the cached response", resp.StatusCode, body)
+	}
+
+	// entries are specific to the token
+	s.SetToken("other")
+	if _, err := gitlab.NewProjectStatistics(1).GetStatistics(s); err != nil {
+		t.Fatalf("GetStatistics() error = %v", err)
+	}
+	if cache.Hits() != 2 || cache.Misses() != 2 {
+		t.Errorf("cache hits = %d, misses = %d, want 2 and 2", cache.Hits(), cache.Misses())
+	}
+}
+
+func TestCacheLastModified(t *testing.T) {
+	const lastModified = "Wed, 14 Oct 2026 10:00:00 GMT"
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("If-Modified-Since") == lastModified {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Last-Modified", lastModified)
+			w.Header().Set("X-Total", "7")
+			fmt.Fprintln(w, `[]`)
+		}))
+	defer ts.Close()
+
+	cache, err := gitlab.NewCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	s := gitlab.NewService()
+	s.SetHTTPClient(ts.Client())
+	s.SetGitlabEndpoint(ts.URL)
+	s.SetCache(cache)
+
+	for range 2 {
+		counts, err := gitlab.NewProjectMergeRequestStatistics(1).GetStatistics(s)
+		if err != nil {
+			t.Fatalf("GetStatistics() error = %v", err)
+		}
+		if counts.All != 7 {
+			t.Errorf("GetStatistics() = %+v, want the X-Total of the cached response", counts)
+		}
+	}
+	if cache.Hits() != 5 {
+		t.Errorf("cache hits = %d, want 5", cache.Hits())
+	}
+}
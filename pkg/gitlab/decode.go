@@ -0,0 +1,146 @@
+package gitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrMissingField is returned when a field required by this package is
+// missing from a GitLab response.
+var ErrMissingField = errors.New("field missing from response")
+
+// SchemaDrift describes the fields of a GitLab response which are unknown to
+// this package, usually added by a GitLab upgrade.
+type SchemaDrift struct {
+	// Endpoint is the requested path, without the query string.
+	Endpoint string
+	// Fields holds the raw value of each unknown field, by dotted path
+	// (for instance "statistics.counts.archived").
+	Fields map[string]json.RawMessage
+	// New holds the sorted paths of the fields never reported before for
+	// this endpoint during the lifetime of the Service.
+	New []string
+}
+
+// Names returns the sorted paths of the unknown fields.
+func (d SchemaDrift) Names() []string {
+	names := make([]string, 0, len(d.Fields))
+	for name := range d.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetStrictDecoding makes responses with unknown fields fail to decode.
+// By default, unknown fields are accepted and reported to the schema drift handler.
+func (r *Service) SetStrictDecoding(strict bool) {
+	r.strictDecoding = strict
+}
+
+// SetSchemaDriftHandler sets the function called with the unknown fields of
+// every response which has some. SchemaDrift.New tells which of them are
+// reported for the first time on their endpoint.
+func (r *Service) SetSchemaDriftHandler(handler func(SchemaDrift)) {
+	r.driftMu.Lock()
+	defer r.driftMu.Unlock()
+	r.driftHandler = handler
+}
+
+// decodeJSON decodes body into v. The top-level fields listed in required
+// must be present. In strict mode, unknown fields are an error; otherwise
+// they are reported in raw form to the drift handler.
+func (r *Service) decodeJSON(endpoint string, body []byte, v any, required ...string) error {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(body, &object); err != nil {
+		return fmt.Errorf("failed to decode JSON response: %w", err)
+	}
+	for _, name := range required {
+		if _, ok := object[name]; !ok {
+			return fmt.Errorf("%w: %s", ErrMissingField, name)
+		}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if r.strictDecoding {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("failed to decode JSON response: %w", err)
+	}
+	if r.strictDecoding {
+		return nil
+	}
+	unknown := map[string]json.RawMessage{}
+	collectUnknownFields(object, reflect.TypeOf(v), "", unknown)
+	if len(unknown) > 0 {
+		r.reportDrift(endpoint, unknown)
+	}
+	return nil
+}
+
+// reportDrift calls the drift handler with the unknown fields of a response,
+// flagging the fields not reported yet for the endpoint.
+func (r *Service) reportDrift(endpoint string, fields map[string]json.RawMessage) {
+	r.driftMu.Lock()
+	defer r.driftMu.Unlock()
+	if r.driftHandler == nil {
+		return
+	}
+	if r.driftSeen == nil {
+		r.driftSeen = map[string]bool{}
+	}
+	drift := SchemaDrift{Endpoint: endpoint, Fields: fields}
+	for _, name := range drift.Names() {
+		if key := endpoint + " " + name; !r.driftSeen[key] {
+			r.driftSeen[key] = true
+			drift.New = append(drift.New, name)
+		}
+	}
+	r.driftHandler(drift)
+}
+
+// collectUnknownFields adds to unknown the fields of object which are not
+// decoded into a value of type t. As with encoding/json, names are matched
+// case-insensitively.
+func collectUnknownFields(object map[string]json.RawMessage, t reflect.Type, prefix string, unknown map[string]json.RawMessage) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for key, value := range object {
+		field, ok := jsonField(t, key)
+		if !ok {
+			unknown[prefix+key] = value
+			continue
+		}
+		var nested map[string]json.RawMessage
+		if json.Unmarshal(value, &nested) == nil {
+			collectUnknownFields(nested, field.Type, prefix+key+".", unknown)
+		}
+	}
+}
+
+// jsonField returns the field of the struct type t decoded from the JSON key.
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
@@ -0,0 +1,137 @@
+package gitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const maxErrorBodySize = 64 * 1024
+
+var (
+	// ErrUnauthorized is returned when the token is missing, invalid or expired (401).
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden is returned when the token is not allowed to access the resource,
+	// for instance because it lacks a scope (403).
+	ErrForbidden = errors.New("forbidden")
+	// ErrNotFound is returned when the resource does not exist or is not visible (404).
+	ErrNotFound = errors.New("not found")
+	// ErrRateLimited is returned when the rate limit is still exceeded after retries (429).
+	ErrRateLimited = errors.New("rate limited")
+	// ErrServerError is returned when GitLab answers with a 5xx status code.
+	ErrServerError = errors.New("server error")
+)
+
+// APIError is returned when the GitLab API answers with a non-200 status code.
+// It matches ErrNon200Response and one of the sentinel errors above with errors.Is.
+type APIError struct {
+	StatusCode int
+	// Path is the requested URL path.
+	Path string
+	// Message is the GitLab "message" or "error_description" field.
+	Message string
+	// Code is the GitLab "error" field, for instance "insufficient_scope".
+	Code string
+	// Scope lists the scopes required by the endpoint, when GitLab reports it.
+	Scope string
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s: %d", ErrNon200Response, e.StatusCode)
+	if e.Path != "" {
+		msg += " on " + e.Path
+	}
+	switch {
+	case e.Message != "" && e.Code != "":
+		msg += fmt.Sprintf(" (%s: %s)", e.Code, e.Message)
+	case e.Message != "":
+		msg += fmt.Sprintf(" (%s)", e.Message)
+	case e.Code != "":
+		msg += fmt.Sprintf(" (%s)", e.Code)
+	}
+	return msg
+}
+
+// Is reports whether the error matches ErrNon200Response or the sentinel of its status code.
+func (e *APIError) Is(target error) bool {
+	if target == ErrNon200Response { //nolint:errorlint // sentinel comparison
+		return true
+	}
+	sentinel := e.sentinel()
+	return sentinel != nil && target == sentinel //nolint:errorlint // sentinel comparison
+}
+
+// InsufficientScope reports whether the token lacks a scope required by the endpoint.
+func (e *APIError) InsufficientScope() bool {
+	return e.StatusCode == http.StatusForbidden && e.Code == "insufficient_scope"
+}
+
+func (e *APIError) sentinel() error {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusForbidden:
+		return ErrForbidden
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrServerError
+	default:
+		return nil
+	}
+}
+
+// errorBody is the JSON body returned by GitLab on errors.
+// message can be a string, a list or an object (validation errors).
+type errorBody struct {
+	Message          json.RawMessage `json:"message"`
+	Error            string          `json:"error"`
+	ErrorDescription string          `json:"error_description"`
+	Scope            string          `json:"scope"`
+}
+
+// newAPIError builds an APIError from a non-200 response and reads its body.
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	if resp.Request != nil && resp.Request.URL != nil {
+		apiErr.Path = resp.Request.URL.Path
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil || len(bytes.TrimSpace(body)) == 0 {
+		return apiErr
+	}
+	var eb errorBody
+	if err := json.Unmarshal(body, &eb); err != nil {
+		return apiErr
+	}
+	apiErr.Code = eb.Error
+	apiErr.Scope = eb.Scope
+	apiErr.Message = eb.ErrorDescription
+	if msg := rawMessageString(eb.Message); msg != "" {
+		apiErr.Message = msg
+	}
+	return apiErr
+}
+
+// rawMessageString returns a string message as is and other JSON values compacted.
+func rawMessageString(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return strings.TrimSpace(string(raw))
+	}
+	return buf.String()
+}
@@ -0,0 +1,101 @@
+package gitlab_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+func TestGetStatisticsAPIErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		want       error
+		message    string
+		code       string
+	}{
+		{
+			name:       "unauthorized",
+			statusCode: http.StatusUnauthorized,
+			body:       `{"message":"401 Unauthorized"}`,
+			want:       gitlab.ErrUnauthorized,
+			message:    "401 Unauthorized",
+		},
+		{
+			name:       "insufficient scope",
+			statusCode: http.StatusForbidden,
+			body: `{"error":"insufficient_scope",` +
+				`"error_description":"The request requires higher privileges than provided by the access token.",` +
+				`"scope":"api read_api"}`,
+			want:    gitlab.ErrForbidden,
+			message: "The request requires higher privileges than provided by the access token.",
+			code:    "insufficient_scope",
+		},
+		{
+			name:       "not found",
+			statusCode: http.StatusNotFound,
+			body:       `{"message":"404 Project Not Found"}`,
+			want:       gitlab.ErrNotFound,
+			message:    "404 Project Not Found",
+		},
+		{
+			name:       "validation message object",
+			statusCode: http.StatusBadRequest,
+			body:       `{"message":{"labels":["is invalid"]}}`,
+			want:       gitlab.ErrNon200Response,
+			message:    `{"labels":["is invalid"]}`,
+		},
+		{
+			name:       "server error without body",
+			statusCode: http.StatusInternalServerError,
+			want:       gitlab.ErrServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewTLSServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(tt.statusCode)
+					fmt.Fprint(w, tt.body)
+				}))
+			defer ts.Close()
+
+			s := gitlab.NewService()
+			s.SetHTTPClient(ts.Client())
+			s.SetGitlabEndpoint(ts.URL)
+			s.SetRetryPolicy(gitlab.RetryPolicy{})
+
+			_, err := gitlab.NewProjectStatistics(1).GetStatistics(s)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("GetStatistics() error = %v, want %v", err, tt.want)
+			}
+			if !errors.Is(err, gitlab.ErrNon200Response) {
+				t.Errorf("GetStatistics() error = %v, want %v", err, gitlab.ErrNon200Response)
+			}
+			var apiErr *gitlab.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("GetStatistics() error is not an *APIError")
+			}
+			if apiErr.StatusCode != tt.statusCode {
+				t.Errorf("StatusCode = %d, want %d", apiErr.StatusCode, tt.statusCode)
+			}
+			if apiErr.Message != tt.message {
+				t.Errorf("Message = %q, want %q", apiErr.Message, tt.message)
+			}
+			if apiErr.Code != tt.code {
+				t.Errorf("Code = %q, want %q", apiErr.Code, tt.code)
+			}
+			if apiErr.Path != "/projects/1/issues_statistics" {
+				t.Errorf("Path = %q, want /projects/1/issues_statistics", apiErr.Path)
+			}
+			if apiErr.InsufficientScope() != (tt.code == "insufficient_scope") {
+				t.Errorf("InsufficientScope() = %v", apiErr.InsufficientScope())
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrUnsupportedScope is returned when the statistics are not available for a scope,
+// for instance the merge requests of the whole instance.
+var ErrUnsupportedScope = errors.New("unsupported scope")
+
+// ScopeKind is the kind of entity of a Scope.
+type ScopeKind string
+
+// Kinds of scopes.
+const (
+	ScopeProject  ScopeKind = "project"
+	ScopeGroup    ScopeKind = "group"
+	ScopeInstance ScopeKind = "instance"
+)
+
+// Scope designates the issues or merge requests of a project, of a group and
+// its subgroups, or of the whole instance.
+type Scope struct {
+	Kind ScopeKind
+	// ID is the ID of the project or group, zero for the instance.
+	ID int
+}
+
+// ProjectScope returns the scope of a project.
+func ProjectScope(projectID int) Scope {
+	return Scope{Kind: ScopeProject, ID: projectID}
+}
+
+// GroupScope returns the scope of a group and its subgroups.
+func GroupScope(groupID int) Scope {
+	return Scope{Kind: ScopeGroup, ID: groupID}
+}
+
+// InstanceScope returns the scope of everything visible with the token.
+func InstanceScope() Scope {
+	return Scope{Kind: ScopeInstance}
+}
+
+// String returns a human readable name of the scope, for instance "project 42".
+func (s Scope) String() string {
+	if s.Kind == ScopeInstance {
+		return string(s.Kind)
+	}
+	return fmt.Sprintf("%s %d", s.Kind, s.ID)
+}
+
+// StatisticsFetcher fetches the issue and merge request statistics of a scope.
+// Service implements it with the GitLab API; fakes, caching decorators or
+// other forges can be plugged in instead.
+type StatisticsFetcher interface {
+	IssueStatistics(ctx context.Context, scope Scope, filter IssueFilter) (Statistics, error)
+	MergeRequestStatistics(ctx context.Context, scope Scope) (MergeRequestCounts, error)
+}
+
+// MetadataFetcher looks up projects and groups.
+type MetadataFetcher interface {
+	// Project returns a project from its ID or its full path.
+	Project(ctx context.Context, idOrPath string) (Project, error)
+	// Group returns a group from its ID or its full path.
+	Group(ctx context.Context, idOrPath string) (Group, error)
+	// GroupProjects returns the projects of a group and of all its subgroups.
+	GroupProjects(ctx context.Context, groupID int) ([]Project, error)
+}
+
+// Fetcher fetches both statistics and metadata.
+type Fetcher interface {
+	StatisticsFetcher
+	MetadataFetcher
+}
+
+// IssueLister lists the issues of a scope.
+type IssueLister interface {
+	// Issues returns the issues of the scope created before the date, every
+	// issue if it is zero.
+	Issues(ctx context.Context, scope Scope, createdBefore time.Time) ([]Issue, error)
+	// ClosedIssues returns the closed issues of the scope updated since the
+	// date, including every issue closed since then.
+	ClosedIssues(ctx context.Context, scope Scope, since time.Time) ([]Issue, error)
+}
+
+var (
+	_ Fetcher     = (*Service)(nil)
+	_ IssueLister = (*Service)(nil)
+)
+
+// IssueStatistics retrieves the issue statistics of the scope.
+func (r *Service) IssueStatistics(ctx context.Context, scope Scope, filter IssueFilter) (Statistics, error) {
+	var n *ServiceStatistics
+	switch scope.Kind {
+	case ScopeProject:
+		n = NewProjectStatistics(scope.ID)
+	case ScopeGroup:
+		n = NewGroupStatistics(scope.ID)
+	case ScopeInstance:
+		n = NewInstanceStatistics()
+	default:
+		return Statistics{}, fmt.Errorf("%w: %s", ErrUnsupportedScope, scope)
+	}
+	n.SetFilter(filter)
+	return n.GetStatisticsWithContext(ctx, r)
+}
+
+// MergeRequestStatistics retrieves the merge request counts of a project or group.
+func (r *Service) MergeRequestStatistics(ctx context.Context, scope Scope) (MergeRequestCounts, error) {
+	var n *ServiceMergeRequestStatistics
+	switch scope.Kind {
+	case ScopeProject:
+		n = NewProjectMergeRequestStatistics(scope.ID)
+	case ScopeGroup:
+		n = NewGroupMergeRequestStatistics(scope.ID)
+	default:
+		return MergeRequestCounts{}, fmt.Errorf("%w: merge requests of %s", ErrUnsupportedScope, scope)
+	}
+	return n.GetStatisticsWithContext(ctx, r)
+}
+
+// Project returns a project from its ID or its full path.
+func (r *Service) Project(ctx context.Context, idOrPath string) (Project, error) {
+	return GetProject(ctx, r, idOrPath)
+}
+
+// Group returns a group from its ID or its full path.
+func (r *Service) Group(ctx context.Context, idOrPath string) (Group, error) {
+	return GetGroup(ctx, r, idOrPath)
+}
+
+// GroupProjects returns the projects of a group and of all its subgroups.
+func (r *Service) GroupProjects(ctx context.Context, groupID int) ([]Project, error) {
+	return ListGroupProjects(ctx, r, groupID)
+}
+
+// Issues returns the issues of the scope created before the date, open or
+// closed, oldest first.
+func (r *Service) Issues(ctx context.Context, scope Scope, createdBefore time.Time) ([]Issue, error) {
+	return ListIssues(ctx, r, scope, createdBefore)
+}
+
+// ClosedIssues returns the closed issues of the scope updated since the date.
+func (r *Service) ClosedIssues(ctx context.Context, scope Scope, since time.Time) ([]Issue, error) {
+	return ListClosedIssues(ctx, r, scope, since)
+}
@@ -0,0 +1,59 @@
+package gitlab_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab/gitlabtest"
+)
+
+func TestServiceFetcher(t *testing.T) {
+	ctx := context.Background()
+	srv := gitlabtest.NewServer(t)
+	srv.AddGroup(gitlabtest.Group{ID: 1, Name: "grp", FullPath: "grp"})
+	srv.AddProject(gitlabtest.Project{ID: 42, Name: "proj", PathWithNamespace: "grp/proj", GroupID: 1})
+	srv.AddIssues(42, gitlabtest.Issue{State: "opened", Labels: []string{"bug"}}, gitlabtest.Issue{State: "closed"})
+	srv.AddMergeRequests(42, gitlabtest.MergeRequest{State: "merged"})
+
+	var f gitlab.Fetcher = srv.NewService()
+	tests := []struct {
+		scope  gitlab.Scope
+		filter gitlab.IssueFilter
+		want   gitlab.Counts
+	}{
+		{scope: gitlab.ProjectScope(42), want: gitlab.Counts{All: 2, Closed: 1, Opened: 1}},
+		{scope: gitlab.GroupScope(1), want: gitlab.Counts{All: 2, Closed: 1, Opened: 1}},
+		{scope: gitlab.InstanceScope(), filter: gitlab.IssueFilter{Labels: []string{"bug"}}, want: gitlab.Counts{All: 1, Opened: 1}},
+	}
+	for _, tt := range tests {
+		stats, err := f.IssueStatistics(ctx, tt.scope, tt.filter)
+		if err != nil {
+			t.Fatalf("IssueStatistics(%s) error = %v", tt.scope, err)
+		}
+		if stats.Statistics.Counts != tt.want {
+			t.Errorf("IssueStatistics(%s) = %+v, want %+v", tt.scope, stats.Statistics.Counts, tt.want)
+		}
+	}
+
+	counts, err := f.MergeRequestStatistics(ctx, gitlab.ProjectScope(42))
+	if err != nil {
+		t.Fatalf("MergeRequestStatistics() error = %v", err)
+	}
+	if counts.All != 1 || counts.Merged != 1 {
+		t.Errorf("MergeRequestStatistics() = %+v, want 1 merged", counts)
+	}
+	if _, err := f.MergeRequestStatistics(ctx, gitlab.InstanceScope()); !errors.Is(err, gitlab.ErrUnsupportedScope) {
+		t.Errorf("MergeRequestStatistics(instance) error = %v, want %v", err, gitlab.ErrUnsupportedScope)
+	}
+
+	project, err := f.Project(ctx, "grp/proj")
+	if err != nil || project.ID != 42 {
+		t.Errorf("Project() = %+v, %v, want project 42", project, err)
+	}
+	projects, err := f.GroupProjects(ctx, 1)
+	if err != nil || len(projects) != 1 {
+		t.Errorf("GroupProjects() = %+v, %v, want 1 project", projects, err)
+	}
+}
@@ -3,27 +3,41 @@ package gitlab
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
+	"sync"
+	"time"
 )
 
 // GitlabAPIEndpoint is the default GitLab API endpoint.
 const GitlabAPIEndpoint = "https://gitlab.com/api/v4"
 
+// DefaultHTTPTimeout is the timeout of the HTTP client created by NewService.
+const DefaultHTTPTimeout = 60 * time.Second
+
 // Service provides access to GitLab API.
 type Service struct {
 	gitlabAPIEndpoint string
-	token             string
+	tokenSource       TokenSource
+	tokenMu           sync.Mutex
+	token             *Token
 	httpClient        *http.Client
+	retrier           *Retrier
+	cache             *Cache
+	strictDecoding    bool
+	driftMu           sync.Mutex
+	driftHandler      func(SchemaDrift)
+	driftSeen         map[string]bool // by endpoint and field
 }
 
 // NewService returns a new Service.
 func NewService() *Service {
 	return &Service{
 		gitlabAPIEndpoint: GitlabAPIEndpoint,
-		token:             os.Getenv("GITLAB_TOKEN"),
-		httpClient:        &http.Client{},
+		tokenSource:       DefaultTokenSource(),
+		httpClient:        &http.Client{Timeout: DefaultHTTPTimeout},
+		retrier:           NewRetrier(GitLabRateLimitHeaders, nil),
 	}
 }
 
@@ -38,7 +52,40 @@ func (r *Service) SetGitlabEndpoint(gitlabAPIEndpoint string) {
 // SetToken sets the Gitlab API token
 // default: GITLAB_TOKEN env variable
 func (r *Service) SetToken(token string) {
-	r.token = token
+	r.SetTokenSource(NewStaticTokenSource(Token{Value: token, Header: PrivateTokenHeader, Source: "SetToken"}))
+}
+
+// SetTokenSource sets the source of the Gitlab API token.
+// The token is read once, before the first request.
+// default: DefaultTokenSource()
+func (r *Service) SetTokenSource(source TokenSource) {
+	r.tokenMu.Lock()
+	defer r.tokenMu.Unlock()
+	r.tokenSource = source
+	r.token = nil
+}
+
+// authenticate sets the token header of the request.
+// Requests are anonymous when the token source has no token.
+func (r *Service) authenticate(req *http.Request) error {
+	r.tokenMu.Lock()
+	defer r.tokenMu.Unlock()
+	if r.token == nil {
+		token, err := r.tokenSource.Token()
+		if err != nil && !errors.Is(err, ErrNoToken) {
+			return fmt.Errorf("failed to get token: %w", err)
+		}
+		r.token = &token
+	}
+	if r.token.Value != "" {
+		req.Header.Set(r.token.Header, r.token.Value)
+	}
+	return nil
+}
+
+// SetCache enables conditional GET requests with the given cache (nil to disable).
+func (r *Service) SetCache(cache *Cache) {
+	r.cache = cache
 }
 
 // SetHTTPClient sets the HTTP client to use for requests.
@@ -48,31 +95,60 @@ func (r *Service) SetHTTPClient(httpClient *http.Client) {
 
 // Get sends a GET request to the Gitlab API to the given path.
 func (r *Service) Get(path string) (*http.Response, error) {
-	url := fmt.Sprintf("%s/%s", r.gitlabAPIEndpoint, path)
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	return r.GetWithContext(context.Background(), path)
+}
+
+// GetWithContext sends a GET request to the Gitlab API to the given path.
+// The request is throttled according to the rate limit headers and retried
+// following the retry policy, until the context is done.
+func (r *Service) GetWithContext(ctx context.Context, path string) (*http.Response, error) {
+	return r.getURL(ctx, fmt.Sprintf("%s/%s", r.gitlabAPIEndpoint, path))
+}
+
+// getURL sends a GET request to the given absolute URL.
+func (r *Service) getURL(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create GET request: %w", err)
 	}
-	req.Header.Set("Private-Token", r.token)
+	if err := r.authenticate(req); err != nil {
+		return nil, err
+	}
 	req.Header.Set("Content-Type", "application/json")
-	resp, err := r.httpClient.Do(req)
+	if r.cache == nil {
+		return r.doWithRetry(req)
+	}
+	entry := r.cache.prepare(req)
+	resp, err := r.doWithRetry(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute GET request: %w", err)
+		return nil, err
 	}
-	return resp, nil
+	return r.cache.handle(req, resp, entry)
 }
 
 // Post sends a POST request to the Gitlab API to the given path.
 func (r *Service) Post(path string) (*http.Response, error) {
+	return r.PostWithContext(context.Background(), path)
+}
+
+// PostWithContext sends a POST request to the Gitlab API to the given path.
+// POST requests are throttled but never retried.
+func (r *Service) PostWithContext(ctx context.Context, path string) (*http.Response, error) {
 	url := fmt.Sprintf("%s/%s", r.gitlabAPIEndpoint, path)
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create POST request: %w", err)
 	}
-	req.Header.Set("Private-Token", r.token)
+	if err := r.authenticate(req); err != nil {
+		return nil, err
+	}
+	if err := r.retrier.limiter.wait(req.Context()); err != nil {
+		return nil, err
+	}
 	resp, err := r.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute POST request: %w", err)
 	}
+	r.retrier.limiter.update(resp.Header)
 	return resp, nil
 }
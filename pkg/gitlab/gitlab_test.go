@@ -1,11 +1,14 @@
 package gitlab_test
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
 )
@@ -135,3 +138,22 @@ func TestService_Post_CheckToken(t *testing.T) {
 		t.Error("tokn not found in response")
 	}
 }
+
+func TestService_GetWithContextCanceled(t *testing.T) {
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+	defer ts.Close()
+
+	r := gitlab.NewService()
+	r.SetHTTPClient(ts.Client())
+	r.SetGitlabEndpoint(ts.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := r.GetWithContext(ctx, "groups")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetWithContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
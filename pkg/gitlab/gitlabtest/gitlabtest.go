@@ -0,0 +1,540 @@
+// Package gitlabtest provides an in-process fake GitLab API for tests.
+//
+// The server is programmed with groups, projects, issues and merge requests,
+// and serves the endpoints used by the gitlab package: issues statistics,
+// project search, and the paginated lists of projects, issues and merge
+// requests. Failures and rate limits can be injected to test retries and
+// error handling.
+package gitlabtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+// APIPath is the path of the API on the server, as on a real GitLab.
+const APIPath = "/api/v4"
+
+const defaultPerPage = 20
+
+// Group is a group of the fake server.
+type Group struct {
+	ID       int
+	Name     string
+	FullPath string
+	// ParentID is the ID of the parent group, 0 for a top-level group.
+	ParentID int
+}
+
+// Project is a project of the fake server.
+type Project struct {
+	ID                int
+	Name              string
+	PathWithNamespace string
+	// GroupID is the ID of the group of the project, 0 for a user project.
+	GroupID int
+}
+
+// Issue is an issue of the fake server.
+type Issue struct {
+	IID       int
+	State     string // opened or closed
+	Labels    []string
+	CreatedAt time.Time
+	ClosedAt  *time.Time
+}
+
+// updatedAt returns the last update of the issue: its closing, or its creation.
+func (i Issue) updatedAt() time.Time {
+	if i.ClosedAt != nil {
+		return *i.ClosedAt
+	}
+	return i.CreatedAt
+}
+
+// MergeRequest is a merge request of the fake server.
+type MergeRequest struct {
+	IID   int
+	State string // opened, merged, closed or locked
+}
+
+type failure struct {
+	pathPrefix string
+	status     int
+	remaining  int
+}
+
+// Server is a fake GitLab API. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	token         string
+	groups        map[int]Group
+	projects      map[int]Project
+	issues        map[int][]Issue
+	mergeRequests map[int][]MergeRequest
+	failures      []*failure
+	rateLimited   int
+	retryAfter    time.Duration
+	requests      []string
+}
+
+// NewServer starts a fake GitLab API over TLS, closed at the end of the test.
+func NewServer(tb testing.TB) *Server {
+	tb.Helper()
+	s := &Server{
+		groups:        map[int]Group{},
+		projects:      map[int]Project{},
+		issues:        map[int][]Issue{},
+		mergeRequests: map[int][]MergeRequest{},
+	}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// Endpoint returns the API endpoint of the server, to use with Service.SetGitlabEndpoint.
+func (s *Server) Endpoint() string {
+	return s.URL + APIPath
+}
+
+// NewService returns a gitlab.Service using the server, without retry delays.
+func (s *Server) NewService() *gitlab.Service {
+	gs := gitlab.NewService()
+	gs.SetHTTPClient(s.Client())
+	gs.SetGitlabEndpoint(s.Endpoint())
+	policy := gitlab.DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	policy.MaxDelay = 10 * time.Millisecond
+	gs.SetRetryPolicy(policy)
+	return gs
+}
+
+// RequireToken makes the server answer 401 to requests without this private token.
+func (s *Server) RequireToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+}
+
+// AddGroup adds a group.
+func (s *Server) AddGroup(g Group) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.groups[g.ID] = g
+}
+
+// AddProject adds a project.
+func (s *Server) AddProject(p Project) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.projects[p.ID] = p
+}
+
+// AddIssues adds issues to a project. Issues without IID are numbered.
+func (s *Server) AddIssues(projectID int, issues ...Issue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, issue := range issues {
+		if issue.IID == 0 {
+			issue.IID = len(s.issues[projectID]) + 1
+		}
+		if issue.State == "" {
+			issue.State = "opened"
+		}
+		if issue.CreatedAt.IsZero() {
+			issue.CreatedAt = time.Now().UTC()
+		}
+		s.issues[projectID] = append(s.issues[projectID], issue)
+	}
+}
+
+// AddMergeRequests adds merge requests to a project.
+func (s *Server) AddMergeRequests(projectID int, mergeRequests ...MergeRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, mr := range mergeRequests {
+		if mr.IID == 0 {
+			mr.IID = len(s.mergeRequests[projectID]) + 1
+		}
+		s.mergeRequests[projectID] = append(s.mergeRequests[projectID], mr)
+	}
+}
+
+// Fail makes the next times requests whose path, relative to the API, starts
+// with pathPrefix answer with status. An empty prefix matches all requests.
+func (s *Server) Fail(pathPrefix string, status int, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, &failure{pathPrefix: pathPrefix, status: status, remaining: times})
+}
+
+// RateLimit makes the next times requests answer 429 with a Retry-After header.
+func (s *Server) RateLimit(times int, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimited = times
+	s.retryAfter = retryAfter
+}
+
+// Requests returns the path and query of the requests received, relative to the API.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path, ok := strings.CutPrefix(r.URL.EscapedPath(), APIPath+"/")
+	if !ok {
+		writeError(w, http.StatusNotFound, "404 Not Found")
+		return
+	}
+	s.requests = append(s.requests, path+queryString(r.URL))
+
+	if s.token != "" && r.Header.Get(gitlab.PrivateTokenHeader) != s.token {
+		writeError(w, http.StatusUnauthorized, "401 Unauthorized")
+		return
+	}
+	if s.rateLimited > 0 {
+		s.rateLimited--
+		w.Header().Set("Retry-After", strconv.Itoa(int(s.retryAfter.Seconds())))
+		w.Header().Set("RateLimit-Remaining", "0")
+		writeError(w, http.StatusTooManyRequests, "Retry later")
+		return
+	}
+	for _, f := range s.failures {
+		if f.remaining > 0 && strings.HasPrefix(path, f.pathPrefix) {
+			f.remaining--
+			writeError(w, f.status, http.StatusText(f.status))
+			return
+		}
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "405 Method Not Allowed")
+		return
+	}
+	s.route(w, r, strings.Split(path, "/"))
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request, segments []string) {
+	q := r.URL.Query()
+	switch {
+	case len(segments) == 1 && segments[0] == "issues_statistics":
+		s.writeStatistics(w, q, s.allProjectIDs())
+	case len(segments) == 1 && segments[0] == "issues":
+		s.listIssues(w, r, s.allProjectIDs())
+	case len(segments) == 1 && segments[0] == "search":
+		s.search(w, r)
+	case len(segments) >= 2 && segments[0] == "projects":
+		project, ok := s.findProject(segments[1])
+		if !ok {
+			writeError(w, http.StatusNotFound, "404 Project Not Found")
+			return
+		}
+		s.routeEntity(w, r, segments[2:], project, []int{project.ID})
+	case len(segments) >= 2 && segments[0] == "groups":
+		group, ok := s.findGroup(segments[1])
+		if !ok {
+			writeError(w, http.StatusNotFound, "404 Group Not Found")
+			return
+		}
+		s.routeEntity(w, r, segments[2:], group, s.groupProjectIDs(group.ID))
+	default:
+		writeError(w, http.StatusNotFound, "404 Not Found")
+	}
+}
+
+func (s *Server) routeEntity(w http.ResponseWriter, r *http.Request, segments []string, entity any, projectIDs []int) {
+	q := r.URL.Query()
+	if len(segments) == 0 {
+		writeJSON(w, http.StatusOK, toJSON(entity))
+		return
+	}
+	switch segments[0] {
+	case "issues_statistics":
+		s.writeStatistics(w, q, projectIDs)
+	case "issues":
+		s.listIssues(w, r, projectIDs)
+	case "merge_requests":
+		s.listMergeRequests(w, r, projectIDs)
+	case "projects":
+		group, ok := entity.(Group)
+		if !ok {
+			writeError(w, http.StatusNotFound, "404 Not Found")
+			return
+		}
+		ids := s.groupProjectIDs(group.ID)
+		if q.Get("include_subgroups") != "true" {
+			ids = s.directProjectIDs(group.ID)
+		}
+		items := make([]any, 0, len(ids))
+		for _, id := range ids {
+			items = append(items, toJSON(s.projects[id]))
+		}
+		paginate(w, r, items)
+	default:
+		writeError(w, http.StatusNotFound, "404 Not Found")
+	}
+}
+
+func (s *Server) findProject(idOrPath string) (Project, bool) {
+	if id, err := strconv.Atoi(idOrPath); err == nil {
+		p, ok := s.projects[id]
+		return p, ok
+	}
+	path, _ := url.PathUnescape(idOrPath)
+	for _, p := range s.projects {
+		if strings.EqualFold(p.PathWithNamespace, path) {
+			return p, true
+		}
+	}
+	return Project{}, false
+}
+
+func (s *Server) findGroup(idOrPath string) (Group, bool) {
+	if id, err := strconv.Atoi(idOrPath); err == nil {
+		g, ok := s.groups[id]
+		return g, ok
+	}
+	path, _ := url.PathUnescape(idOrPath)
+	for _, g := range s.groups {
+		if strings.EqualFold(g.FullPath, path) {
+			return g, true
+		}
+	}
+	return Group{}, false
+}
+
+func (s *Server) allProjectIDs() []int {
+	ids := make([]int, 0, len(s.projects))
+	for id := range s.projects {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func (s *Server) directProjectIDs(groupID int) []int {
+	var ids []int
+	for _, id := range s.allProjectIDs() {
+		if s.projects[id].GroupID == groupID {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// groupProjectIDs returns the projects of the group and of its subgroups.
+func (s *Server) groupProjectIDs(groupID int) []int {
+	var ids []int
+	for _, id := range s.allProjectIDs() {
+		for g := s.projects[id].GroupID; g != 0; g = s.groups[g].ParentID {
+			if g == groupID {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	return ids
+}
+
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("scope") != "projects" {
+		writeError(w, http.StatusBadRequest, "scope does not have a valid value")
+		return
+	}
+	var items []any
+	for _, id := range s.allProjectIDs() {
+		p := s.projects[id]
+		if strings.Contains(strings.ToLower(p.Name), strings.ToLower(q.Get("search"))) {
+			items = append(items, toJSON(p))
+		}
+	}
+	paginate(w, r, items)
+}
+
+// matchingIssues returns the issues of the projects matching the filter of the query.
+func (s *Server) matchingIssues(q url.Values, projectIDs []int) []issueJSON {
+	var labels []string
+	if q.Get("labels") != "" {
+		labels = strings.Split(q.Get("labels"), ",")
+	}
+	createdAfter, _ := time.Parse(time.RFC3339, q.Get("created_after"))
+	createdBefore, _ := time.Parse(time.RFC3339, q.Get("created_before"))
+	updatedAfter, _ := time.Parse(time.RFC3339, q.Get("updated_after"))
+	var result []issueJSON
+	for _, id := range projectIDs {
+		for _, issue := range s.issues[id] {
+			if !containsAll(issue.Labels, labels) ||
+				(!createdAfter.IsZero() && issue.CreatedAt.Before(createdAfter)) ||
+				(!createdBefore.IsZero() && issue.CreatedAt.After(createdBefore)) ||
+				(!updatedAfter.IsZero() && issue.updatedAt().Before(updatedAfter)) {
+				continue
+			}
+			result = append(result, newIssueJSON(id, issue))
+		}
+	}
+	return result
+}
+
+func (s *Server) writeStatistics(w http.ResponseWriter, q url.Values, projectIDs []int) {
+	var counts gitlab.Counts
+	for _, issue := range s.matchingIssues(q, projectIDs) {
+		counts.All++
+		if issue.State == "closed" {
+			counts.Closed++
+		} else {
+			counts.Opened++
+		}
+	}
+	writeJSON(w, http.StatusOK, gitlab.Statistics{Statistics: gitlab.Statistic{Counts: counts}})
+}
+
+func (s *Server) listIssues(w http.ResponseWriter, r *http.Request, projectIDs []int) {
+	state := r.URL.Query().Get("state")
+	var items []any
+	for _, issue := range s.matchingIssues(r.URL.Query(), projectIDs) {
+		if state == "" || state == "all" || state == issue.State {
+			items = append(items, issue)
+		}
+	}
+	paginate(w, r, items)
+}
+
+func (s *Server) listMergeRequests(w http.ResponseWriter, r *http.Request, projectIDs []int) {
+	state := r.URL.Query().Get("state")
+	var items []any
+	for _, id := range projectIDs {
+		for _, mr := range s.mergeRequests[id] {
+			if state == "" || state == "all" || state == mr.State {
+				items = append(items, map[string]any{"iid": mr.IID, "project_id": id, "state": mr.State})
+			}
+		}
+	}
+	paginate(w, r, items)
+}
+
+type issueJSON struct {
+	ID        int        `json:"id"`
+	IID       int        `json:"iid"`
+	ProjectID int        `json:"project_id"`
+	State     string     `json:"state"`
+	Labels    []string   `json:"labels"`
+	CreatedAt time.Time  `json:"created_at"`
+	ClosedAt  *time.Time `json:"closed_at"`
+}
+
+func newIssueJSON(projectID int, issue Issue) issueJSON {
+	labels := issue.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+	return issueJSON{
+		ID:        projectID*100000 + issue.IID,
+		IID:       issue.IID,
+		ProjectID: projectID,
+		State:     issue.State,
+		Labels:    labels,
+		CreatedAt: issue.CreatedAt,
+		ClosedAt:  issue.ClosedAt,
+	}
+}
+
+func toJSON(entity any) any {
+	switch e := entity.(type) {
+	case Project:
+		return gitlab.Project{
+			ID:                e.ID,
+			Name:              e.Name,
+			PathWithNamespace: e.PathWithNamespace,
+			SSHURLToRepo:      "git@gitlab.example.com:" + e.PathWithNamespace + ".git",
+			HTTPURLToRepo:     "https://gitlab.example.com/" + e.PathWithNamespace + ".git",
+			WebURL:            "https://gitlab.example.com/" + e.PathWithNamespace,
+		}
+	case Group:
+		return gitlab.Group{
+			ID:       e.ID,
+			Name:     e.Name,
+			FullPath: e.FullPath,
+			FullName: e.Name,
+			WebURL:   "https://gitlab.example.com/groups/" + e.FullPath,
+		}
+	default:
+		return entity
+	}
+}
+
+// paginate writes a page of items with the pagination headers of GitLab.
+func paginate(w http.ResponseWriter, r *http.Request, items []any) {
+	q := r.URL.Query()
+	page, err := strconv.Atoi(q.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	perPage, err := strconv.Atoi(q.Get("per_page"))
+	if err != nil || perPage < 1 {
+		perPage = defaultPerPage
+	}
+	start := min((page-1)*perPage, len(items))
+	end := min(start+perPage, len(items))
+
+	w.Header().Set("X-Total", strconv.Itoa(len(items)))
+	w.Header().Set("X-Page", strconv.Itoa(page))
+	w.Header().Set("X-Per-Page", strconv.Itoa(perPage))
+	if end < len(items) {
+		w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+		next := *r.URL
+		nq := next.Query()
+		nq.Set("page", strconv.Itoa(page+1))
+		next.RawQuery = nq.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<https://%s%s>; rel="next"`, r.Host, next.RequestURI()))
+	}
+	pageItems := items[start:end]
+	if pageItems == nil {
+		pageItems = []any{}
+	}
+	writeJSON(w, http.StatusOK, pageItems)
+}
+
+func containsAll(labels, wanted []string) bool {
+	for _, l := range wanted {
+		if !slices.Contains(labels, l) {
+			return false
+		}
+	}
+	return true
+}
+
+func queryString(u *url.URL) string {
+	if u.RawQuery == "" {
+		return ""
+	}
+	return "?" + u.RawQuery
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v) // ignore error
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
@@ -0,0 +1,139 @@
+package gitlabtest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab/gitlabtest"
+)
+
+func newServer(t *testing.T) *gitlabtest.Server {
+	t.Helper()
+	srv := gitlabtest.NewServer(t)
+	srv.AddGroup(gitlabtest.Group{ID: 1, Name: "grp", FullPath: "grp"})
+	srv.AddGroup(gitlabtest.Group{ID: 2, Name: "sub", FullPath: "grp/sub", ParentID: 1})
+	srv.AddProject(gitlabtest.Project{ID: 10, Name: "a", PathWithNamespace: "grp/a", GroupID: 1})
+	srv.AddProject(gitlabtest.Project{ID: 11, Name: "b", PathWithNamespace: "grp/sub/b", GroupID: 2})
+	srv.AddProject(gitlabtest.Project{ID: 12, Name: "c", PathWithNamespace: "other/c"})
+	srv.AddIssues(10, gitlabtest.Issue{Labels: []string{"bug"}}, gitlabtest.Issue{State: "closed"})
+	srv.AddIssues(11, gitlabtest.Issue{Labels: []string{"bug"}})
+	srv.AddIssues(12, gitlabtest.Issue{})
+	srv.AddMergeRequests(11, gitlabtest.MergeRequest{State: "opened"}, gitlabtest.MergeRequest{State: "merged"})
+	return srv
+}
+
+func TestStatistics(t *testing.T) {
+	srv := newServer(t)
+	gs := srv.NewService()
+
+	res, err := gitlab.NewGroupStatistics(1).GetStatistics(gs)
+	if err != nil {
+		t.Fatalf("GetStatistics() error = %v", err)
+	}
+	want := gitlab.Counts{All: 3, Closed: 1, Opened: 2}
+	if res.Statistics.Counts != want {
+		t.Errorf("GetStatistics() = %+v, want %+v", res.Statistics.Counts, want)
+	}
+
+	n := gitlab.NewInstanceStatistics()
+	n.SetFilter(gitlab.IssueFilter{Labels: []string{"bug"}})
+	res, err = n.GetStatistics(gs)
+	if err != nil {
+		t.Fatalf("GetStatistics() error = %v", err)
+	}
+	if res.Statistics.Counts.All != 2 {
+		t.Errorf("GetStatistics() of bugs = %+v, want 2 issues", res.Statistics.Counts)
+	}
+
+	mrs, err := gitlab.NewGroupMergeRequestStatistics(2).GetStatistics(gs)
+	if err != nil {
+		t.Fatalf("GetStatistics() error = %v", err)
+	}
+	if mrs.All != 2 || mrs.Opened != 1 || mrs.Merged != 1 {
+		t.Errorf("GetStatistics() of merge requests = %+v", mrs)
+	}
+}
+
+func TestProjectsAndPagination(t *testing.T) {
+	srv := newServer(t)
+	gs := srv.NewService()
+	ctx := context.Background()
+
+	project, err := gitlab.GetProject(ctx, gs, "grp/sub/b")
+	if err != nil {
+		t.Fatalf("GetProject() error = %v", err)
+	}
+	if project.ID != 11 {
+		t.Errorf("GetProject() = %+v, want project 11", project)
+	}
+
+	var ids []int
+	for p, err := range gitlab.Paginate[gitlab.Project](ctx, gs, "groups/1/projects?include_subgroups=true&per_page=1") {
+		if err != nil {
+			t.Fatalf("Paginate() error = %v", err)
+		}
+		ids = append(ids, p.ID)
+	}
+	if len(ids) != 2 || ids[0] != 10 || ids[1] != 11 {
+		t.Errorf("Paginate() = %v, want [10 11]", ids)
+	}
+	if got := len(srv.Requests()); got != 3 {
+		t.Errorf("server received %d requests, want 3", got)
+	}
+}
+
+func TestFailuresAndRateLimits(t *testing.T) {
+	srv := newServer(t)
+	gs := srv.NewService()
+
+	srv.Fail("projects/10", http.StatusBadGateway, 2)
+	srv.RateLimit(1, 0)
+	if _, err := gitlab.NewProjectStatistics(10).GetStatistics(gs); err != nil {
+		t.Errorf("GetStatistics() error = %v, want a success after retries", err)
+	}
+
+	srv.Fail("groups/", http.StatusForbidden, 1)
+	_, err := gitlab.NewGroupStatistics(1).GetStatistics(gs)
+	if !errors.Is(err, gitlab.ErrForbidden) {
+		t.Errorf("GetStatistics() error = %v, want %v", err, gitlab.ErrForbidden)
+	}
+
+	_, err = gitlab.NewProjectStatistics(99).GetStatistics(gs)
+	if !errors.Is(err, gitlab.ErrNotFound) {
+		t.Errorf("GetStatistics() error = %v, want %v", err, gitlab.ErrNotFound)
+	}
+
+	srv.RequireToken("secret")
+	_, err = gitlab.NewProjectStatistics(10).GetStatistics(gs)
+	if !errors.Is(err, gitlab.ErrUnauthorized) {
+		t.Errorf("GetStatistics() error = %v, want %v", err, gitlab.ErrUnauthorized)
+	}
+	gs.SetToken("secret")
+	if _, err := gitlab.NewProjectStatistics(10).GetStatistics(gs); err != nil {
+		t.Errorf("GetStatistics() error = %v", err)
+	}
+}
+
+func TestIssuesList(t *testing.T) {
+	srv := gitlabtest.NewServer(t)
+	created := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	closed := created.AddDate(0, 0, 3)
+	srv.AddProject(gitlabtest.Project{ID: 1, Name: "a", PathWithNamespace: "a"})
+	srv.AddIssues(1, gitlabtest.Issue{State: "closed", CreatedAt: created, ClosedAt: &closed}, gitlabtest.Issue{})
+
+	type issue struct {
+		IID      int        `json:"iid"`
+		ClosedAt *time.Time `json:"closed_at"`
+	}
+	issues, err := gitlab.GetAll[issue](context.Background(), srv.NewService(), "projects/1/issues?state=closed")
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	if len(issues) != 1 || issues[0].ClosedAt == nil || !issues[0].ClosedAt.Equal(closed) {
+		t.Errorf("GetAll() = %+v, want the closed issue", issues)
+	}
+}
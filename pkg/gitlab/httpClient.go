@@ -0,0 +1,90 @@
+package gitlab
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// ErrInvalidCABundle is returned when a CA bundle contains no PEM certificate.
+var ErrInvalidCABundle = errors.New("no certificate found in CA bundle")
+
+// HTTPClientOptions configures the HTTP client of a self-hosted GitLab.
+type HTTPClientOptions struct {
+	// CAFile is a PEM bundle of CAs trusted in addition to the system ones.
+	CAFile string
+	// CertFile and KeyFile are the PEM client certificate and key for mutual TLS.
+	CertFile string
+	KeyFile  string
+	// InsecureSkipVerify disables the verification of the server certificate.
+	// It should only be used for tests: the connection is open to man in the middle attacks.
+	InsecureSkipVerify bool
+	// ProxyURL is the proxy used for all requests. If empty, the proxy is read
+	// from the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables.
+	ProxyURL string
+	// Timeout is the timeout of requests, DefaultHTTPTimeout if zero.
+	Timeout time.Duration
+}
+
+// NewHTTPClient returns an HTTP client to use with Service.SetHTTPClient.
+func NewHTTPClient(opts HTTPClientOptions) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: opts.InsecureSkipVerify, //nolint:gosec // explicitly requested by the user
+	}
+	if opts.CAFile != "" {
+		pool, err := loadCABundle(opts.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		transport = &http.Transport{}
+	}
+	transport = transport.Clone()
+	transport.TLSClientConfig = tlsConfig
+	if opts.ProxyURL != "" {
+		proxy, err := url.Parse(opts.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultHTTPTimeout
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
+
+// loadCABundle returns the system CAs with the CAs of the bundle.
+func loadCABundle(caFile string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(caFile) //nolint:gosec // CA bundle given by the user
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCABundle, caFile)
+	}
+	return pool, nil
+}
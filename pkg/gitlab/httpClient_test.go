@@ -0,0 +1,156 @@
+package gitlab_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newClientCertificate writes a self-signed client certificate and its key.
+func newClientCertificate(t *testing.T) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "gitlab-stats"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, "client.crt", "CERTIFICATE", der), writePEM(t, "client.key", "EC PRIVATE KEY", keyDER), cert
+}
+
+func getStatus(t *testing.T, opts gitlab.HTTPClientOptions, endpoint string) error {
+	t.Helper()
+	client, err := gitlab.NewHTTPClient(opts)
+	if err != nil {
+		t.Fatalf("NewHTTPClient() error = %v", err)
+	}
+	s := gitlab.NewService()
+	s.SetHTTPClient(client)
+	s.SetGitlabEndpoint(endpoint)
+	s.SetRetryPolicy(gitlab.RetryPolicy{})
+	resp, err := s.Get("version")
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func TestNewHTTPClientCABundle(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	if err := getStatus(t, gitlab.HTTPClientOptions{}, ts.URL); err == nil {
+		t.Errorf("Get() succeeded with an unknown CA")
+	}
+	caFile := writePEM(t, "ca.crt", "CERTIFICATE", ts.Certificate().Raw)
+	if err := getStatus(t, gitlab.HTTPClientOptions{CAFile: caFile}, ts.URL); err != nil {
+		t.Errorf("Get() error = %v", err)
+	}
+}
+
+func TestNewHTTPClientInvalidCABundle(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	if err := os.WriteFile(caFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := gitlab.NewHTTPClient(gitlab.HTTPClientOptions{CAFile: caFile})
+	if !errors.Is(err, gitlab.ErrInvalidCABundle) {
+		t.Errorf("NewHTTPClient() error = %v, want %v", err, gitlab.ErrInvalidCABundle)
+	}
+}
+
+func TestNewHTTPClientInsecureSkipVerify(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	if err := getStatus(t, gitlab.HTTPClientOptions{InsecureSkipVerify: true}, ts.URL); err != nil {
+		t.Errorf("Get() error = %v", err)
+	}
+}
+
+func TestNewHTTPClientMutualTLS(t *testing.T) {
+	certFile, keyFile, cert := newClientCertificate(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+		MinVersion: tls.VersionTLS12,
+	}
+	ts.StartTLS()
+	defer ts.Close()
+	caFile := writePEM(t, "ca.crt", "CERTIFICATE", ts.Certificate().Raw)
+
+	if err := getStatus(t, gitlab.HTTPClientOptions{CAFile: caFile}, ts.URL); err == nil {
+		t.Errorf("Get() succeeded without client certificate")
+	}
+	opts := gitlab.HTTPClientOptions{CAFile: caFile, CertFile: certFile, KeyFile: keyFile}
+	if err := getStatus(t, opts, ts.URL); err != nil {
+		t.Errorf("Get() error = %v", err)
+	}
+}
+
+func TestNewHTTPClientProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	err := getStatus(t, gitlab.HTTPClientOptions{ProxyURL: proxy.URL}, "http://gitlab.example.invalid/api/v4")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if proxied != "http://gitlab.example.invalid/api/v4/version" {
+		t.Errorf("proxy received %q, want the request to GitLab", proxied)
+	}
+}
@@ -0,0 +1,69 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// IssueAges is the number of open issues per age, from their creation.
+type IssueAges struct {
+	// UnderWeek counts the issues created less than 7 days ago.
+	UnderWeek int
+	// WeekToMonth counts the issues created 7 to 30 days ago.
+	WeekToMonth int
+	// MonthToQuarter counts the issues created 30 to 90 days ago.
+	MonthToQuarter int
+	// QuarterToYear counts the issues created 90 to 365 days ago.
+	QuarterToYear int
+	// OverYear counts the issues created more than 365 days ago.
+	OverYear int
+}
+
+// issueAgeBounds are the ages, in days, separating the buckets of IssueAges.
+var issueAgeBounds = [...]int{7, 30, 90, 365}
+
+// OpenIssueAges counts the open issues of the scope matching the filter per
+// age at now. Rather than listing the open issues, it requests the statistics
+// of the issues created during each age bucket, intersected with the creation
+// dates of the filter: a request per bucket, whatever the number of issues.
+func OpenIssueAges(
+	ctx context.Context,
+	f StatisticsFetcher,
+	scope Scope,
+	filter IssueFilter,
+	now time.Time,
+) (IssueAges, error) {
+	var counts [len(issueAgeBounds) + 1]int
+	for i := range counts {
+		bucket := filter
+		if i < len(issueAgeBounds) {
+			after := now.AddDate(0, 0, -issueAgeBounds[i])
+			if filter.CreatedAfter == nil || after.After(*filter.CreatedAfter) {
+				bucket.CreatedAfter = &after
+			}
+		}
+		if i > 0 {
+			// the dates are sent to the second and both bounds are included
+			before := now.AddDate(0, 0, -issueAgeBounds[i-1]).Add(-time.Second)
+			if filter.CreatedBefore == nil || before.Before(*filter.CreatedBefore) {
+				bucket.CreatedBefore = &before
+			}
+		}
+		if bucket.CreatedAfter != nil && bucket.CreatedBefore != nil && bucket.CreatedAfter.After(*bucket.CreatedBefore) {
+			continue // no issue of the filter is of this age
+		}
+		statistics, err := f.IssueStatistics(ctx, scope, bucket)
+		if err != nil {
+			return IssueAges{}, fmt.Errorf("failed to count the open issues by age: %w", err)
+		}
+		counts[i] = statistics.Statistics.Counts.Opened
+	}
+	return IssueAges{
+		UnderWeek:      counts[0],
+		WeekToMonth:    counts[1],
+		MonthToQuarter: counts[2],
+		QuarterToYear:  counts[3],
+		OverYear:       counts[4],
+	}, nil
+}
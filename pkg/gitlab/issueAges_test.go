@@ -0,0 +1,64 @@
+package gitlab_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab/gitlabtest"
+)
+
+func TestOpenIssueAges(t *testing.T) {
+	ctx := context.Background()
+	srv := gitlabtest.NewServer(t)
+	srv.AddProject(gitlabtest.Project{ID: 42, Name: "proj", PathWithNamespace: "grp/proj"})
+	now := time.Now()
+	daysAgo := func(days int) time.Time {
+		return now.AddDate(0, 0, -days)
+	}
+	srv.AddIssues(42,
+		gitlabtest.Issue{CreatedAt: daysAgo(1)},
+		gitlabtest.Issue{CreatedAt: daysAgo(2), Labels: []string{"bug"}},
+		gitlabtest.Issue{CreatedAt: daysAgo(10)},
+		gitlabtest.Issue{CreatedAt: daysAgo(45), Labels: []string{"bug"}},
+		gitlabtest.Issue{CreatedAt: daysAgo(200)},
+		gitlabtest.Issue{CreatedAt: daysAgo(400), Labels: []string{"bug"}},
+		gitlabtest.Issue{CreatedAt: daysAgo(800)},
+		// closed issues are not counted
+		gitlabtest.Issue{State: "closed", CreatedAt: daysAgo(3)},
+	)
+	gs := srv.NewService()
+
+	tests := []struct {
+		name   string
+		filter gitlab.IssueFilter
+		want   gitlab.IssueAges
+	}{
+		{
+			name: "all",
+			want: gitlab.IssueAges{UnderWeek: 2, WeekToMonth: 1, MonthToQuarter: 1, QuarterToYear: 1, OverYear: 2},
+		},
+		{
+			name:   "labels",
+			filter: gitlab.IssueFilter{Labels: []string{"bug"}},
+			want:   gitlab.IssueAges{UnderWeek: 1, MonthToQuarter: 1, OverYear: 1},
+		},
+		{
+			name:   "created after",
+			filter: gitlab.IssueFilter{CreatedAfter: func() *time.Time { d := daysAgo(60); return &d }()},
+			want:   gitlab.IssueAges{UnderWeek: 2, WeekToMonth: 1, MonthToQuarter: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gitlab.OpenIssueAges(ctx, gs, gitlab.ProjectScope(42), tt.filter, now)
+			if err != nil {
+				t.Fatalf("OpenIssueAges() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("OpenIssueAges() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
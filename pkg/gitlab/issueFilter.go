@@ -0,0 +1,107 @@
+package gitlab
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidFilter is returned when an issue filter is inconsistent.
+var ErrInvalidFilter = errors.New("invalid issue filter")
+
+// IssueFilter restricts the issues counted by the issues statistics API.
+// Zero values are not sent. See: https://docs.gitlab.com/ee/api/issues_statistics.html
+type IssueFilter struct {
+	// Labels keeps issues having all the given labels ("None" and "Any" are accepted).
+	Labels []string `json:"labels,omitempty"`
+	// Milestone is a milestone title, "None" or "Any".
+	Milestone string `json:"milestone,omitempty"`
+	// Scope is one of created_by_me, assigned_to_me or all.
+	Scope string `json:"scope,omitempty"`
+	// AuthorID is the ID of the issues author.
+	AuthorID int `json:"author_id,omitempty"`
+	// AuthorUsername is the username of the issues author.
+	AuthorUsername string `json:"author_username,omitempty"`
+	// AssigneeID is a user ID, "None" or "Any".
+	AssigneeID string `json:"assignee_id,omitempty"`
+	// AssigneeUsername is the username of the assignee.
+	AssigneeUsername string `json:"assignee_username,omitempty"`
+	// Search matches the title and description of the issues.
+	Search string `json:"search,omitempty"`
+	// In restricts Search to title, description or "title,description".
+	In            string     `json:"in,omitempty"`
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+	UpdatedAfter  *time.Time `json:"updated_after,omitempty"`
+	UpdatedBefore *time.Time `json:"updated_before,omitempty"`
+	// Confidential keeps only confidential (true) or public (false) issues when set.
+	Confidential *bool `json:"confidential,omitempty"`
+}
+
+// IsZero reports whether the filter does not restrict anything.
+func (f IssueFilter) IsZero() bool {
+	return len(f.Values()) == 0
+}
+
+// Validate checks the consistency of the filter.
+func (f IssueFilter) Validate() error {
+	if f.AuthorID != 0 && f.AuthorUsername != "" {
+		return fmt.Errorf("%w: author ID and author username are mutually exclusive", ErrInvalidFilter)
+	}
+	if f.AssigneeID != "" && f.AssigneeUsername != "" {
+		return fmt.Errorf("%w: assignee ID and assignee username are mutually exclusive", ErrInvalidFilter)
+	}
+	if f.AssigneeID != "" && f.AssigneeID != "None" && f.AssigneeID != "Any" {
+		if _, err := strconv.Atoi(f.AssigneeID); err != nil {
+			return fmt.Errorf("%w: assignee ID should be a number, None or Any", ErrInvalidFilter)
+		}
+	}
+	if f.CreatedAfter != nil && f.CreatedBefore != nil && f.CreatedAfter.After(*f.CreatedBefore) {
+		return fmt.Errorf("%w: created after is later than created before", ErrInvalidFilter)
+	}
+	if f.UpdatedAfter != nil && f.UpdatedBefore != nil && f.UpdatedAfter.After(*f.UpdatedBefore) {
+		return fmt.Errorf("%w: updated after is later than updated before", ErrInvalidFilter)
+	}
+	return nil
+}
+
+// Values encodes the filter as query string parameters.
+func (f IssueFilter) Values() url.Values {
+	v := url.Values{}
+	if len(f.Labels) > 0 {
+		v.Set("labels", strings.Join(f.Labels, ","))
+	}
+	setString(v, "milestone", f.Milestone)
+	setString(v, "scope", f.Scope)
+	if f.AuthorID != 0 {
+		v.Set("author_id", strconv.Itoa(f.AuthorID))
+	}
+	setString(v, "author_username", f.AuthorUsername)
+	setString(v, "assignee_id", f.AssigneeID)
+	setString(v, "assignee_username", f.AssigneeUsername)
+	setString(v, "search", f.Search)
+	setString(v, "in", f.In)
+	setTime(v, "created_after", f.CreatedAfter)
+	setTime(v, "created_before", f.CreatedBefore)
+	setTime(v, "updated_after", f.UpdatedAfter)
+	setTime(v, "updated_before", f.UpdatedBefore)
+	if f.Confidential != nil {
+		v.Set("confidential", strconv.FormatBool(*f.Confidential))
+	}
+	return v
+}
+
+func setString(v url.Values, key string, value string) {
+	if value != "" {
+		v.Set(key, value)
+	}
+}
+
+func setTime(v url.Values, key string, value *time.Time) {
+	if value != nil {
+		v.Set(key, value.UTC().Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,71 @@
+package gitlab_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+func TestGetStatisticsWithFilter(t *testing.T) {
+	var query string
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query = r.URL.RawQuery
+			fmt.Fprintln(w, `{"statistics":{"counts":{"all":1,"closed":0,"opened":1}}}`)
+		}))
+	defer ts.Close()
+
+	s := gitlab.NewService()
+	s.SetHTTPClient(ts.Client())
+	s.SetGitlabEndpoint(ts.URL)
+
+	createdAfter := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	confidential := false
+	r := gitlab.NewGroupStatistics(10)
+	r.SetFilter(gitlab.IssueFilter{
+		Labels:       []string{"bug", "team X&Y"},
+		Milestone:    "v1.0",
+		AssigneeID:   "Any",
+		AuthorID:     42,
+		Search:       "crash?",
+		CreatedAfter: &createdAfter,
+		Confidential: &confidential,
+	})
+	if _, err := r.GetStatistics(s); err != nil {
+		t.Fatalf("GetStatistics() error = %v", err)
+	}
+	want := "assignee_id=Any&author_id=42&confidential=false&created_after=2024-01-02T00%3A00%3A00Z" +
+		"&labels=bug%2Cteam+X%26Y&milestone=v1.0&search=crash%3F"
+	if query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+}
+
+func TestIssueFilterValidate(t *testing.T) {
+	before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	after := before.AddDate(0, 1, 0)
+	tests := []struct {
+		name    string
+		filter  gitlab.IssueFilter
+		wantErr bool
+	}{
+		{name: "empty", filter: gitlab.IssueFilter{}},
+		{name: "numeric assignee", filter: gitlab.IssueFilter{AssigneeID: "12"}},
+		{name: "invalid assignee", filter: gitlab.IssueFilter{AssigneeID: "bob"}, wantErr: true},
+		{name: "author ID and username", filter: gitlab.IssueFilter{AuthorID: 1, AuthorUsername: "bob"}, wantErr: true},
+		{name: "inverted dates", filter: gitlab.IssueFilter{CreatedAfter: &after, CreatedBefore: &before}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if tt.wantErr != errors.Is(err, gitlab.ErrInvalidFilter) {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// ListIssues returns every issue of a project, of a group and its subgroups
+// or of the instance, open or closed, created up to the date if it is not
+// zero, ordered by creation date.
+func ListIssues(ctx context.Context, gs *Service, scope Scope, createdBefore time.Time) ([]Issue, error) {
+	path, err := issuesPath(scope)
+	if err != nil {
+		return nil, err
+	}
+	query := "?scope=all&state=all&order_by=created_at&sort=asc"
+	if !createdBefore.IsZero() {
+		query += "&created_before=" + url.QueryEscape(createdBefore.UTC().Format(time.RFC3339))
+	}
+	issues, err := GetAll[Issue](ctx, gs, path+query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list issues of %s: %w", scope, err)
+	}
+	return issues, nil
+}
+
+// ListClosedIssues returns the closed issues of a project, of a group and its
+// subgroups or of the instance updated since the date, which includes every
+// issue closed since then: closing an issue updates it.
+func ListClosedIssues(ctx context.Context, gs *Service, scope Scope, since time.Time) ([]Issue, error) {
+	path, err := issuesPath(scope)
+	if err != nil {
+		return nil, err
+	}
+	query := "?scope=all&state=closed&order_by=created_at&sort=asc&updated_after=" +
+		url.QueryEscape(since.UTC().Format(time.RFC3339))
+	issues, err := GetAll[Issue](ctx, gs, path+query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list closed issues of %s: %w", scope, err)
+	}
+	return issues, nil
+}
+
+func issuesPath(scope Scope) (string, error) {
+	switch scope.Kind {
+	case ScopeProject:
+		return fmt.Sprintf("projects/%d/issues", scope.ID), nil
+	case ScopeGroup:
+		return fmt.Sprintf("groups/%d/issues", scope.ID), nil
+	case ScopeInstance:
+		return "issues", nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedScope, scope)
+	}
+}
@@ -0,0 +1,63 @@
+package gitlab_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab/gitlabtest"
+)
+
+func TestListIssues(t *testing.T) {
+	ctx := context.Background()
+	srv := gitlabtest.NewServer(t)
+	srv.AddGroup(gitlabtest.Group{ID: 1, Name: "grp", FullPath: "grp"})
+	srv.AddProject(gitlabtest.Project{ID: 42, Name: "proj", PathWithNamespace: "grp/proj", GroupID: 1})
+	srv.AddProject(gitlabtest.Project{ID: 43, Name: "other", PathWithNamespace: "other"})
+	created := time.Date(2024, 1, 10, 9, 0, 0, 0, time.UTC)
+	closed := created.AddDate(0, 0, 5)
+	for range 150 {
+		srv.AddIssues(42, gitlabtest.Issue{CreatedAt: created})
+	}
+	srv.AddIssues(42, gitlabtest.Issue{State: "closed", CreatedAt: created, ClosedAt: &closed})
+	srv.AddIssues(43, gitlabtest.Issue{})
+
+	gs := srv.NewService()
+	issues, err := gitlab.ListIssues(ctx, gs, gitlab.GroupScope(1), time.Time{})
+	if err != nil {
+		t.Fatalf("ListIssues() error = %v", err)
+	}
+	if len(issues) != 151 {
+		t.Fatalf("ListIssues() returned %d issues, want 151 over 2 pages", len(issues))
+	}
+	last := issues[150]
+	if last.State != "closed" || !last.CreatedAt.Equal(created) || last.ClosedAt == nil || !last.ClosedAt.Equal(closed) {
+		t.Errorf("ListIssues()[150] = %+v, want the closed issue with its dates", last)
+	}
+	if issues[0].ClosedAt != nil {
+		t.Errorf("ListIssues()[0] = %+v, want an open issue without closing date", issues[0])
+	}
+
+	var lister gitlab.IssueLister = gs
+	all, err := lister.Issues(ctx, gitlab.InstanceScope(), time.Time{})
+	if err != nil || len(all) != 152 {
+		t.Errorf("Issues(instance) = %d issues, %v, want 152", len(all), err)
+	}
+	before, err := lister.Issues(ctx, gitlab.InstanceScope(), created.Add(-time.Second))
+	if err != nil || len(before) != 0 {
+		t.Errorf("Issues(instance) created before %s = %d issues, %v, want none", created, len(before), err)
+	}
+	closedSince, err := lister.ClosedIssues(ctx, gitlab.ProjectScope(42), closed.AddDate(0, 0, -1))
+	if err != nil || len(closedSince) != 1 || closedSince[0].ClosedAt == nil {
+		t.Errorf("ClosedIssues() = %+v, %v, want the closed issue", closedSince, err)
+	}
+	closedSince, err = lister.ClosedIssues(ctx, gitlab.ProjectScope(42), closed.AddDate(0, 0, 1))
+	if err != nil || len(closedSince) != 0 {
+		t.Errorf("ClosedIssues() after the closing = %+v, %v, want none", closedSince, err)
+	}
+	if _, err := lister.Issues(ctx, gitlab.Scope{Kind: "user"}, time.Time{}); !errors.Is(err, gitlab.ErrUnsupportedScope) {
+		t.Errorf("Issues(user) error = %v, want %v", err, gitlab.ErrUnsupportedScope)
+	}
+}
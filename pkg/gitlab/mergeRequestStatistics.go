@@ -0,0 +1,129 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// MergeRequestCounts represents the merge request counts per state.
+type MergeRequestCounts struct {
+	All    int
+	Opened int
+	Merged int
+	Closed int
+	Locked int
+}
+
+// ServiceMergeRequestStatistics counts merge requests per state.
+// As there is no merge request statistics endpoint, the counts are read from
+// the X-Total header of the merge requests list filtered by state, or by
+// walking the list when GitLab leaves that header out.
+// See: https://docs.gitlab.com/ee/api/merge_requests.html
+type ServiceMergeRequestStatistics struct {
+	uri string
+}
+
+// NewProjectMergeRequestStatistics creates a new ServiceMergeRequestStatistics for a project.
+func NewProjectMergeRequestStatistics(projectID int) *ServiceMergeRequestStatistics {
+	r := ServiceMergeRequestStatistics{
+		uri: fmt.Sprintf("projects/%d/merge_requests", projectID),
+	}
+	return &r
+}
+
+// NewGroupMergeRequestStatistics creates a new ServiceMergeRequestStatistics for a group.
+func NewGroupMergeRequestStatistics(groupID int) *ServiceMergeRequestStatistics {
+	r := ServiceMergeRequestStatistics{
+		uri: fmt.Sprintf("groups/%d/merge_requests", groupID),
+	}
+	return &r
+}
+
+// GetStatistics retrieves merge request counts from GitLab API.
+func (r *ServiceMergeRequestStatistics) GetStatistics(gs *Service) (MergeRequestCounts, error) {
+	return r.GetStatisticsWithContext(context.Background(), gs)
+}
+
+// GetStatisticsWithContext retrieves merge request counts from GitLab API until the context is done.
+func (r *ServiceMergeRequestStatistics) GetStatisticsWithContext(
+	ctx context.Context,
+	gs *Service,
+) (MergeRequestCounts, error) {
+	var counts MergeRequestCounts
+	states := []struct {
+		state string
+		count *int
+	}{
+		{"all", &counts.All},
+		{"opened", &counts.Opened},
+		{"merged", &counts.Merged},
+		{"closed", &counts.Closed},
+		{"locked", &counts.Locked},
+	}
+	for _, s := range states {
+		total, err := r.countState(ctx, gs, s.state)
+		if err != nil {
+			return MergeRequestCounts{}, err
+		}
+		*s.count = total
+	}
+	return counts, nil
+}
+
+func (r *ServiceMergeRequestStatistics) countState(ctx context.Context, gs *Service, state string) (int, error) {
+	q := url.Values{}
+	q.Set("state", state)
+	q.Set("per_page", "1")
+	return getTotal(ctx, gs, r.uri+"?"+q.Encode())
+}
+
+// getTotal returns the number of items of a list endpoint queried with per_page=1.
+// It reads the X-Total header, then X-Total-Pages, which is the same number
+// with one item per page. GitLab leaves both out above 10,000 items: the list
+// is then walked and its items counted.
+func getTotal(ctx context.Context, gs *Service, path string) (int, error) {
+	resp, err := gs.GetWithContext(ctx, path)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close() // ignore error
+	}()
+	if resp.StatusCode != httpOK {
+		return 0, newAPIError(resp)
+	}
+	for _, header := range []string{"X-Total", "X-Total-Pages"} {
+		value := resp.Header.Get(header)
+		if value == "" {
+			continue
+		}
+		total, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s header %q: %w", header, value, err)
+		}
+		return total, nil
+	}
+	return countItems(ctx, gs, path)
+}
+
+// countItems walks every page of a list endpoint and counts its items.
+func countItems(ctx context.Context, gs *Service, path string) (int, error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("per_page", strconv.Itoa(defaultPerPage))
+	u.RawQuery = q.Encode()
+	total := 0
+	for _, err := range Paginate[json.RawMessage](ctx, gs, u.String()) {
+		if err != nil {
+			return 0, err
+		}
+		total++
+	}
+	return total, nil
+}
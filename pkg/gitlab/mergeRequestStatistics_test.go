@@ -0,0 +1,85 @@
+package gitlab_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+func TestGetMergeRequestStatistics(t *testing.T) {
+	totals := map[string]string{
+		"all":    "15",
+		"opened": "3",
+		"merged": "10",
+		"closed": "1",
+		"locked": "1",
+	}
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/groups/10/merge_requests" {
+				t.Errorf("unexpected path %s", r.URL.Path)
+			}
+			w.Header().Set("X-Total", totals[r.URL.Query().Get("state")])
+			fmt.Fprintln(w, `[]`)
+		}))
+	defer ts.Close()
+
+	s := gitlab.NewService()
+	s.SetHTTPClient(ts.Client())
+	s.SetGitlabEndpoint(ts.URL)
+
+	res, err := gitlab.NewGroupMergeRequestStatistics(10).GetStatistics(s)
+	if err != nil {
+		t.Fatalf("GetStatistics() error = %v", err)
+	}
+	want := gitlab.MergeRequestCounts{All: 15, Opened: 3, Merged: 10, Closed: 1, Locked: 1}
+	if !cmp.Equal(res, want) {
+		t.Errorf("GetStatistics() = %v, want %v", res, want)
+	}
+}
+
+func TestGetMergeRequestStatisticsMissingTotal(t *testing.T) {
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("state") {
+			case "all":
+				// GitLab leaves out both headers above 10,000 items
+				if r.URL.Query().Get("per_page") == "1" {
+					fmt.Fprintln(w, `[{}]`)
+					return
+				}
+				if r.URL.Query().Get("page") == "" {
+					q := r.URL.Query()
+					q.Set("page", "2")
+					w.Header().Set("Link", fmt.Sprintf(`<https://%s%s?%s>; rel="next"`, r.Host, r.URL.Path, q.Encode()))
+					fmt.Fprintln(w, `[{},{}]`)
+					return
+				}
+				fmt.Fprintln(w, `[{}]`)
+			case "opened":
+				w.Header().Set("X-Total-Pages", "2")
+				fmt.Fprintln(w, `[{}]`)
+			default:
+				w.Header().Set("X-Total", "0")
+				fmt.Fprintln(w, `[]`)
+			}
+		}))
+	defer ts.Close()
+
+	s := gitlab.NewService()
+	s.SetHTTPClient(ts.Client())
+	s.SetGitlabEndpoint(ts.URL)
+
+	res, err := gitlab.NewProjectMergeRequestStatistics(1).GetStatistics(s)
+	if err != nil {
+		t.Fatalf("GetStatistics() error = %v", err)
+	}
+	want := gitlab.MergeRequestCounts{All: 3, Opened: 2}
+	if !cmp.Equal(res, want) {
+		t.Errorf("GetStatistics() = %v, want %v", res, want)
+	}
+}
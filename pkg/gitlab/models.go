@@ -1,8 +1,13 @@
 package gitlab
 
+import "time"
+
 // Statistics represents the top-level response from GitLab statistics API.
 type Statistics struct {
 	Statistics Statistic `json:"statistics"`
+	// FromCache reports whether the response was served from the cache,
+	// unchanged since the previous request.
+	FromCache bool `json:"-"`
 }
 
 // Statistic represents the statistics data structure.
@@ -16,3 +21,35 @@ type Counts struct {
 	Closed int `json:"closed,omitempty"`
 	Opened int `json:"opened,omitempty"`
 }
+
+// Project represents a GitLab project.
+type Project struct {
+	ID                int    `json:"id"`
+	Name              string `json:"name"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	SSHURLToRepo      string `json:"ssh_url_to_repo"`
+	HTTPURLToRepo     string `json:"http_url_to_repo"`
+	WebURL            string `json:"web_url"`
+}
+
+// Group represents a GitLab group.
+type Group struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	FullPath string `json:"full_path"`
+	FullName string `json:"full_name"`
+	WebURL   string `json:"web_url"`
+}
+
+// Issue represents a GitLab issue with the dates of its history.
+type Issue struct {
+	ID        int       `json:"id"`
+	IID       int       `json:"iid"`
+	ProjectID int       `json:"project_id"`
+	State     string    `json:"state"`
+	Labels    []string  `json:"labels"`
+	CreatedAt time.Time `json:"created_at"`
+	// ClosedAt is nil for an open issue. A reopened issue is open: only its
+	// last closing date is known.
+	ClosedAt *time.Time `json:"closed_at"`
+}
@@ -0,0 +1,161 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const defaultPerPage = 100
+
+// ErrForeignNextPage is returned when a pagination link points to another host
+// or scheme than the configured GitLab API endpoint.
+var ErrForeignNextPage = errors.New("next page link points to a foreign host")
+
+// Paginate returns an iterator over every item of a GitLab list endpoint.
+// It follows the Link header (rel="next"), which covers both offset and keyset
+// pagination, and falls back on the X-Next-Page header when no link is given.
+// For keyset pagination, add pagination=keyset and order_by to the path query.
+// The iteration stops on the first error, which is yielded with a zero item.
+func Paginate[T any](ctx context.Context, s *Service, path string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		nextURL, err := s.firstPageURL(path)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		for nextURL != "" {
+			items, next, err := getPage[T](ctx, s, nextURL)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			nextURL = next
+		}
+	}
+}
+
+// GetAll retrieves every item of a GitLab list endpoint by walking all pages.
+func GetAll[T any](ctx context.Context, s *Service, path string) ([]T, error) {
+	var result []T
+	for item, err := range Paginate[T](ctx, s, path) {
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// firstPageURL builds the URL of the first page, asking for the largest
+// page size if the caller did not set one.
+func (r *Service) firstPageURL(path string) (string, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/%s", r.gitlabAPIEndpoint, path))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse URL: %w", err)
+	}
+	q := u.Query()
+	if q.Get("per_page") == "" {
+		q.Set("per_page", strconv.Itoa(defaultPerPage))
+		u.RawQuery = q.Encode()
+	}
+	return u.String(), nil
+}
+
+func getPage[T any](ctx context.Context, s *Service, pageURL string) ([]T, string, error) {
+	resp, err := s.getURL(ctx, pageURL)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() {
+		_ = resp.Body.Close() // ignore error
+	}()
+	if resp.StatusCode != httpOK {
+		return nil, "", newAPIError(resp)
+	}
+	var items []T
+	if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
+		return nil, "", fmt.Errorf("failed to decode JSON response: %w", err)
+	}
+	next, err := s.nextPageURL(pageURL, resp.Header)
+	if err != nil {
+		return nil, "", err
+	}
+	return items, next, nil
+}
+
+// nextPageURL returns the URL of the next page or an empty string on the last page.
+func (r *Service) nextPageURL(currentURL string, header http.Header) (string, error) {
+	if next := parseLinkHeader(header.Get("Link"))["next"]; next != "" {
+		if err := r.checkSameHost(next); err != nil {
+			return "", err
+		}
+		return next, nil
+	}
+	nextPage := header.Get("X-Next-Page")
+	if nextPage == "" {
+		return "", nil
+	}
+	u, err := url.Parse(currentURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("page", nextPage)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// checkSameHost prevents the token from being sent to a host returned by a Link header,
+// or in plain text when the link downgrades https to http.
+func (r *Service) checkSameHost(rawURL string) error {
+	endpoint, err := url.Parse(r.gitlabAPIEndpoint)
+	if err != nil {
+		return fmt.Errorf("failed to parse endpoint: %w", err)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse next page URL: %w", err)
+	}
+	if u.Scheme != endpoint.Scheme || u.Host != endpoint.Host {
+		return fmt.Errorf("%w: %s://%s", ErrForeignNextPage, u.Scheme, u.Host)
+	}
+	return nil
+}
+
+// parseLinkHeader parses a RFC 8288 Link header into a map of rel to URL.
+func parseLinkHeader(header string) map[string]string {
+	links := make(map[string]string)
+	for part := range strings.SplitSeq(header, ",") {
+		segments := strings.Split(strings.TrimSpace(part), ";")
+		if len(segments) < 2 {
+			continue
+		}
+		target := strings.TrimSpace(segments[0])
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		target = target[1 : len(target)-1]
+		for _, param := range segments[1:] {
+			key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if found && strings.TrimSpace(key) == "rel" {
+				for rel := range strings.FieldsSeq(strings.Trim(value, `"`)) {
+					links[rel] = target
+				}
+			}
+		}
+	}
+	return links
+}
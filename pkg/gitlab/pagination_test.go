@@ -0,0 +1,163 @@
+package gitlab_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+type item struct {
+	ID int `json:"id"`
+}
+
+func TestGetAllFollowsXNextPage(t *testing.T) {
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("per_page") != "100" {
+				t.Errorf("per_page = %q, want 100", r.URL.Query().Get("per_page"))
+			}
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			if page == 0 {
+				page = 1
+			}
+			if page < 3 {
+				w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+			}
+			responseJSON, _ := json.Marshal([]item{{ID: page*10 + 1}, {ID: page*10 + 2}})
+			fmt.Fprintln(w, string(responseJSON))
+		}))
+	defer ts.Close()
+
+	s := gitlab.NewService()
+	s.SetHTTPClient(ts.Client())
+	s.SetGitlabEndpoint(ts.URL)
+
+	res, err := gitlab.GetAll[item](context.Background(), s, "projects")
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	want := []item{{11}, {12}, {21}, {22}, {31}, {32}}
+	if !cmp.Equal(res, want) {
+		t.Errorf("GetAll() = %v, want %v", res, want)
+	}
+}
+
+func TestGetAllFollowsKeysetLink(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("pagination") != "keyset" {
+				t.Errorf("pagination = %q, want keyset", r.URL.Query().Get("pagination"))
+			}
+			idAfter := r.URL.Query().Get("id_after")
+			if idAfter == "" {
+				w.Header().Set("Link",
+					fmt.Sprintf(`<%s/projects?id_after=1&order_by=id&pagination=keyset&per_page=1>; rel="next"`, ts.URL))
+				fmt.Fprintln(w, `[{"id":1}]`)
+				return
+			}
+			fmt.Fprintln(w, `[{"id":2}]`)
+		}))
+	defer ts.Close()
+
+	s := gitlab.NewService()
+	s.SetHTTPClient(ts.Client())
+	s.SetGitlabEndpoint(ts.URL)
+
+	res, err := gitlab.GetAll[item](context.Background(), s, "projects?pagination=keyset&order_by=id&per_page=1")
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	want := []item{{1}, {2}}
+	if !cmp.Equal(res, want) {
+		t.Errorf("GetAll() = %v, want %v", res, want)
+	}
+}
+
+func TestPaginateStopsEarly(t *testing.T) {
+	calls := 0
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("X-Next-Page", strconv.Itoa(calls+1))
+			fmt.Fprintln(w, `[{"id":1},{"id":2}]`)
+		}))
+	defer ts.Close()
+
+	s := gitlab.NewService()
+	s.SetHTTPClient(ts.Client())
+	s.SetGitlabEndpoint(ts.URL)
+
+	for it, err := range gitlab.Paginate[item](context.Background(), s, "projects") {
+		if err != nil {
+			t.Fatalf("Paginate() error = %v", err)
+		}
+		if it.ID == 2 {
+			break
+		}
+	}
+	if calls != 1 {
+		t.Errorf("server called %d times, want 1", calls)
+	}
+}
+
+func TestPaginateRejectsForeignLink(t *testing.T) {
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Link", `<https://evil.example.com/api/v4/projects?page=2>; rel="next"`)
+			fmt.Fprintln(w, `[{"id":1}]`)
+		}))
+	defer ts.Close()
+
+	s := gitlab.NewService()
+	s.SetHTTPClient(ts.Client())
+	s.SetGitlabEndpoint(ts.URL)
+
+	_, err := gitlab.GetAll[item](context.Background(), s, "projects")
+	if !errors.Is(err, gitlab.ErrForeignNextPage) {
+		t.Errorf("GetAll() error = %v, want %v", err, gitlab.ErrForeignNextPage)
+	}
+}
+
+func TestPaginateRejectsSchemeDowngrade(t *testing.T) {
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s/projects?page=2>; rel="next"`, r.Host))
+			fmt.Fprintln(w, `[{"id":1}]`)
+		}))
+	defer ts.Close()
+
+	s := gitlab.NewService()
+	s.SetHTTPClient(ts.Client())
+	s.SetGitlabEndpoint(ts.URL)
+
+	_, err := gitlab.GetAll[item](context.Background(), s, "projects")
+	if !errors.Is(err, gitlab.ErrForeignNextPage) {
+		t.Errorf("GetAll() error = %v, want %v", err, gitlab.ErrForeignNextPage)
+	}
+}
+
+func TestPaginateNon200(t *testing.T) {
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+	defer ts.Close()
+
+	s := gitlab.NewService()
+	s.SetHTTPClient(ts.Client())
+	s.SetGitlabEndpoint(ts.URL)
+
+	_, err := gitlab.GetAll[item](context.Background(), s, "projects")
+	if !errors.Is(err, gitlab.ErrNon200Response) {
+		t.Errorf("GetAll() error = %v, want %v", err, gitlab.ErrNon200Response)
+	}
+}
@@ -0,0 +1,55 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ListGroupProjects returns the projects of a group and of all its subgroups.
+// Projects shared with the group from other namespaces are not included.
+func ListGroupProjects(ctx context.Context, gs *Service, groupID int) ([]Project, error) {
+	path := fmt.Sprintf("groups/%d/projects?include_subgroups=true&with_shared=false&order_by=id&sort=asc", groupID)
+	projects, err := GetAll[Project](ctx, gs, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects of group %d: %w", groupID, err)
+	}
+	return projects, nil
+}
+
+// GetProject returns a project from its ID or its full path, for instance "namespace/subgroup/project".
+func GetProject(ctx context.Context, gs *Service, idOrPath string) (Project, error) {
+	return getJSON[Project](ctx, gs, "projects/"+escapeIDOrPath(idOrPath))
+}
+
+// GetGroup returns a group from its ID or its full path, for instance "namespace/subgroup".
+func GetGroup(ctx context.Context, gs *Service, idOrPath string) (Group, error) {
+	return getJSON[Group](ctx, gs, "groups/"+escapeIDOrPath(idOrPath))
+}
+
+// escapeIDOrPath URL-encodes a full path so that it is a single segment of the URL,
+// as required by the GitLab API.
+func escapeIDOrPath(idOrPath string) string {
+	return url.PathEscape(strings.Trim(idOrPath, "/"))
+}
+
+// getJSON decodes the response of a GET request. Unknown fields are ignored.
+func getJSON[T any](ctx context.Context, gs *Service, path string) (T, error) {
+	var result T
+	resp, err := gs.GetWithContext(ctx, path)
+	if err != nil {
+		return result, err
+	}
+	defer func() {
+		_ = resp.Body.Close() // ignore error
+	}()
+	if resp.StatusCode != httpOK {
+		return result, newAPIError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return result, fmt.Errorf("failed to decode JSON response: %w", err)
+	}
+	return result, nil
+}
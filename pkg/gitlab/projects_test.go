@@ -0,0 +1,87 @@
+package gitlab_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+func TestListGroupProjects(t *testing.T) {
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/groups/10/projects" {
+				t.Errorf("unexpected path %s", r.URL.Path)
+			}
+			if r.URL.Query().Get("include_subgroups") != "true" {
+				t.Errorf("include_subgroups should be true")
+			}
+			if r.URL.Query().Get("page") == "" {
+				w.Header().Set("X-Next-Page", "2")
+				fmt.Fprintln(w, `[{"id":1,"name":"a","path_with_namespace":"grp/a"}]`)
+				return
+			}
+			fmt.Fprintln(w, `[{"id":2,"name":"b","path_with_namespace":"grp/sub/b"}]`)
+		}))
+	defer ts.Close()
+
+	s := gitlab.NewService()
+	s.SetHTTPClient(ts.Client())
+	s.SetGitlabEndpoint(ts.URL)
+
+	projects, err := gitlab.ListGroupProjects(context.Background(), s, 10)
+	if err != nil {
+		t.Fatalf("ListGroupProjects() error = %v", err)
+	}
+	if len(projects) != 2 || projects[1].PathWithNamespace != "grp/sub/b" {
+		t.Errorf("ListGroupProjects() = %+v", projects)
+	}
+}
+
+func TestGetProjectByPath(t *testing.T) {
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.RequestURI != "/projects/grp%2Fsub%2Fproj" {
+				t.Errorf("unexpected request URI %s", r.RequestURI)
+			}
+			fmt.Fprintln(w, `{"id":42,"name":"proj","path_with_namespace":"grp/sub/proj","visibility":"private"}`)
+		}))
+	defer ts.Close()
+
+	s := gitlab.NewService()
+	s.SetHTTPClient(ts.Client())
+	s.SetGitlabEndpoint(ts.URL)
+
+	project, err := gitlab.GetProject(context.Background(), s, "grp/sub/proj")
+	if err != nil {
+		t.Fatalf("GetProject() error = %v", err)
+	}
+	if project.ID != 42 || project.PathWithNamespace != "grp/sub/proj" {
+		t.Errorf("GetProject() = %+v", project)
+	}
+}
+
+func TestGetGroupNotFound(t *testing.T) {
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.RequestURI != "/groups/grp%2Fsub" {
+				t.Errorf("unexpected request URI %s", r.RequestURI)
+			}
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintln(w, `{"message":"404 Group Not Found"}`)
+		}))
+	defer ts.Close()
+
+	s := gitlab.NewService()
+	s.SetHTTPClient(ts.Client())
+	s.SetGitlabEndpoint(ts.URL)
+
+	_, err := gitlab.GetGroup(context.Background(), s, "grp/sub")
+	if !errors.Is(err, gitlab.ErrNotFound) {
+		t.Errorf("GetGroup() error = %v, want %v", err, gitlab.ErrNotFound)
+	}
+}
@@ -0,0 +1,242 @@
+package gitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// originPlaceholder replaces the scheme and host of the recorded instance in
+// fixtures, so that they can be replayed against any endpoint.
+const originPlaceholder = "{{origin}}"
+
+const redacted = "REDACTED"
+
+// ErrNoInteraction is returned by a Replayer when no recorded interaction matches a request.
+var ErrNoInteraction = errors.New("no recorded interaction for request")
+
+// sensitiveHeaders are redacted from fixtures.
+var sensitiveHeaders = []string{PrivateTokenHeader, JobTokenHeader, "Authorization", "Cookie", "Set-Cookie"}
+
+// sensitiveParams are redacted from the query of the recorded URLs.
+var sensitiveParams = []string{"private_token", "job_token", "access_token"}
+
+// Interaction is a recorded request with its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request of an Interaction. URL is the path and query, without host.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+}
+
+// RecordedResponse is a response of an Interaction.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Fixture is the content of a fixture file.
+type Fixture struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper which records the interactions with GitLab
+// to a fixture file. Tokens are redacted and the host of the instance is
+// replaced by a placeholder. The file is written after each interaction, so
+// that it is complete even if the program exits early.
+type Recorder struct {
+	transport http.RoundTripper
+	path      string
+	mu        sync.Mutex
+	fixture   Fixture
+}
+
+// NewRecorder returns a Recorder sending the requests with transport
+// (http.DefaultTransport if nil) and writing the fixture to path.
+func NewRecorder(transport http.RoundTripper, path string) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Recorder{transport: transport, path: path}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // transparent transport
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	interaction := sanitize(req, resp, string(body))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fixture.Interactions = append(r.fixture.Interactions, interaction)
+	if err := writeFixture(r.path, r.fixture); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// sanitize converts an exchange to an Interaction without secrets nor host.
+func sanitize(req *http.Request, resp *http.Response, body string) Interaction {
+	origin := req.URL.Scheme + "://" + req.URL.Host
+	var secrets []string
+	for _, name := range sensitiveHeaders {
+		secrets = append(secrets, req.Header.Values(name)...)
+	}
+	clean := func(s string) string {
+		for _, secret := range secrets {
+			if secret != "" {
+				s = strings.ReplaceAll(s, secret, redacted)
+			}
+		}
+		return strings.ReplaceAll(s, origin, originPlaceholder)
+	}
+	cleanHeader := func(h http.Header) http.Header {
+		h = h.Clone()
+		for name, values := range h {
+			for i := range values {
+				values[i] = clean(values[i])
+			}
+			h[name] = values
+		}
+		for _, name := range sensitiveHeaders {
+			if h.Get(name) != "" {
+				h.Set(name, redacted)
+			}
+		}
+		return h
+	}
+
+	u := *req.URL
+	q := u.Query()
+	for _, param := range sensitiveParams {
+		if q.Has(param) {
+			q.Set(param, redacted)
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	return Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    u.RequestURI(),
+			Header: cleanHeader(req.Header),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     cleanHeader(resp.Header),
+			Body:       clean(body),
+		},
+	}
+}
+
+func writeFixture(path string, fixture Fixture) error {
+	content, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+	if err := os.WriteFile(path, append(content, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return nil
+}
+
+// Replayer is an http.RoundTripper answering requests with the interactions
+// of a fixture file, without network access. A request matches the first
+// unused interaction with the same method, path and query, whatever its host.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer loads the fixture file written by a Recorder.
+func NewReplayer(path string) (*Replayer, error) {
+	content, err := os.ReadFile(path) //nolint:gosec // fixture given by the user
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(content, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to decode fixture %s: %w", path, err)
+	}
+	return &Replayer{
+		interactions: fixture.Interactions,
+		used:         make([]bool, len(fixture.Interactions)),
+	}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestURI := normalizeRequestURI(req.URL)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.interactions {
+		if r.used[i] || interaction.Request.Method != req.Method {
+			continue
+		}
+		recorded, err := url.Parse(interaction.Request.URL)
+		if err != nil || normalizeRequestURI(recorded) != requestURI {
+			continue
+		}
+		r.used[i] = true
+		return replay(req, interaction.Response), nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, requestURI)
+}
+
+// normalizeRequestURI returns the path and the sorted query of a URL, without secrets.
+func normalizeRequestURI(u *url.URL) string {
+	q := u.Query()
+	for _, param := range sensitiveParams {
+		if q.Has(param) {
+			q.Set(param, redacted)
+		}
+	}
+	return (&url.URL{Path: u.Path, RawPath: u.RawPath, RawQuery: q.Encode()}).RequestURI()
+}
+
+func replay(req *http.Request, recorded RecordedResponse) *http.Response {
+	origin := req.URL.Scheme + "://" + req.URL.Host
+	header := recorded.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	for _, values := range header {
+		for i := range values {
+			values[i] = strings.ReplaceAll(values[i], originPlaceholder, origin)
+		}
+	}
+	body := strings.ReplaceAll(recorded.Body, originPlaceholder, origin)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,100 @@
+package gitlab_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+func TestRecorderAndReplayer(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("page") == "" {
+				w.Header().Set("Link", fmt.Sprintf(`<%s/groups/1/projects?page=2>; rel="next"`, ts.URL))
+				fmt.Fprintf(w, `[{"id":1,"name":"a","web_url":"%s/grp/a"}]`, ts.URL)
+				return
+			}
+			// some endpoints echo the token, it must not leak in fixtures
+			fmt.Fprintf(w, `[{"id":2,"name":"%s"}]`, r.Header.Get("Private-Token"))
+		}))
+	defer ts.Close()
+
+	fixture := filepath.Join(t.TempDir(), "fixture.json")
+	recorded := gitlab.NewService()
+	recorded.SetHTTPClient(&http.Client{Transport: gitlab.NewRecorder(ts.Client().Transport, fixture)})
+	recorded.SetGitlabEndpoint(ts.URL)
+	recorded.SetToken("secret-token")
+	want, err := gitlab.ListGroupProjects(context.Background(), recorded, 1)
+	if err != nil {
+		t.Fatalf("ListGroupProjects() error = %v", err)
+	}
+
+	content, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "secret-token") {
+		t.Errorf("fixture contains the token:\n%s", content)
+	}
+	if strings.Contains(string(content), strings.TrimPrefix(ts.URL, "https://")) {
+		t.Errorf("fixture contains the host of the instance:\n%s", content)
+	}
+
+	replayer, err := gitlab.NewReplayer(fixture)
+	if err != nil {
+		t.Fatalf("NewReplayer() error = %v", err)
+	}
+	replayed := gitlab.NewService()
+	replayed.SetHTTPClient(&http.Client{Transport: replayer})
+	replayed.SetGitlabEndpoint("https://gitlab.example.invalid")
+	replayed.SetRetryPolicy(gitlab.RetryPolicy{})
+	got, err := gitlab.ListGroupProjects(context.Background(), replayed, 1)
+	if err != nil {
+		t.Fatalf("ListGroupProjects() replayed error = %v", err)
+	}
+	want[0].WebURL = "https://gitlab.example.invalid/grp/a"
+	want[1].Name = "REDACTED"
+	if !cmp.Equal(got, want) {
+		t.Errorf("ListGroupProjects() replayed = %+v, want %+v", got, want)
+	}
+
+	// all interactions were used
+	_, err = gitlab.ListGroupProjects(context.Background(), replayed, 1)
+	if !errors.Is(err, gitlab.ErrNoInteraction) {
+		t.Errorf("ListGroupProjects() error = %v, want %v", err, gitlab.ErrNoInteraction)
+	}
+}
+
+func TestReplayStatisticsFixture(t *testing.T) {
+	replayer, err := gitlab.NewReplayer(filepath.Join("testdata", "project_statistics.json"))
+	if err != nil {
+		t.Fatalf("NewReplayer() error = %v", err)
+	}
+	s := gitlab.NewService()
+	s.SetHTTPClient(&http.Client{Transport: replayer})
+	s.SetGitlabEndpoint("https://gitlab.example.invalid/api/v4")
+
+	res, err := gitlab.NewProjectStatistics(42).GetStatistics(s)
+	if err != nil {
+		t.Fatalf("GetStatistics() error = %v", err)
+	}
+	want := gitlab.Counts{All: 120, Closed: 100, Opened: 20}
+	if res.Statistics.Counts != want {
+		t.Errorf("GetStatistics() = %+v, want %+v", res.Statistics.Counts, want)
+	}
+
+	_, err = gitlab.NewGroupStatistics(42).GetStatistics(s)
+	if !errors.Is(err, gitlab.ErrForbidden) {
+		t.Errorf("GetStatistics() error = %v, want %v", err, gitlab.ErrForbidden)
+	}
+}
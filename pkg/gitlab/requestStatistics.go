@@ -1,28 +1,29 @@
 package gitlab
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"io"
-	"strings"
 )
 
 const httpOK = 200
 
 // ErrNon200Response is returned when the HTTP response status is not 200.
+// The actual error is an *APIError carrying the status code and GitLab's message.
 var ErrNon200Response = errors.New("non-200 response code")
 
 // ServiceStatistics provides access to GitLab issue statistics API.
 // See: https://docs.gitlab.com/ee/api/issues_statistics.html
 type ServiceStatistics struct {
-	uri string
+	uri    string
+	filter IssueFilter
 }
 
 // NewProjectStatistics creates a new ServiceStatistics for a project.
 func NewProjectStatistics(projectID int) *ServiceStatistics {
 	r := ServiceStatistics{
-		uri: fmt.Sprintf("projects/%d/issues_statistics?", projectID),
+		uri: fmt.Sprintf("projects/%d/issues_statistics", projectID),
 	}
 	return &r
 }
@@ -30,17 +31,44 @@ func NewProjectStatistics(projectID int) *ServiceStatistics {
 // NewGroupStatistics creates a new ServiceStatistics for a group.
 func NewGroupStatistics(groupID int) *ServiceStatistics {
 	r := ServiceStatistics{
-		uri: fmt.Sprintf("groups/%d/issues_statistics?", groupID),
+		uri: fmt.Sprintf("groups/%d/issues_statistics", groupID),
 	}
 	return &r
 }
 
+// NewInstanceStatistics creates a new ServiceStatistics for all the issues
+// the token has access to on the instance.
+func NewInstanceStatistics() *ServiceStatistics {
+	r := ServiceStatistics{
+		uri: "issues_statistics",
+	}
+	return &r
+}
+
+// SetFilter restricts the issues counted by the statistics.
+func (r *ServiceStatistics) SetFilter(filter IssueFilter) {
+	r.filter = filter
+}
+
+// path returns the request path with the filter encoded in the query string.
+func (r *ServiceStatistics) path() string {
+	return r.uri + "?" + r.filter.Values().Encode()
+}
+
 // GetStatistics retrieves statistics from GitLab API.
 func (r *ServiceStatistics) GetStatistics(gs *Service) (Statistics, error) {
+	return r.GetStatisticsWithContext(context.Background(), gs)
+}
+
+// GetStatisticsWithContext retrieves statistics from GitLab API until the context is done.
+func (r *ServiceStatistics) GetStatisticsWithContext(ctx context.Context, gs *Service) (Statistics, error) {
 	// if r.uri == "" {
 	// 	return result, errors.New("no project or group specified")
 	// }
-	resp, err := gs.Get(r.uri)
+	if err := r.filter.Validate(); err != nil {
+		return Statistics{}, err
+	}
+	resp, err := gs.GetWithContext(ctx, r.path())
 	if err != nil {
 		return Statistics{}, err
 	}
@@ -48,22 +76,16 @@ func (r *ServiceStatistics) GetStatistics(gs *Service) (Statistics, error) {
 		_ = resp.Body.Close() // ignore error
 	}()
 	if resp.StatusCode != httpOK {
-		// !TODO: handle 404
-		return Statistics{}, fmt.Errorf("%w: %d", ErrNon200Response, resp.StatusCode)
+		return Statistics{}, newAPIError(resp)
 	}
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return Statistics{}, fmt.Errorf("failed to read response body: %w", err)
 	}
-	// if err := json.Unmarshal(body, &result); err != nil {
-	// 	return result, err
-	// }
 	var result Statistics
-	decoder := json.NewDecoder(strings.NewReader(string(body)))
-	decoder.DisallowUnknownFields()
-	err = decoder.Decode(&result)
-	if err != nil {
-		return Statistics{}, fmt.Errorf("failed to decode JSON response: %w", err)
+	if err := gs.decodeJSON(r.uri, body, &result, "statistics"); err != nil {
+		return Statistics{}, err
 	}
+	result.FromCache = FromCache(resp)
 	return result, nil
 }
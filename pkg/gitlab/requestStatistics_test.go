@@ -2,6 +2,7 @@ package gitlab_test
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -73,3 +74,117 @@ func TestGetStatisticsWrongResponse(t *testing.T) {
 		t.Errorf("GetStatistics() should return an error")
 	}
 }
+
+func TestGetStatisticsScopes(t *testing.T) {
+	tests := []struct {
+		name string
+		r    *gitlab.ServiceStatistics
+		path string
+	}{
+		{name: "project", r: gitlab.NewProjectStatistics(1), path: "/projects/1/issues_statistics"},
+		{name: "group", r: gitlab.NewGroupStatistics(2), path: "/groups/2/issues_statistics"},
+		{name: "instance", r: gitlab.NewInstanceStatistics(), path: "/issues_statistics"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path string
+			ts := httptest.NewTLSServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					path = r.URL.Path
+					fmt.Fprintln(w, `{"statistics":{"counts":{"all":1,"closed":0,"opened":1}}}`)
+				}))
+			defer ts.Close()
+
+			s := gitlab.NewService()
+			s.SetHTTPClient(ts.Client())
+			s.SetGitlabEndpoint(ts.URL)
+
+			if _, err := tt.r.GetStatistics(s); err != nil {
+				t.Fatalf("GetStatistics() error = %v", err)
+			}
+			if path != tt.path {
+				t.Errorf("GetStatistics() requested %s, want %s", path, tt.path)
+			}
+		})
+	}
+}
+
+func TestGetStatisticsUnknownFields(t *testing.T) {
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, `{"statistics":{"counts":{"all":3,"closed":1,"opened":2,"archived":4}},"scope":"all"}`)
+		}))
+	defer ts.Close()
+
+	s := gitlab.NewService()
+	s.SetHTTPClient(ts.Client())
+	s.SetGitlabEndpoint(ts.URL)
+	var drifts []gitlab.SchemaDrift
+	s.SetSchemaDriftHandler(func(d gitlab.SchemaDrift) {
+		drifts = append(drifts, d)
+	})
+
+	r := gitlab.NewProjectStatistics(1)
+	for range 2 {
+		res, err := r.GetStatistics(s)
+		if err != nil {
+			t.Fatalf("GetStatistics() error = %v", err)
+		}
+		if res.Statistics.Counts != (gitlab.Counts{All: 3, Closed: 1, Opened: 2}) {
+			t.Errorf("GetStatistics() counts = %+v", res.Statistics.Counts)
+		}
+	}
+	if _, err := gitlab.NewGroupStatistics(2).GetStatistics(s); err != nil {
+		t.Fatalf("GetStatistics() error = %v", err)
+	}
+
+	// every response is reported, the fields are new once per endpoint
+	names := []string{"scope", "statistics.counts.archived"}
+	want := []struct {
+		endpoint string
+		new      []string
+	}{
+		{"projects/1/issues_statistics", names},
+		{"projects/1/issues_statistics", nil},
+		{"groups/2/issues_statistics", names},
+	}
+	if len(drifts) != len(want) {
+		t.Fatalf("drift handler called %d times, want %d", len(drifts), len(want))
+	}
+	for i, w := range want {
+		if drifts[i].Endpoint != w.endpoint {
+			t.Errorf("drift %d endpoint = %q, want %q", i, drifts[i].Endpoint, w.endpoint)
+		}
+		if diff := cmp.Diff(names, drifts[i].Names()); diff != "" {
+			t.Errorf("drift %d fields mismatch (-want +got):\n%s", i, diff)
+		}
+		if diff := cmp.Diff(w.new, drifts[i].New); diff != "" {
+			t.Errorf("drift %d new fields mismatch (-want +got):\n%s", i, diff)
+		}
+		if string(drifts[i].Fields["statistics.counts.archived"]) != "4" {
+			t.Errorf("drift %d fields = %v", i, drifts[i].Fields)
+		}
+	}
+
+	s.SetStrictDecoding(true)
+	if _, err := r.GetStatistics(s); err == nil {
+		t.Errorf("GetStatistics() should return an error in strict mode")
+	}
+}
+
+func TestGetStatisticsMissingStatistics(t *testing.T) {
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, `{"counts":{"all":1}}`)
+		}))
+	defer ts.Close()
+
+	s := gitlab.NewService()
+	s.SetHTTPClient(ts.Client())
+	s.SetGitlabEndpoint(ts.URL)
+
+	_, err := gitlab.NewProjectStatistics(1).GetStatistics(s)
+	if !errors.Is(err, gitlab.ErrMissingField) {
+		t.Errorf("GetStatistics() error = %v, want %v", err, gitlab.ErrMissingField)
+	}
+}
@@ -0,0 +1,243 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxRetries         = 3
+	defaultBaseDelay          = 500 * time.Millisecond
+	defaultMaxDelay           = 30 * time.Second
+	defaultRateLimitThreshold = 1
+)
+
+// RetryPolicy configures how idempotent requests are retried
+// when GitLab answers 429 or 5xx, or when the connection fails.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt (0 disables retries).
+	MaxRetries int
+	// BaseDelay is the delay before the first retry, doubled on each attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between two attempts, including Retry-After.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy returns the retry policy used by NewService.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: defaultMaxRetries,
+		BaseDelay:  defaultBaseDelay,
+		MaxDelay:   defaultMaxDelay,
+	}
+}
+
+// backoff returns the jittered delay before the given retry attempt (starting at 0).
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << attempt
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	// equal jitter: half fixed, half random
+	half := d / 2
+	return half + rand.N(d-half+1) //nolint:gosec // jitter does not need a secure random source
+}
+
+// capDelay limits a server-provided delay to MaxDelay.
+func (p RetryPolicy) capDelay(d time.Duration) time.Duration {
+	if d > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return d
+}
+
+// RateLimitHeaders names the response headers giving the number of requests
+// left in the rate limit window and the Unix time of its reset.
+type RateLimitHeaders struct {
+	Remaining string
+	Reset     string
+}
+
+// GitLabRateLimitHeaders are the rate limit headers of GitLab.
+var GitLabRateLimitHeaders = RateLimitHeaders{Remaining: "RateLimit-Remaining", Reset: "RateLimit-Reset"}
+
+// rateLimiter tracks the rate limit headers of the responses.
+// It is shared by all requests of a Retrier, so concurrent callers throttle together.
+type rateLimiter struct {
+	mu        sync.Mutex
+	headers   RateLimitHeaders
+	remaining int
+	reset     time.Time
+	threshold int
+}
+
+func newRateLimiter(headers RateLimitHeaders) *rateLimiter {
+	return &rateLimiter{
+		headers:   headers,
+		remaining: -1,
+		threshold: defaultRateLimitThreshold,
+	}
+}
+
+// wait blocks until the rate limit window resets when the remaining
+// number of requests is at or below the threshold.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	var d time.Duration
+	if l.remaining >= 0 && l.remaining <= l.threshold {
+		d = time.Until(l.reset)
+	}
+	l.mu.Unlock()
+	return sleep(ctx, d)
+}
+
+// update records the rate limit state from a response.
+func (l *rateLimiter) update(header http.Header) {
+	remaining, err := strconv.Atoi(header.Get(l.headers.Remaining))
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseInt(header.Get(l.headers.Reset), 10, 64)
+	if err != nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.remaining = remaining
+	l.reset = time.Unix(reset, 0)
+}
+
+// Retrier throttles requests according to the rate limit headers and retries
+// idempotent requests following a retry policy. A Service has its own; the
+// clients of other forges create one with NewRetrier.
+type Retrier struct {
+	policy    RetryPolicy
+	limiter   *rateLimiter
+	retryable func(*http.Response) bool
+}
+
+// NewRetrier returns a Retrier reading the given rate limit headers, with the
+// default retry policy. retryable tells which responses are retried: nil
+// retries 429 and 5xx responses.
+func NewRetrier(headers RateLimitHeaders, retryable func(*http.Response) bool) *Retrier {
+	if retryable == nil {
+		retryable = isRetryable
+	}
+	return &Retrier{
+		policy:    DefaultRetryPolicy(),
+		limiter:   newRateLimiter(headers),
+		retryable: retryable,
+	}
+}
+
+// SetPolicy sets the retry policy.
+// default: DefaultRetryPolicy()
+func (r *Retrier) SetPolicy(policy RetryPolicy) {
+	r.policy = policy
+}
+
+// SetRateLimitThreshold sets the number of remaining requests below which
+// requests wait for the rate limit window to reset.
+// default: 1
+func (r *Retrier) SetRateLimitThreshold(threshold int) {
+	r.limiter.mu.Lock()
+	defer r.limiter.mu.Unlock()
+	r.limiter.threshold = threshold
+}
+
+// Do executes an idempotent request with the client, retrying on transport
+// errors and retryable responses. The last response is returned when retries
+// are exhausted.
+func (r *Retrier) Do(client *http.Client, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		if err := r.limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			if attempt >= r.policy.MaxRetries || ctx.Err() != nil {
+				return nil, fmt.Errorf("failed to execute GET request: %w", err)
+			}
+			if err := sleep(ctx, r.policy.backoff(attempt)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		r.limiter.update(resp.Header)
+		if !r.retryable(resp) || attempt >= r.policy.MaxRetries {
+			return resp, nil
+		}
+		delay, ok := retryAfter(resp.Header)
+		if ok {
+			delay = r.policy.capDelay(delay)
+		} else {
+			delay = r.policy.backoff(attempt)
+		}
+		_ = resp.Body.Close() // ignore error
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// SetRetryPolicy sets the retry policy for idempotent requests.
+// default: DefaultRetryPolicy()
+func (r *Service) SetRetryPolicy(policy RetryPolicy) {
+	r.retrier.SetPolicy(policy)
+}
+
+// SetRateLimitThreshold sets the number of remaining requests below which
+// the service waits for the rate limit window to reset.
+// default: 1
+func (r *Service) SetRateLimitThreshold(threshold int) {
+	r.retrier.SetRateLimitThreshold(threshold)
+}
+
+// doWithRetry executes an idempotent request, retrying on transport errors,
+// 429 and 5xx responses. The last response is returned when retries are exhausted.
+func (r *Service) doWithRetry(req *http.Request) (*http.Response, error) {
+	return r.retrier.Do(r.httpClient, req)
+}
+
+func isRetryable(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// retryAfter parses the Retry-After header, given either in seconds or as an HTTP date.
+func retryAfter(header http.Header) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date), true
+	}
+	return 0, false
+}
+
+// sleep waits for the given duration or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("request canceled while waiting: %w", ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
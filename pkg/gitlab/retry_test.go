@@ -0,0 +1,136 @@
+package gitlab_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+func newRetryService(ts *httptest.Server, maxRetries int) *gitlab.Service {
+	s := gitlab.NewService()
+	s.SetHTTPClient(ts.Client())
+	s.SetGitlabEndpoint(ts.URL)
+	s.SetRetryPolicy(gitlab.RetryPolicy{
+		MaxRetries: maxRetries,
+		BaseDelay:  time.Millisecond,
+		MaxDelay:   10 * time.Millisecond,
+	})
+	return s
+}
+
+func TestGetRetriesOnTooManyRequests(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) <= 2 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			fmt.Fprintln(w, `{"statistics":{"counts":{"all":3,"closed":1,"opened":2}}}`)
+		}))
+	defer ts.Close()
+
+	s := newRetryService(ts, 3)
+	res, err := gitlab.NewProjectStatistics(1).GetStatistics(s)
+	if err != nil {
+		t.Fatalf("GetStatistics() error = %v", err)
+	}
+	if res.Statistics.Counts.All != 3 {
+		t.Errorf("GetStatistics() all = %d, want 3", res.Statistics.Counts.All)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("server called %d times, want 3", calls.Load())
+	}
+}
+
+func TestGetRetryBudgetExhausted(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+	defer ts.Close()
+
+	s := newRetryService(ts, 2)
+	_, err := gitlab.NewProjectStatistics(1).GetStatistics(s)
+	if !errors.Is(err, gitlab.ErrNon200Response) {
+		t.Errorf("GetStatistics() error = %v, want %v", err, gitlab.ErrNon200Response)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("server called %d times, want 3", calls.Load())
+	}
+}
+
+func TestGetDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+		}))
+	defer ts.Close()
+
+	s := newRetryService(ts, 3)
+	_, err := gitlab.NewProjectStatistics(1).GetStatistics(s)
+	if err == nil {
+		t.Errorf("GetStatistics() should return an error")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("server called %d times, want 1", calls.Load())
+	}
+}
+
+func TestPostIsNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+	defer ts.Close()
+
+	s := newRetryService(ts, 3)
+	resp, err := s.Post("groups")
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	defer resp.Body.Close()
+	if calls.Load() != 1 {
+		t.Errorf("server called %d times, want 1", calls.Load())
+	}
+}
+
+func TestGetThrottlesBeforeRateLimit(t *testing.T) {
+	var calls atomic.Int32
+	var reset time.Time
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				reset = time.Now().Add(time.Second).Truncate(time.Second).Add(time.Second)
+				w.Header().Set("RateLimit-Remaining", "1")
+				w.Header().Set("RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+			} else if time.Now().Before(reset) {
+				t.Errorf("second request sent before the rate limit reset")
+			}
+			fmt.Fprintln(w, `[]`)
+		}))
+	defer ts.Close()
+
+	s := newRetryService(ts, 0)
+	for range 2 {
+		resp, err := s.Get("projects")
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		resp.Body.Close()
+	}
+}
@@ -0,0 +1,242 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// PrivateTokenHeader is the header of personal, project and group access tokens.
+	PrivateTokenHeader = "Private-Token"
+	// JobTokenHeader is the header of the CI_JOB_TOKEN of GitLab CI jobs.
+	JobTokenHeader = "Job-Token"
+	// DefaultTokenCommandTimeout is the time given to a token command to print the token.
+	DefaultTokenCommandTimeout = 30 * time.Second
+)
+
+var (
+	// ErrNoToken is returned by a TokenSource which has no token to provide.
+	ErrNoToken = errors.New("no token found")
+	// ErrInsecureTokenFile is returned when a token file can be read by other users.
+	ErrInsecureTokenFile = errors.New("token file is accessible by other users")
+)
+
+// Token is a GitLab API token with the header used to send it.
+type Token struct {
+	Value  string
+	Header string
+	// Source describes where the token comes from, for logging.
+	Source string
+}
+
+// TokenSource provides the token used to authenticate API requests.
+// A source without token returns ErrNoToken.
+type TokenSource interface {
+	Token() (Token, error)
+}
+
+type staticTokenSource struct {
+	token Token
+}
+
+func (s staticTokenSource) Token() (Token, error) {
+	if s.token.Value == "" {
+		return Token{}, ErrNoToken
+	}
+	return s.token, nil
+}
+
+// NewStaticTokenSource returns a source of an already known token.
+func NewStaticTokenSource(token Token) TokenSource {
+	return staticTokenSource{token: token}
+}
+
+type envTokenSource struct {
+	name string
+}
+
+// NewEnvTokenSource returns a source reading the token from an environment variable.
+func NewEnvTokenSource(name string) TokenSource {
+	return envTokenSource{name: name}
+}
+
+func (s envTokenSource) Token() (Token, error) {
+	value := strings.TrimSpace(os.Getenv(s.name))
+	if value == "" {
+		return Token{}, fmt.Errorf("%w in %s environment variable", ErrNoToken, s.name)
+	}
+	return Token{Value: value, Header: PrivateTokenHeader, Source: s.name + " environment variable"}, nil
+}
+
+type jobTokenSource struct{}
+
+// NewJobTokenSource returns a source reading the CI_JOB_TOKEN of a GitLab CI job.
+// The token is sent with the Job-Token header.
+func NewJobTokenSource() TokenSource {
+	return jobTokenSource{}
+}
+
+func (jobTokenSource) Token() (Token, error) {
+	value := strings.TrimSpace(os.Getenv("CI_JOB_TOKEN"))
+	if value == "" {
+		return Token{}, fmt.Errorf("%w in CI_JOB_TOKEN environment variable", ErrNoToken)
+	}
+	return Token{Value: value, Header: JobTokenHeader, Source: "CI_JOB_TOKEN environment variable"}, nil
+}
+
+type fileTokenSource struct {
+	path string
+}
+
+// NewFileTokenSource returns a source reading the token from a file.
+// The file must not be accessible by the group or other users.
+func NewFileTokenSource(path string) TokenSource {
+	return fileTokenSource{path: path}
+}
+
+func (s fileTokenSource) Token() (Token, error) {
+	stat, err := os.Stat(s.path)
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to read token file: %w", err)
+	}
+	if runtime.GOOS != "windows" && stat.Mode().Perm()&0o077 != 0 {
+		return Token{}, fmt.Errorf("%w: %s has mode %o, run chmod 600 %s",
+			ErrInsecureTokenFile, s.path, stat.Mode().Perm(), s.path)
+	}
+	content, err := os.ReadFile(s.path)
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to read token file: %w", err)
+	}
+	value := strings.TrimSpace(string(content))
+	if value == "" {
+		return Token{}, fmt.Errorf("%w: token file %s is empty", ErrNoToken, s.path)
+	}
+	return Token{Value: value, Header: PrivateTokenHeader, Source: "token file " + s.path}, nil
+}
+
+type commandTokenSource struct {
+	command string
+	timeout time.Duration
+}
+
+// NewCommandTokenSource returns a source running a shell command which prints
+// the token on its standard output, for instance "pass show gitlab/token".
+func NewCommandTokenSource(command string) TokenSource {
+	return commandTokenSource{command: command, timeout: DefaultTokenCommandTimeout}
+}
+
+func (s commandTokenSource) Token() (Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", s.command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", s.command)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return Token{}, fmt.Errorf("token command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	value := strings.TrimSpace(string(out))
+	if value == "" {
+		return Token{}, fmt.Errorf("%w: token command printed nothing", ErrNoToken)
+	}
+	return Token{Value: value, Header: PrivateTokenHeader, Source: "token command"}, nil
+}
+
+type glabTokenSource struct {
+	configFile string
+	host       string
+}
+
+// NewGlabTokenSource returns a source reading the token of host from the
+// configuration of the glab CLI. If configFile is empty, the default location
+// of the glab configuration is used.
+func NewGlabTokenSource(configFile, host string) TokenSource {
+	return glabTokenSource{configFile: configFile, host: host}
+}
+
+type glabConfig struct {
+	Hosts map[string]struct {
+		Token   string `yaml:"token"`
+		APIHost string `yaml:"api_host"`
+	} `yaml:"hosts"`
+}
+
+func (s glabTokenSource) Token() (Token, error) {
+	configFile := s.configFile
+	if configFile == "" {
+		configFile = glabConfigFile()
+	}
+	content, err := os.ReadFile(configFile) //nolint:gosec // configuration file of the user
+	if errors.Is(err, os.ErrNotExist) {
+		return Token{}, fmt.Errorf("%w: no glab configuration", ErrNoToken)
+	}
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to read glab configuration: %w", err)
+	}
+	var cfg glabConfig
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return Token{}, fmt.Errorf("failed to parse glab configuration %s: %w", configFile, err)
+	}
+	for name, host := range cfg.Hosts {
+		if !strings.EqualFold(name, s.host) && !strings.EqualFold(host.APIHost, s.host) {
+			continue
+		}
+		if host.Token == "" {
+			break
+		}
+		return Token{Value: host.Token, Header: PrivateTokenHeader, Source: "glab configuration " + configFile}, nil
+	}
+	return Token{}, fmt.Errorf("%w for %s in glab configuration", ErrNoToken, s.host)
+}
+
+// glabConfigFile returns the location of the glab configuration file.
+func glabConfigFile() string {
+	if dir := os.Getenv("GLAB_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "config.yml")
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "glab-cli", "config.yml")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "glab-cli", "config.yml")
+}
+
+type chainTokenSource []TokenSource
+
+// NewChainTokenSource returns a source trying the sources in order.
+// Sources without token are skipped; any other error stops the chain.
+func NewChainTokenSource(sources ...TokenSource) TokenSource {
+	return chainTokenSource(sources)
+}
+
+func (c chainTokenSource) Token() (Token, error) {
+	for _, s := range c {
+		token, err := s.Token()
+		if errors.Is(err, ErrNoToken) {
+			continue
+		}
+		return token, err
+	}
+	return Token{}, ErrNoToken
+}
+
+// DefaultTokenSource returns the source used by NewService: the GITLAB_TOKEN
+// environment variable, then the CI_JOB_TOKEN of GitLab CI jobs.
+func DefaultTokenSource() TokenSource {
+	return NewChainTokenSource(NewEnvTokenSource("GITLAB_TOKEN"), NewJobTokenSource())
+}
@@ -0,0 +1,135 @@
+package gitlab_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+func TestFileTokenSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	token, err := gitlab.NewFileTokenSource(path).Token()
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if token.Value != "secret" || token.Header != gitlab.PrivateTokenHeader {
+		t.Errorf("Token() = %+v, want secret sent with %s", token, gitlab.PrivateTokenHeader)
+	}
+
+	if err := os.Chmod(path, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = gitlab.NewFileTokenSource(path).Token()
+	if !errors.Is(err, gitlab.ErrInsecureTokenFile) {
+		t.Errorf("Token() error = %v, want %v", err, gitlab.ErrInsecureTokenFile)
+	}
+}
+
+func TestCommandTokenSource(t *testing.T) {
+	token, err := gitlab.NewCommandTokenSource("echo secret").Token()
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if token.Value != "secret" {
+		t.Errorf("Token() = %q, want secret", token.Value)
+	}
+	if _, err := gitlab.NewCommandTokenSource("exit 1").Token(); err == nil {
+		t.Errorf("Token() of a failing command returned no error")
+	}
+}
+
+func TestGlabTokenSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	config := `hosts:
+  gitlab.com:
+    token: gitlab-com-token
+  gitlab.example.com:
+    token: example-token
+    api_host: gitlab.example.com
+`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	token, err := gitlab.NewGlabTokenSource(path, "gitlab.example.com").Token()
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if token.Value != "example-token" {
+		t.Errorf("Token() = %q, want the token of gitlab.example.com", token.Value)
+	}
+	_, err = gitlab.NewGlabTokenSource(path, "other.example.com").Token()
+	if !errors.Is(err, gitlab.ErrNoToken) {
+		t.Errorf("Token() error = %v, want %v", err, gitlab.ErrNoToken)
+	}
+}
+
+func TestChainTokenSource(t *testing.T) {
+	t.Setenv("GITLAB_TOKEN", "")
+	t.Setenv("CI_JOB_TOKEN", "job")
+	token, err := gitlab.DefaultTokenSource().Token()
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if token.Value != "job" || token.Header != gitlab.JobTokenHeader {
+		t.Errorf("Token() = %+v, want the job token", token)
+	}
+
+	missing := gitlab.NewFileTokenSource(filepath.Join(t.TempDir(), "missing"))
+	_, err = gitlab.NewChainTokenSource(missing, gitlab.DefaultTokenSource()).Token()
+	if err == nil || errors.Is(err, gitlab.ErrNoToken) {
+		t.Errorf("Token() error = %v, want the error of the token file", err)
+	}
+}
+
+func TestService_JobToken(t *testing.T) {
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Job-Token") != "job" {
+				t.Errorf("Job-Token header = %q, want job", r.Header.Get("Job-Token"))
+			}
+			if r.Header.Get("Private-Token") != "" {
+				t.Errorf("Private-Token header should not be sent with a job token")
+			}
+		}))
+	defer ts.Close()
+
+	s := gitlab.NewService()
+	s.SetHTTPClient(ts.Client())
+	s.SetGitlabEndpoint(ts.URL)
+	s.SetTokenSource(gitlab.NewStaticTokenSource(gitlab.Token{Value: "job", Header: gitlab.JobTokenHeader}))
+
+	resp, err := s.Get("projects/1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+func TestService_Anonymous(t *testing.T) {
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Private-Token") != "" || r.Header.Get("Job-Token") != "" {
+				t.Errorf("no token should be sent")
+			}
+		}))
+	defer ts.Close()
+
+	s := gitlab.NewService()
+	s.SetHTTPClient(ts.Client())
+	s.SetGitlabEndpoint(ts.URL)
+	s.SetTokenSource(gitlab.NewChainTokenSource())
+
+	resp, err := s.Get("projects/1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	_ = resp.Body.Close()
+}
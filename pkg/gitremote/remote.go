@@ -0,0 +1,85 @@
+// Package gitremote finds the git repository of a directory and parses the
+// URL of its remotes into the namespace path of the project.
+package gitremote
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidRemote is returned when a remote URL cannot be parsed.
+var ErrInvalidRemote = errors.New("invalid remote URL")
+
+// Remote is a parsed remote URL.
+type Remote struct {
+	// Host is the host name, without port and user.
+	Host string
+	// Port is the port given in the URL, if any.
+	Port string
+	// Path is the namespace path of the project, for instance "group/subgroup/project".
+	Path string
+}
+
+// Parse parses the URL of a remote. SSH ("ssh://git@host:2222/group/project.git"),
+// scp-like ("git@host:group/project.git"), HTTP(S) and git URLs are supported.
+// Only a trailing ".git" is removed from the path: "group/foo.github" is kept as is.
+func Parse(rawURL string) (Remote, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return Remote{}, fmt.Errorf("%w: empty URL", ErrInvalidRemote)
+	}
+	if isSCPLike(rawURL) {
+		return parseSCPLike(rawURL)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Remote{}, fmt.Errorf("%w: %w", ErrInvalidRemote, err)
+	}
+	switch u.Scheme {
+	case "ssh", "git+ssh", "ssh+git", "http", "https", "git":
+	default:
+		return Remote{}, fmt.Errorf("%w: unsupported scheme %q in %s", ErrInvalidRemote, u.Scheme, rawURL)
+	}
+	return newRemote(rawURL, u.Hostname(), u.Port(), u.Path)
+}
+
+// isSCPLike reports whether the URL has the scp-like syntax [user@]host:path.
+// As git does, a colon after the first slash means a local path.
+func isSCPLike(rawURL string) bool {
+	if strings.Contains(rawURL, "://") {
+		return false
+	}
+	colon := strings.Index(rawURL, ":")
+	slash := strings.Index(rawURL, "/")
+	return colon > 0 && (slash < 0 || colon < slash)
+}
+
+func parseSCPLike(rawURL string) (Remote, error) {
+	hostPart, path, _ := strings.Cut(rawURL, ":")
+	if at := strings.LastIndex(hostPart, "@"); at >= 0 {
+		hostPart = hostPart[at+1:]
+	}
+	return newRemote(rawURL, hostPart, "", path)
+}
+
+func newRemote(rawURL, host, port, path string) (Remote, error) {
+	path = strings.Trim(path, "/")
+	path = strings.TrimSuffix(path, ".git")
+	path = strings.TrimSuffix(path, "/")
+	if host == "" || path == "" {
+		return Remote{}, fmt.Errorf("%w: %s", ErrInvalidRemote, rawURL)
+	}
+	return Remote{Host: strings.ToLower(host), Port: port, Path: path}, nil
+}
+
+// TrimBasePath removes the relative URL root of a GitLab installed under a
+// subpath, for instance "gitlab" for https://example.com/gitlab.
+func (r Remote) TrimBasePath(basePath string) Remote {
+	basePath = strings.Trim(basePath, "/")
+	if basePath != "" {
+		r.Path = strings.TrimPrefix(r.Path, basePath+"/")
+	}
+	return r
+}
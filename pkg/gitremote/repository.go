@@ -0,0 +1,96 @@
+package gitremote
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/ini.v1"
+)
+
+var (
+	// ErrGitNotFound is returned when no git repository contains the directory.
+	ErrGitNotFound = errors.New(".git not found")
+	// ErrRemoteNotFound is returned when the repository has no remote with the requested name.
+	ErrRemoteNotFound = errors.New("remote not found")
+)
+
+// Repository is a git repository found on disk.
+type Repository struct {
+	// Root is the working tree containing the .git directory or file.
+	Root string
+	// GitDir is the git directory of the working tree. For worktrees and
+	// submodules, it is the directory the .git file points to.
+	GitDir string
+	// CommonDir is the git directory holding the configuration. It differs
+	// from GitDir for linked worktrees.
+	CommonDir string
+}
+
+// FindRepository returns the repository containing dir, looking in its parents.
+// A .git directory, and a .git file as created for worktrees and submodules, are supported.
+func FindRepository(dir string) (Repository, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return Repository{}, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		stat, err := os.Stat(dotGit)
+		if err == nil {
+			if stat.IsDir() {
+				return Repository{Root: dir, GitDir: dotGit, CommonDir: dotGit}, nil
+			}
+			return readGitFile(dir, dotGit)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return Repository{}, ErrGitNotFound
+		}
+		dir = parent
+	}
+}
+
+// readGitFile follows a .git file containing "gitdir: <path>".
+func readGitFile(root, dotGit string) (Repository, error) {
+	content, err := os.ReadFile(dotGit) //nolint:gosec // path of a .git file found by FindRepository
+	if err != nil {
+		return Repository{}, fmt.Errorf("failed to read %s: %w", dotGit, err)
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir:")
+	if !ok {
+		return Repository{}, fmt.Errorf("%w: invalid .git file %s", ErrGitNotFound, dotGit)
+	}
+	gitDir = resolvePath(root, strings.TrimSpace(gitDir))
+	repo := Repository{Root: root, GitDir: gitDir, CommonDir: gitDir}
+
+	// linked worktrees share the configuration of the main repository
+	commonDir, err := os.ReadFile(filepath.Join(gitDir, "commondir")) //nolint:gosec // path inside the git directory
+	if err == nil {
+		repo.CommonDir = resolvePath(gitDir, strings.TrimSpace(string(commonDir)))
+	}
+	return repo, nil
+}
+
+func resolvePath(base, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(base, path)
+}
+
+// RemoteURL returns the URL of the remote with the given name, "origin" for instance.
+func (r Repository) RemoteURL(name string) (string, error) {
+	configFile := filepath.Join(r.CommonDir, "config")
+	cfg, err := ini.Load(configFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", configFile, err)
+	}
+	section, err := cfg.GetSection(fmt.Sprintf("remote %q", name))
+	if err != nil || section.Key("url").String() == "" {
+		return "", fmt.Errorf("%w: %s", ErrRemoteNotFound, name)
+	}
+	return section.Key("url").String(), nil
+}
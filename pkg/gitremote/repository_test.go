@@ -0,0 +1,100 @@
+package gitremote_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitremote"
+)
+
+const gitConfig = `[core]
+	bare = false
+[remote "origin"]
+	url = git@gitlab.com:group/project.git
+	fetch = +refs/heads/*:refs/remotes/origin/*
+[remote "upstream"]
+	url = https://gitlab.com/upstream/project.git
+`
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindRepository(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".git", "config"), gitConfig)
+	sub := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(sub, 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := gitremote.FindRepository(sub)
+	if err != nil {
+		t.Fatalf("FindRepository() error = %v", err)
+	}
+	if repo.Root != root {
+		t.Errorf("FindRepository() root = %s, want %s", repo.Root, root)
+	}
+	url, err := repo.RemoteURL("upstream")
+	if err != nil {
+		t.Fatalf("RemoteURL() error = %v", err)
+	}
+	if url != "https://gitlab.com/upstream/project.git" {
+		t.Errorf("RemoteURL() = %s", url)
+	}
+	_, err = repo.RemoteURL("fork")
+	if !errors.Is(err, gitremote.ErrRemoteNotFound) {
+		t.Errorf("RemoteURL() error = %v, want %v", err, gitremote.ErrRemoteNotFound)
+	}
+}
+
+func TestFindRepositoryWorktree(t *testing.T) {
+	main := t.TempDir()
+	writeFile(t, filepath.Join(main, ".git", "config"), gitConfig)
+	writeFile(t, filepath.Join(main, ".git", "worktrees", "feature", "commondir"), "../..\n")
+	worktree := t.TempDir()
+	writeFile(t, filepath.Join(worktree, ".git"), "gitdir: "+filepath.Join(main, ".git", "worktrees", "feature")+"\n")
+
+	repo, err := gitremote.FindRepository(worktree)
+	if err != nil {
+		t.Fatalf("FindRepository() error = %v", err)
+	}
+	if repo.CommonDir != filepath.Join(main, ".git") {
+		t.Errorf("FindRepository() common dir = %s, want %s", repo.CommonDir, filepath.Join(main, ".git"))
+	}
+	url, err := repo.RemoteURL("origin")
+	if err != nil {
+		t.Fatalf("RemoteURL() error = %v", err)
+	}
+	if url != "git@gitlab.com:group/project.git" {
+		t.Errorf("RemoteURL() = %s", url)
+	}
+}
+
+func TestFindRepositorySubmodule(t *testing.T) {
+	super := t.TempDir()
+	writeFile(t, filepath.Join(super, ".git", "config"), gitConfig)
+	writeFile(t, filepath.Join(super, ".git", "modules", "lib", "config"),
+		"[remote \"origin\"]\n\turl = ssh://git@gitlab.com:2222/group/lib.git\n")
+	writeFile(t, filepath.Join(super, "lib", ".git"), "gitdir: ../.git/modules/lib\n")
+
+	repo, err := gitremote.FindRepository(filepath.Join(super, "lib"))
+	if err != nil {
+		t.Fatalf("FindRepository() error = %v", err)
+	}
+	url, err := repo.RemoteURL("origin")
+	if err != nil {
+		t.Fatalf("RemoteURL() error = %v", err)
+	}
+	if url != "ssh://git@gitlab.com:2222/group/lib.git" {
+		t.Errorf("RemoteURL() = %s, want the URL of the submodule", url)
+	}
+}
@@ -0,0 +1,68 @@
+package graphissues
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-analyze/charts"
+)
+
+// CreateOpenIssueAgesGraph creates a stacked area graph of the open issues per
+// age, the oldest at the bottom: its top is the number of open issues.
+func CreateOpenIssueAgesGraph(
+	graphFilePath string,
+	underWeek []float64,
+	weekToMonth []float64,
+	monthToQuarter []float64,
+	quarterToYear []float64,
+	overYear []float64,
+	dateExecSeries []time.Time,
+) error {
+	seriesCount := len(dateExecSeries)
+	if len(underWeek) != seriesCount || len(weekToMonth) != seriesCount || len(monthToQuarter) != seriesCount ||
+		len(quarterToYear) != seriesCount || len(overYear) != seriesCount {
+		return ErrAllSeriesLengthMismatch
+	}
+
+	labels := make([]string, 0, seriesCount)
+	for r := range dateExecSeries {
+		labels = append(labels, dateExecSeries[r].Format("2006-01"))
+	}
+
+	values := [][]float64{
+		overYear,
+		quarterToYear,
+		monthToQuarter,
+		weekToMonth,
+		underWeek,
+	}
+
+	opt := charts.NewLineChartOptionWithData(values)
+	opt.Title = charts.TitleOption{Text: "GitLab Open Issues Age"}
+	opt.XAxis.Labels = labels
+	opt.StackSeries = charts.Ptr(true)
+	opt.Legend = charts.LegendOption{
+		SeriesNames: []string{
+			"Over a Year",
+			"90 to 365 Days",
+			"30 to 90 Days",
+			"7 to 30 Days",
+			"Under 7 Days",
+		},
+		Offset: charts.OffsetCenter,
+	}
+
+	p := charts.NewPainter(charts.PainterOptions{
+		Width:  defaultWidth,
+		Height: defaultHeight,
+	})
+	err := p.LineChart(opt)
+	if err != nil {
+		return fmt.Errorf("failed to render line chart: %w", err)
+	}
+	buf, err := p.Bytes()
+	if err != nil {
+		return fmt.Errorf("failed to get chart bytes: %w", err)
+	}
+	return writeFile(graphFilePath, buf)
+}
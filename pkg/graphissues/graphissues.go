@@ -18,6 +18,9 @@ const (
 	filePerm      = 0o600
 )
 
+// reconstructedNote explains the mark of the periods with reconstructed snapshots.
+const reconstructedNote = "* period including snapshots reconstructed from the history of the issues (-backfill)"
+
 var (
 	// ErrSeriesLengthMismatch is returned when input series have different lengths.
 	ErrSeriesLengthMismatch    = errors.New("openedSerie, closedSerie and dateExecSerie should have the same length")
@@ -63,7 +66,8 @@ func CreateGraph(graphFilePath string, openedSerie []float64, closedSerie []floa
 }
 
 // CreateEnhancedGraph creates a graph with 4 series: total opened, opened during period,
-// closed during period, and velocity.
+// closed during period, and velocity. The periods flagged in reconstructedSeries,
+// which may be nil, are marked as reconstructed.
 func CreateEnhancedGraph(
 	graphFilePath string,
 	totalOpenedSeries []float64,
@@ -71,18 +75,17 @@ func CreateEnhancedGraph(
 	closedDuringPeriod []float64,
 	velocitySeries []float64,
 	dateExecSeries []time.Time,
+	reconstructedSeries []bool,
 ) error {
 	// Validate all series have the same length BEFORE using them
 	seriesCount := len(totalOpenedSeries)
 	if len(openedDuringPeriod) != seriesCount || len(closedDuringPeriod) != seriesCount || 
-	   len(velocitySeries) != seriesCount || len(dateExecSeries) != seriesCount {
+	   len(velocitySeries) != seriesCount || len(dateExecSeries) != seriesCount ||
+	   (reconstructedSeries != nil && len(reconstructedSeries) != seriesCount) {
 		return ErrAllSeriesLengthMismatch
 	}
 	
-	labels := make([]string, 0, len(totalOpenedSeries))
-	for r := range totalOpenedSeries {
-		labels = append(labels, dateExecSeries[r].Format("2006-01"))
-	}
+	labels, note := periodLabels(dateExecSeries, reconstructedSeries)
 
 	values := [][]float64{
 		totalOpenedSeries,
@@ -92,7 +95,7 @@ func CreateEnhancedGraph(
 	}
 	
 	opt := charts.NewLineChartOptionWithData(values)
-	opt.Title = charts.TitleOption{Text: "GitLab Issues Statistics"}
+	opt.Title = charts.TitleOption{Text: "GitLab Issues Statistics", Subtext: note}
 	opt.XAxis.Labels = labels
 	opt.Legend = charts.LegendOption{
 		SeriesNames: []string{
@@ -119,6 +122,76 @@ func CreateEnhancedGraph(
 	return writeFile(graphFilePath, buf)
 }
 
+// CreateMergeRequestGraph creates a graph with 3 series: currently open merge requests,
+// merge requests merged during period and merge requests closed during period.
+func CreateMergeRequestGraph(
+	graphFilePath string,
+	openedSeries []float64,
+	mergedDuringPeriod []float64,
+	closedDuringPeriod []float64,
+	dateExecSeries []time.Time,
+) error {
+	seriesCount := len(openedSeries)
+	if len(mergedDuringPeriod) != seriesCount || len(closedDuringPeriod) != seriesCount ||
+		len(dateExecSeries) != seriesCount {
+		return ErrAllSeriesLengthMismatch
+	}
+
+	labels := make([]string, 0, seriesCount)
+	for r := range openedSeries {
+		labels = append(labels, dateExecSeries[r].Format("2006-01"))
+	}
+
+	values := [][]float64{
+		openedSeries,
+		mergedDuringPeriod,
+		closedDuringPeriod,
+	}
+
+	opt := charts.NewLineChartOptionWithData(values)
+	opt.Title = charts.TitleOption{Text: "GitLab Merge Requests Statistics"}
+	opt.XAxis.Labels = labels
+	opt.Legend = charts.LegendOption{
+		SeriesNames: []string{
+			"Currently Open Merge Requests",
+			"Merged This Period",
+			"Closed This Period",
+		},
+		Offset: charts.OffsetCenter,
+	}
+
+	p := charts.NewPainter(charts.PainterOptions{
+		Width:  defaultWidth,
+		Height: defaultHeight,
+	})
+	err := p.LineChart(opt)
+	if err != nil {
+		return fmt.Errorf("failed to render line chart: %w", err)
+	}
+	buf, err := p.Bytes()
+	if err != nil {
+		return fmt.Errorf("failed to get chart bytes: %w", err)
+	}
+	return writeFile(graphFilePath, buf)
+}
+
+// periodLabels returns the labels of the periods, with an asterisk on those
+// including reconstructed snapshots, and the note explaining the asterisk if
+// there is one.
+func periodLabels(dates []time.Time, reconstructed []bool) ([]string, string) {
+	labels := make([]string, 0, len(dates))
+	note := ""
+	for i, date := range dates {
+		label := date.Format("2006-01")
+		if i < len(reconstructed) && reconstructed[i] {
+			label += "*"
+			note = reconstructedNote
+		}
+		labels = append(labels, label)
+	}
+	return labels, note
+}
+
 func writeFile(filename string, buf []byte) error {
 	tmpPath := filepath.Dir(filename)
 	err := os.MkdirAll(tmpPath, dirPerm)
@@ -0,0 +1,99 @@
+package graphissues
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-analyze/charts"
+)
+
+// LeadTimeSeries is a percentile of the lead time of the issues, in days,
+// per period. The periods without closed issues are NaN.
+type LeadTimeSeries struct {
+	Name string
+	Days []float64
+}
+
+// CreateLeadTimeGraph creates a graph with the backlog series, currently open
+// issues, opened and closed during period, on the left axis and the lead time
+// series, in days, on the right axis. The periods flagged in
+// reconstructedSeries, which may be nil, are marked as reconstructed.
+func CreateLeadTimeGraph(
+	graphFilePath string,
+	totalOpenedSeries []float64,
+	openedDuringPeriod []float64,
+	closedDuringPeriod []float64,
+	leadTimes []LeadTimeSeries,
+	dateExecSeries []time.Time,
+	reconstructedSeries []bool,
+) error {
+	seriesCount := len(totalOpenedSeries)
+	if len(openedDuringPeriod) != seriesCount || len(closedDuringPeriod) != seriesCount ||
+		len(dateExecSeries) != seriesCount ||
+		(reconstructedSeries != nil && len(reconstructedSeries) != seriesCount) {
+		return ErrAllSeriesLengthMismatch
+	}
+	for _, leadTime := range leadTimes {
+		if len(leadTime.Days) != seriesCount {
+			return ErrAllSeriesLengthMismatch
+		}
+	}
+
+	labels, note := periodLabels(dateExecSeries, reconstructedSeries)
+
+	values := [][]float64{
+		totalOpenedSeries,
+		openedDuringPeriod,
+		closedDuringPeriod,
+	}
+	names := []string{
+		"Currently Open Issues",
+		"Issues Opened This Period",
+		"Issues Closed This Period",
+	}
+	backlogCount := len(values)
+	for _, leadTime := range leadTimes {
+		days := make([]float64, 0, seriesCount)
+		for _, d := range leadTime.Days {
+			if math.IsNaN(d) {
+				d = charts.GetNullValue()
+			}
+			days = append(days, d)
+		}
+		values = append(values, days)
+		names = append(names, leadTime.Name)
+	}
+
+	seriesList := charts.NewSeriesListLine(values)
+	for i := backlogCount; i < len(seriesList); i++ {
+		seriesList[i].YAxisIndex = 1
+	}
+	opt := charts.NewLineChartOptionWithSeries(seriesList)
+	opt.Title = charts.TitleOption{Text: "GitLab Issues Lead Time", Subtext: note}
+	opt.XAxis.Labels = labels
+	opt.Legend = charts.LegendOption{
+		SeriesNames: names,
+		Offset:      charts.OffsetCenter,
+	}
+	opt.YAxis[0].Title = "issues"
+	if len(opt.YAxis) > 1 {
+		opt.YAxis[1].Title = "days"
+		opt.YAxis[0].Theme = opt.Theme.WithYAxisSeriesColor(0)
+		opt.YAxis[1].Theme = opt.Theme.WithYAxisSeriesColor(backlogCount)
+	}
+
+	p := charts.NewPainter(charts.PainterOptions{
+		Width:  defaultWidth,
+		Height: defaultHeight,
+	})
+	err := p.LineChart(opt)
+	if err != nil {
+		return fmt.Errorf("failed to render line chart: %w", err)
+	}
+	buf, err := p.Bytes()
+	if err != nil {
+		return fmt.Errorf("failed to get chart bytes: %w", err)
+	}
+	return writeFile(graphFilePath, buf)
+}
@@ -0,0 +1,96 @@
+// Package leadtime computes the distribution of the lead time of the issues,
+// the time from their creation to their closing, per month of closing.
+//
+// Only the lead time is computed: GitLab does not record when the work on an
+// issue started, so the cycle time, from the start of the work to the
+// closing, is not available.
+package leadtime
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+const hoursPerDay = 24
+
+// Percentiles is the distribution of the lead time, in days, of the issues
+// closed during a month, of all the issues or of those of a label.
+type Percentiles struct {
+	// Month is the first day of the month.
+	Month time.Time
+	// Label is empty for all the issues.
+	Label  string
+	Closed int
+	P50    float64
+	P85    float64
+	P95    float64
+}
+
+// Monthly returns the percentiles of the lead time of the issues closed from
+// the month of from to the month of to included, in the location of from,
+// ordered by month then label. Months without closed issues are omitted. With
+// byLabel, the percentiles of the issues of each label are returned as well.
+func Monthly(issues []gitlab.Issue, from time.Time, to time.Time, byLabel bool) []Percentiles {
+	first := monthOf(from, from.Location())
+	next := monthOf(to, from.Location()).AddDate(0, 1, 0)
+
+	type key struct {
+		month time.Time
+		label string
+	}
+	days := make(map[key][]float64)
+	for _, issue := range issues {
+		if issue.ClosedAt == nil || issue.ClosedAt.Before(first) || !issue.ClosedAt.Before(next) {
+			continue
+		}
+		month := monthOf(*issue.ClosedAt, from.Location())
+		leadTime := max(0, issue.ClosedAt.Sub(issue.CreatedAt).Hours()/hoursPerDay)
+		days[key{month: month, label: ""}] = append(days[key{month: month, label: ""}], leadTime)
+		if byLabel {
+			for _, label := range issue.Labels {
+				days[key{month: month, label: label}] = append(days[key{month: month, label: label}], leadTime)
+			}
+		}
+	}
+
+	result := make([]Percentiles, 0, len(days))
+	for k, values := range days {
+		slices.Sort(values)
+		result = append(result, Percentiles{
+			Month:  k.month,
+			Label:  k.label,
+			Closed: len(values),
+			P50:    Percentile(values, 50),
+			P85:    Percentile(values, 85),
+			P95:    Percentile(values, 95),
+		})
+	}
+	slices.SortFunc(result, func(a, b Percentiles) int {
+		if c := a.Month.Compare(b.Month); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Label, b.Label)
+	})
+	return result
+}
+
+// monthOf returns the first day of the month of the date in the location.
+func monthOf(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+}
+
+// Percentile returns the p-th percentile of the sorted values with the
+// nearest-rank method: the smallest value greater than or equal to p percent
+// of the values. It returns 0 without values.
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
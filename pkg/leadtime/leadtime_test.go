@@ -0,0 +1,71 @@
+package leadtime_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+	"github.com/sgaunet/gitlab-stats/pkg/leadtime"
+)
+
+func TestPercentile(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		p    float64
+		want float64
+	}{
+		{50, 5},
+		{85, 9},
+		{95, 10},
+		{0, 1},
+		{100, 10},
+	}
+	for _, tt := range tests {
+		if got := leadtime.Percentile(values, tt.p); got != tt.want {
+			t.Errorf("Percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if got := leadtime.Percentile(nil, 50); got != 0 {
+		t.Errorf("Percentile() without values = %v, want 0", got)
+	}
+}
+
+func TestMonthly(t *testing.T) {
+	created := time.Date(2024, 2, 20, 0, 0, 0, 0, time.UTC)
+	closedAfter := func(days int) *time.Time {
+		d := created.AddDate(0, 0, days)
+		return &d
+	}
+	issues := []gitlab.Issue{
+		{State: "closed", Labels: []string{"bug"}, CreatedAt: created, ClosedAt: closedAfter(1)},
+		{State: "closed", Labels: []string{"bug", "ui"}, CreatedAt: created, ClosedAt: closedAfter(10)},
+		{State: "closed", CreatedAt: created, ClosedAt: closedAfter(2)},
+		{State: "closed", Labels: []string{"ui"}, CreatedAt: created, ClosedAt: closedAfter(20)},
+		// open, and closed before the range
+		{State: "opened", Labels: []string{"bug"}, CreatedAt: created},
+		{State: "closed", CreatedAt: created.AddDate(0, -2, 0), ClosedAt: closedAfter(-30)},
+	}
+	february := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	march := february.AddDate(0, 1, 0)
+
+	got := leadtime.Monthly(issues, february, march.AddDate(0, 0, 10), true)
+	want := []leadtime.Percentiles{
+		{Month: february, Closed: 2, P50: 1, P85: 2, P95: 2},
+		{Month: february, Label: "bug", Closed: 1, P50: 1, P85: 1, P95: 1},
+		{Month: march, Closed: 2, P50: 10, P85: 20, P95: 20},
+		{Month: march, Label: "bug", Closed: 1, P50: 10, P85: 10, P95: 10},
+		{Month: march, Label: "ui", Closed: 2, P50: 10, P85: 20, P95: 20},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Monthly() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Monthly()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if got := leadtime.Monthly(issues, march, march, false); len(got) != 1 || got[0].Label != "" {
+		t.Errorf("Monthly() without labels = %+v, want the percentiles of all the issues of March", got)
+	}
+}
@@ -0,0 +1,80 @@
+// Package provider abstracts the forges from which statistics are collected.
+// A Provider is a fetcher of the gitlab package bound to a forge instance,
+// identified by its kind and base URL in the database.
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+)
+
+// ErrUnknownKind is returned when parsing an unsupported forge type.
+var ErrUnknownKind = errors.New("unknown provider")
+
+// Kind is the type of forge of a provider.
+type Kind string
+
+// Supported forges.
+const (
+	GitLab  Kind = "gitlab"
+	GitHub  Kind = "github"
+	Gitea   Kind = "gitea"
+	Forgejo Kind = "forgejo"
+)
+
+// Kinds returns the supported forges.
+func Kinds() []Kind {
+	return []Kind{GitLab, GitHub, Gitea, Forgejo}
+}
+
+// ParseKind returns the kind of forge with the given name, case insensitively.
+func ParseKind(name string) (Kind, error) {
+	for _, kind := range Kinds() {
+		if strings.EqualFold(name, string(kind)) {
+			return kind, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownKind, name)
+}
+
+// Provider fetches the statistics and metadata of a forge instance.
+type Provider interface {
+	gitlab.Fetcher
+	// Kind returns the type of forge.
+	Kind() Kind
+	// BaseURL returns the URL of the instance, for instance https://github.com.
+	BaseURL() string
+}
+
+type provider struct {
+	gitlab.Fetcher
+	kind    Kind
+	baseURL string
+}
+
+// New returns the provider of the forge instance at baseURL, served by the fetcher.
+func New(kind Kind, baseURL string, fetcher gitlab.Fetcher) Provider {
+	return &provider{Fetcher: fetcher, kind: kind, baseURL: baseURL}
+}
+
+// IssueLister returns the fetcher of the provider as an issue lister, when
+// its forge client can list issues, so that its rate limiter and retries are shared.
+func IssueLister(p Provider) (gitlab.IssueLister, bool) {
+	if pp, ok := p.(*provider); ok {
+		l, ok := pp.Fetcher.(gitlab.IssueLister)
+		return l, ok
+	}
+	l, ok := p.(gitlab.IssueLister)
+	return l, ok
+}
+
+func (p *provider) Kind() Kind {
+	return p.kind
+}
+
+func (p *provider) BaseURL() string {
+	return p.baseURL
+}
@@ -0,0 +1,36 @@
+package provider_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sgaunet/gitlab-stats/pkg/gitlab"
+	"github.com/sgaunet/gitlab-stats/pkg/provider"
+)
+
+func TestParseKind(t *testing.T) {
+	for name, want := range map[string]provider.Kind{
+		"gitlab":  provider.GitLab,
+		"GitHub":  provider.GitHub,
+		"gitea":   provider.Gitea,
+		"Forgejo": provider.Forgejo,
+	} {
+		got, err := provider.ParseKind(name)
+		if err != nil || got != want {
+			t.Errorf("ParseKind(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := provider.ParseKind("svn"); !errors.Is(err, provider.ErrUnknownKind) {
+		t.Errorf("ParseKind(svn) error = %v, want %v", err, provider.ErrUnknownKind)
+	}
+}
+
+func TestNew(t *testing.T) {
+	p := provider.New(provider.GitLab, "https://gitlab.example.com", gitlab.NewService())
+	if p.Kind() != provider.GitLab || p.BaseURL() != "https://gitlab.example.com" {
+		t.Errorf("New() = %s %s", p.Kind(), p.BaseURL())
+	}
+	if _, ok := provider.IssueLister(p); !ok {
+		t.Errorf("IssueLister() of a GitLab provider = false, want true")
+	}
+}
@@ -0,0 +1,156 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sgaunet/gitlab-stats/internal/database"
+)
+
+// ErrUnsupportedEntityType is returned for statistics of another entity than a project or a group.
+var ErrUnsupportedEntityType = errors.New("unsupported entity type")
+
+// Snapshot is the number of issues of an entity at a date.
+type Snapshot struct {
+	Opened   int64
+	Closed   int64
+	Total    int64
+	DateExec time.Time
+}
+
+type storedSnapshot struct {
+	id            int64
+	dateExec      time.Time
+	reconstructed bool
+}
+
+// AddReconstructedStats stores snapshots rebuilt from the history of the
+// issues of the project or group with the given key, marked as reconstructed.
+// The reconstructed snapshots of the same days are replaced, so that
+// backfilling a range again is idempotent. Live snapshots are kept: no
+// snapshot is stored for a day which already has one. It returns the number
+// of stored snapshots.
+func (s *Storage) AddReconstructedStats(
+	ctx context.Context,
+	entityType string,
+	key int64,
+	snapshots []Snapshot,
+) (int, error) {
+	added := 0
+	err := s.withTx(ctx, func(q *database.Queries) error {
+		stored, err := snapshotsOf(ctx, q, entityType, key)
+		if err != nil {
+			return err
+		}
+		days := make(map[string]bool, len(snapshots))
+		for _, snapshot := range snapshots {
+			days[dayOf(snapshot.DateExec)] = true
+		}
+		live := make(map[string]bool)
+		for _, snapshot := range stored {
+			day := dayOf(snapshot.dateExec)
+			switch {
+			case !snapshot.reconstructed:
+				live[day] = true
+			case days[day]:
+				if err := deleteSnapshot(ctx, q, entityType, snapshot.id); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, snapshot := range snapshots {
+			if live[dayOf(snapshot.DateExec)] {
+				continue
+			}
+			statsID, err := q.InsertReconstructedStats(ctx, database.InsertReconstructedStatsParams{
+				Total:    snapshot.Total,
+				Closed:   snapshot.Closed,
+				Opened:   snapshot.Opened,
+				DateExec: snapshot.DateExec,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to insert reconstructed stats: %w", err)
+			}
+			if err := linkSnapshot(ctx, q, entityType, key, statsID); err != nil {
+				return err
+			}
+			added++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+// snapshotsOf returns the snapshots of the entity with the given key, which must exist.
+func snapshotsOf(ctx context.Context, q *database.Queries, entityType string, key int64) ([]storedSnapshot, error) {
+	var snapshots []storedSnapshot
+	switch entityType {
+	case EntityProject:
+		if err := checkProject(ctx, q, key); err != nil {
+			return nil, err
+		}
+		rows, err := q.GetSnapshotsOfProject(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get stats of project: %w", err)
+		}
+		for _, row := range rows {
+			snapshots = append(snapshots, storedSnapshot{id: row.ID, dateExec: row.DateExec, reconstructed: row.Reconstructed})
+		}
+	case EntityGroup:
+		if err := checkGroup(ctx, q, key); err != nil {
+			return nil, err
+		}
+		rows, err := q.GetSnapshotsOfGroup(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get stats of group: %w", err)
+		}
+		for _, row := range rows {
+			snapshots = append(snapshots, storedSnapshot{id: row.ID, dateExec: row.DateExec, reconstructed: row.Reconstructed})
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedEntityType, entityType)
+	}
+	return snapshots, nil
+}
+
+func linkSnapshot(ctx context.Context, q *database.Queries, entityType string, key int64, statsID int64) error {
+	if entityType == EntityProject {
+		_, err := q.InsertStatsProjects(ctx, database.InsertStatsProjectsParams{Statsid: statsID, Projectid: key})
+		if err != nil {
+			return fmt.Errorf("failed to insert stats projects: %w", err)
+		}
+		return nil
+	}
+	_, err := q.InsertStatsGroups(ctx, database.InsertStatsGroupsParams{Statsid: statsID, Groupid: key})
+	if err != nil {
+		return fmt.Errorf("failed to insert stats groups: %w", err)
+	}
+	return nil
+}
+
+func deleteSnapshot(ctx context.Context, q *database.Queries, entityType string, statsID int64) error {
+	var err error
+	if entityType == EntityProject {
+		err = q.DeleteStatsProjectsOfStats(ctx, statsID)
+	} else {
+		err = q.DeleteStatsGroupsOfStats(ctx, statsID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unlink stats: %w", err)
+	}
+	if err := q.DeleteStats(ctx, statsID); err != nil {
+		return fmt.Errorf("failed to delete stats: %w", err)
+	}
+	return nil
+}
+
+// dayOf returns the local day of the date of a snapshot.
+func dayOf(t time.Time) string {
+	return t.In(time.Local).Format(time.DateOnly)
+}
@@ -0,0 +1,79 @@
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang-module/carbon/v2"
+	"github.com/sgaunet/gitlab-stats/pkg/storage/sqlite"
+)
+
+func TestAddReconstructedStats(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	key, err := s.ProjectKey(ctx, 0, 42)
+	if err != nil {
+		t.Fatalf("err returned by ProjectKey(): %v", err.Error())
+	}
+	day := carbon.Now().SubMonth().StartOfMonth()
+	// live snapshot of the third day
+	if err := s.AddProjectStatsWithContext(ctx, 0, 42, 4, 3, 7, day.Copy().AddDays(2).AddHour()); err != nil {
+		t.Fatalf("err returned by AddProjectStatsWithContext(): %v", err.Error())
+	}
+	snapshots := func(opened ...int64) []sqlite.Snapshot {
+		result := make([]sqlite.Snapshot, 0, len(opened))
+		for i, o := range opened {
+			result = append(result, sqlite.Snapshot{
+				Opened:   o,
+				Closed:   1,
+				Total:    o + 1,
+				DateExec: day.Copy().AddDays(i).EndOfDay().StdTime(),
+			})
+		}
+		return result
+	}
+
+	added, err := s.AddReconstructedStats(ctx, sqlite.EntityProject, key, snapshots(10, 12, 50))
+	if err != nil {
+		t.Fatalf("err returned by AddReconstructedStats(): %v", err.Error())
+	}
+	if added != 2 {
+		t.Errorf("AddReconstructedStats() = %d, want 2: the live snapshot is kept", added)
+	}
+	// backfilling again replaces the reconstructed snapshots
+	added, err = s.AddReconstructedStats(ctx, sqlite.EntityProject, key, snapshots(2, 3, 50))
+	if err != nil {
+		t.Fatalf("err returned by AddReconstructedStats(): %v", err.Error())
+	}
+	if added != 2 {
+		t.Errorf("AddReconstructedStats() = %d, want 2", added)
+	}
+
+	stats, err := s.GetEnhancedStatsByProjectID(key, day, day.Copy().EndOfMonth())
+	if err != nil {
+		t.Fatalf("err returned by GetEnhancedStatsByProjectID(): %v", err.Error())
+	}
+	if len(stats.TotalOpenedSeries) != 1 || stats.TotalOpenedSeries[0] != 4 {
+		t.Errorf("opened issues of the month = %v, want [4] of the live snapshot", stats.TotalOpenedSeries)
+	}
+	if len(stats.ReconstructedSeries) != 1 || !stats.ReconstructedSeries[0] {
+		t.Errorf("reconstructed months = %v, want [true]", stats.ReconstructedSeries)
+	}
+	if err := s.AddProjectStatsWithContext(ctx, 0, 43, 1, 0, 1, day); err != nil {
+		t.Fatalf("err returned by AddProjectStatsWithContext(): %v", err.Error())
+	}
+	other, err := s.ProjectKey(ctx, 0, 43)
+	if err != nil {
+		t.Fatalf("err returned by ProjectKey(): %v", err.Error())
+	}
+	stats, err = s.GetEnhancedStatsByProjectID(other, day, day.Copy().EndOfMonth())
+	if err != nil || len(stats.ReconstructedSeries) != 1 || stats.ReconstructedSeries[0] {
+		t.Errorf("reconstructed months of live snapshots = %v, %v, want [false]", stats.ReconstructedSeries, err)
+	}
+
+	if _, err := s.AddReconstructedStats(ctx, sqlite.EntityInstance, 1, nil); !errors.Is(err, sqlite.ErrUnsupportedEntityType) {
+		t.Errorf("AddReconstructedStats(instance) error = %v, want %v", err, sqlite.ErrUnsupportedEntityType)
+	}
+}
@@ -0,0 +1,31 @@
+-- migrate:up
+
+CREATE TABLE series (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name character varying(255) NOT NULL UNIQUE,
+    entity_type character varying(16) NOT NULL,
+    entity_id integer NOT NULL,
+    filter text NOT NULL
+);
+
+CREATE INDEX series_name_idx       ON series (name) ;
+
+CREATE TABLE stats_series (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    seriesId integer NOT NULL,
+    statsId integer NOT NULL,
+    CONSTRAINT fk_seriesid
+      FOREIGN KEY(seriesId) 
+	  REFERENCES series(id),
+    CONSTRAINT fk_statsid
+      FOREIGN KEY(statsId) 
+	  REFERENCES stats(id)
+);
+
+CREATE INDEX stats_series_id_idx       ON stats_series (id) ;
+
+-- migrate:down
+
+DROP TABLE stats_series;
+
+DROP TABLE series;
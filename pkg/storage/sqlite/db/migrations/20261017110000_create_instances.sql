@@ -0,0 +1,28 @@
+-- migrate:up
+
+CREATE TABLE instances (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    uri character varying(255) NOT NULL UNIQUE
+);
+
+CREATE INDEX instances_uri_idx       ON instances (uri) ;
+
+CREATE TABLE stats_instances (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    instanceId integer NOT NULL,
+    statsId integer NOT NULL,
+    CONSTRAINT fk_instanceid
+      FOREIGN KEY(instanceId) 
+	  REFERENCES instances(id),
+    CONSTRAINT fk_statsid
+      FOREIGN KEY(statsId) 
+	  REFERENCES stats(id)
+);
+
+CREATE INDEX stats_instances_id_idx       ON stats_instances (id) ;
+
+-- migrate:down
+
+DROP TABLE stats_instances;
+
+DROP TABLE instances;
@@ -0,0 +1,15 @@
+-- migrate:up
+
+-- Snapshots used to be written with the Go time format
+-- ("2006-01-02 15:04:05.999999999 -0700 MST m=+0.001") which SQLite date
+-- functions cannot parse. Rewrite them as "2006-01-02 15:04:05.999999999-07:00".
+UPDATE stats
+SET date_exec =
+  substr(date_exec, 1, 10) || ' ' ||
+  substr(substr(date_exec, 12), 1, instr(substr(date_exec, 12), ' ') - 1) ||
+  substr(substr(date_exec, 12), instr(substr(date_exec, 12), ' ') + 1, 3) || ':' ||
+  substr(substr(date_exec, 12), instr(substr(date_exec, 12), ' ') + 4, 2)
+WHERE date_exec LIKE '____-__-__ __:__:__% +____ %'
+   OR date_exec LIKE '____-__-__ __:__:__% -____ %';
+
+-- migrate:down
@@ -0,0 +1,49 @@
+-- migrate:up
+
+CREATE TABLE mr_stats (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    date_exec timestamp NOT NULL,
+    total integer NOT NULL,
+    opened integer NOT NULL,
+    merged integer NOT NULL,
+    closed integer NOT NULL,
+    locked integer NOT NULL
+);
+
+CREATE INDEX mr_stats_id_idx       ON mr_stats (id) ;
+
+CREATE TABLE mr_stats_projects (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    projectId integer NOT NULL,
+    mrStatsId integer NOT NULL,
+    CONSTRAINT fk_projectid
+      FOREIGN KEY(projectId) 
+	  REFERENCES projects(id),
+    CONSTRAINT fk_mrstatsid
+      FOREIGN KEY(mrStatsId) 
+	  REFERENCES mr_stats(id)
+);
+
+CREATE INDEX mr_stats_projects_id_idx       ON mr_stats_projects (id) ;
+
+CREATE TABLE mr_stats_groups (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    groupId integer NOT NULL,
+    mrStatsId integer NOT NULL,
+    CONSTRAINT fk_groupid
+      FOREIGN KEY(groupId) 
+	  REFERENCES groups(id),
+    CONSTRAINT fk_mrstatsid
+      FOREIGN KEY(mrStatsId) 
+	  REFERENCES mr_stats(id)
+);
+
+CREATE INDEX mr_stats_groups_id_idx       ON mr_stats_groups (id) ;
+
+-- migrate:down
+
+DROP TABLE mr_stats_groups;
+
+DROP TABLE mr_stats_projects;
+
+DROP TABLE mr_stats;
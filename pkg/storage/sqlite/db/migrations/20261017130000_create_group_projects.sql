@@ -0,0 +1,22 @@
+-- migrate:up
+
+CREATE TABLE group_projects (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    groupId integer NOT NULL,
+    projectId integer NOT NULL,
+    last_seen timestamp NOT NULL,
+    CONSTRAINT fk_groupid
+      FOREIGN KEY(groupId) 
+	  REFERENCES groups(id),
+    CONSTRAINT fk_projectid
+      FOREIGN KEY(projectId) 
+	  REFERENCES projects(id),
+    CONSTRAINT uq_group_project
+      UNIQUE(groupId, projectId)
+);
+
+CREATE INDEX group_projects_groupid_idx       ON group_projects (groupId) ;
+
+-- migrate:down
+
+DROP TABLE group_projects;
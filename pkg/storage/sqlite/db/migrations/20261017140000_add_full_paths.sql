@@ -0,0 +1,19 @@
+-- migrate:up
+
+ALTER TABLE projects ADD COLUMN full_path character varying(255) NOT NULL DEFAULT '';
+
+ALTER TABLE groups ADD COLUMN full_path character varying(255) NOT NULL DEFAULT '';
+
+CREATE INDEX projects_full_path_idx       ON projects (full_path) ;
+
+CREATE INDEX groups_full_path_idx       ON groups (full_path) ;
+
+-- migrate:down
+
+DROP INDEX groups_full_path_idx;
+
+DROP INDEX projects_full_path_idx;
+
+ALTER TABLE groups DROP COLUMN full_path;
+
+ALTER TABLE projects DROP COLUMN full_path;
@@ -0,0 +1,27 @@
+-- migrate:up
+
+-- Instances are GitLab instances or other forges (github, ...).
+ALTER TABLE instances ADD COLUMN provider character varying(16) NOT NULL DEFAULT 'gitlab';
+
+-- Projects and groups collected before the providers have no instance:
+-- they belong to the GitLab instance of GITLAB_URI.
+-- (lower case: sqlc does not fold the case of columns added by ALTER TABLE)
+ALTER TABLE projects ADD COLUMN instanceid integer;
+
+ALTER TABLE groups ADD COLUMN instanceid integer;
+
+CREATE INDEX projects_instanceid_idx       ON projects (instanceid) ;
+
+CREATE INDEX groups_instanceid_idx       ON groups (instanceid) ;
+
+-- migrate:down
+
+DROP INDEX groups_instanceid_idx;
+
+DROP INDEX projects_instanceid_idx;
+
+ALTER TABLE groups DROP COLUMN instanceid;
+
+ALTER TABLE projects DROP COLUMN instanceid;
+
+ALTER TABLE instances DROP COLUMN provider;
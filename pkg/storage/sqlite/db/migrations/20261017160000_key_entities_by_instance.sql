@@ -0,0 +1,156 @@
+-- migrate:up transaction:false
+
+-- Projects and groups are keyed by instance: the same ID on two instances are
+-- two entities. id becomes the key of the entity in the database and the ID
+-- on the forge moves to remote_id. Existing rows keep their ID as key, so that
+-- the statistics, series and memberships which reference them are unchanged.
+
+-- The tables are rebuilt as documented in https://www.sqlite.org/lang_altertable.html:
+-- foreign keys are disabled outside of the transaction, so that dropping the
+-- old tables leaves the rows which reference them alone, and checked before
+-- the commit.
+PRAGMA foreign_keys=OFF;
+
+BEGIN TRANSACTION;
+
+-- Series may reference an entity which was never collected without filter.
+INSERT INTO projects (id, project_name)
+SELECT DISTINCT entity_id, '' FROM series
+WHERE entity_type = 'project' AND entity_id NOT IN (SELECT id FROM projects);
+
+INSERT INTO groups (id, group_name)
+SELECT DISTINCT entity_id, '' FROM series
+WHERE entity_type = 'group' AND entity_id NOT IN (SELECT id FROM groups);
+
+-- Entities without instance were collected from the GitLab instance of
+-- GITLAB_URI: they are assigned to the first GitLab instance of the database,
+-- or to an instance without URI, which the GitLab instance registered by the
+-- next run takes over since GITLAB_URI is unknown here.
+INSERT INTO instances (uri, provider)
+SELECT '', 'gitlab'
+WHERE NOT EXISTS (SELECT 1 FROM instances WHERE provider = 'gitlab')
+  AND (EXISTS (SELECT 1 FROM projects WHERE instanceid IS NULL)
+    OR EXISTS (SELECT 1 FROM groups WHERE instanceid IS NULL));
+
+CREATE TABLE projects_by_instance (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    instanceId integer NOT NULL,
+    remote_id integer NOT NULL,
+    project_name character varying(255) NOT NULL,
+    full_path character varying(255) NOT NULL DEFAULT '',
+    CONSTRAINT fk_instanceid
+      FOREIGN KEY(instanceId)
+	  REFERENCES instances(id),
+    CONSTRAINT uq_instance_project
+      UNIQUE(instanceId, remote_id)
+);
+
+INSERT INTO projects_by_instance (id, instanceId, remote_id, project_name, full_path)
+SELECT id, IFNULL(instanceid, (SELECT MIN(id) FROM instances WHERE provider = 'gitlab')), id, project_name, full_path
+FROM projects;
+
+DROP TABLE projects;
+
+ALTER TABLE projects_by_instance RENAME TO projects;
+
+CREATE INDEX projects_id_idx       ON projects (id) ;
+
+CREATE INDEX projects_full_path_idx       ON projects (full_path) ;
+
+CREATE TABLE groups_by_instance (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    instanceId integer NOT NULL,
+    remote_id integer NOT NULL,
+    group_name character varying(255) NOT NULL,
+    full_path character varying(255) NOT NULL DEFAULT '',
+    CONSTRAINT fk_instanceid
+      FOREIGN KEY(instanceId)
+	  REFERENCES instances(id),
+    CONSTRAINT uq_instance_group
+      UNIQUE(instanceId, remote_id)
+);
+
+INSERT INTO groups_by_instance (id, instanceId, remote_id, group_name, full_path)
+SELECT id, IFNULL(instanceid, (SELECT MIN(id) FROM instances WHERE provider = 'gitlab')), id, group_name, full_path
+FROM groups;
+
+DROP TABLE groups;
+
+ALTER TABLE groups_by_instance RENAME TO groups;
+
+CREATE INDEX groups_id_idx       ON groups (id) ;
+
+CREATE INDEX groups_full_path_idx       ON groups (full_path) ;
+
+-- the insert fails on the CHECK constraint if a row references a missing
+-- project or group, which rolls the migration back
+CREATE TEMP TABLE entity_foreign_key_violations (n integer NOT NULL CHECK (n = 0));
+
+INSERT INTO entity_foreign_key_violations
+SELECT COUNT(*) FROM pragma_foreign_key_check WHERE parent IN ('projects', 'groups');
+
+DROP TABLE entity_foreign_key_violations;
+
+COMMIT;
+
+PRAGMA foreign_keys=ON;
+
+-- migrate:down transaction:false
+
+-- The key becomes the ID again: entities created after the migration whose
+-- key differs from their ID on the forge are not restored.
+
+PRAGMA foreign_keys=OFF;
+
+BEGIN TRANSACTION;
+
+CREATE TABLE groups_without_instance (
+    id integer PRIMARY KEY NOT NULL,
+    group_name character varying(255) NOT NULL,
+    full_path character varying(255) NOT NULL DEFAULT '',
+    instanceid integer
+);
+
+INSERT INTO groups_without_instance (id, group_name, full_path, instanceid)
+SELECT id, group_name, full_path, instanceId FROM groups;
+
+DROP TABLE groups;
+
+ALTER TABLE groups_without_instance RENAME TO groups;
+
+CREATE INDEX groups_id_idx       ON groups (id) ;
+
+CREATE INDEX groups_full_path_idx       ON groups (full_path) ;
+
+CREATE INDEX groups_instanceid_idx       ON groups (instanceid) ;
+
+CREATE TABLE projects_without_instance (
+    id integer PRIMARY KEY NOT NULL,
+    project_name character varying(255) NOT NULL,
+    full_path character varying(255) NOT NULL DEFAULT '',
+    instanceid integer
+);
+
+INSERT INTO projects_without_instance (id, project_name, full_path, instanceid)
+SELECT id, project_name, full_path, instanceId FROM projects;
+
+DROP TABLE projects;
+
+ALTER TABLE projects_without_instance RENAME TO projects;
+
+CREATE INDEX projects_id_idx       ON projects (id) ;
+
+CREATE INDEX projects_full_path_idx       ON projects (full_path) ;
+
+CREATE INDEX projects_instanceid_idx       ON projects (instanceid) ;
+
+CREATE TEMP TABLE entity_foreign_key_violations (n integer NOT NULL CHECK (n = 0));
+
+INSERT INTO entity_foreign_key_violations
+SELECT COUNT(*) FROM pragma_foreign_key_check WHERE parent IN ('projects', 'groups');
+
+DROP TABLE entity_foreign_key_violations;
+
+COMMIT;
+
+PRAGMA foreign_keys=ON;
@@ -0,0 +1,9 @@
+-- migrate:up
+
+-- Snapshots rebuilt from the creation and closing dates of the issues by
+-- -backfill are reconstructed, the snapshots collected by a run are live.
+ALTER TABLE stats ADD COLUMN reconstructed boolean NOT NULL DEFAULT 0;
+
+-- migrate:down
+
+ALTER TABLE stats DROP COLUMN reconstructed;
@@ -0,0 +1,23 @@
+-- migrate:up
+
+-- Percentiles, in days, of the time from creation to closing of the issues
+-- closed during a month (YYYY-MM), of all the issues of a project or group
+-- (empty label) or of those of a label. Recomputed on each -lead-time run.
+CREATE TABLE lead_times (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    entity_type character varying(16) NOT NULL,
+    entity_id integer NOT NULL,
+    period character varying(7) NOT NULL,
+    label character varying(255) NOT NULL DEFAULT '',
+    closed integer NOT NULL,
+    p50 real NOT NULL,
+    p85 real NOT NULL,
+    p95 real NOT NULL,
+    date_exec timestamp NOT NULL,
+    CONSTRAINT uq_lead_time
+      UNIQUE(entity_type, entity_id, period, label)
+);
+
+-- migrate:down
+
+DROP TABLE lead_times;
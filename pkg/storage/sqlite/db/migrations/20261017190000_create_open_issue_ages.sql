@@ -0,0 +1,20 @@
+-- migrate:up
+
+-- Number of open issues of a snapshot per age, from their creation:
+-- under 7 days, 7 to 30, 30 to 90, 90 to 365 and over 365 days.
+CREATE TABLE stats_open_issue_ages (
+    id integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    statsId integer NOT NULL UNIQUE,
+    under_7_days integer NOT NULL,
+    days_7_to_30 integer NOT NULL,
+    days_30_to_90 integer NOT NULL,
+    days_90_to_365 integer NOT NULL,
+    over_365_days integer NOT NULL,
+    CONSTRAINT fk_statsid
+      FOREIGN KEY(statsId) 
+	  REFERENCES stats(id)
+);
+
+-- migrate:down
+
+DROP TABLE stats_open_issue_ages;
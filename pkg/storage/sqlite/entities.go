@@ -0,0 +1,317 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sgaunet/gitlab-stats/internal/database"
+)
+
+var (
+	// ErrProjectNotFound is returned when no project has the requested path or ID.
+	ErrProjectNotFound = errors.New("project not found")
+	// ErrGroupNotFound is returned when no group has the requested path or ID.
+	ErrGroupNotFound = errors.New("group not found")
+)
+
+// Project represents a project cached in the database.
+type Project struct {
+	// Key identifies the project in the database: the statistics, series
+	// and memberships of the project reference it.
+	Key int64
+	// ID is the ID of the project on its instance.
+	ID       int64
+	FullPath string
+	Name     string
+	// InstanceID is the instance of the project. When saving, 0 is the
+	// GitLab instance of the projects collected before the instances were
+	// recorded, see LegacyInstance.
+	InstanceID int64
+}
+
+// Group represents a group cached in the database.
+type Group struct {
+	// Key identifies the group in the database: the statistics, series and
+	// memberships of the group reference it.
+	Key int64
+	// ID is the ID of the group on its instance.
+	ID       int64
+	FullPath string
+	Name     string
+	// InstanceID is the instance of the group. When saving, 0 is the GitLab
+	// instance of the groups collected before the instances were recorded,
+	// see LegacyInstance.
+	InstanceID int64
+}
+
+func projectOf(p database.Project) Project {
+	return Project{Key: p.ID, ID: p.RemoteID, FullPath: p.FullPath, Name: p.ProjectName, InstanceID: p.Instanceid}
+}
+
+func groupOf(g database.Group) Group {
+	return Group{Key: g.ID, ID: g.RemoteID, FullPath: g.FullPath, Name: g.GroupName, InstanceID: g.Instanceid}
+}
+
+// SaveProject creates the project of its instance or updates its path and
+// name, and returns it with its key.
+func (s *Storage) SaveProject(ctx context.Context, project Project) (Project, error) {
+	var saved Project
+	err := s.withTx(ctx, func(q *database.Queries) error {
+		var err error
+		saved, err = saveProject(ctx, q, project)
+		return err
+	})
+	return saved, err
+}
+
+func saveProject(ctx context.Context, q *database.Queries, project Project) (Project, error) {
+	instanceID, err := instanceOrLegacy(ctx, q, project.InstanceID)
+	if err != nil {
+		return Project{}, err
+	}
+	project.InstanceID = instanceID
+	project.FullPath = normalizePath(project.FullPath)
+	project.Key, err = q.UpsertProject(ctx, database.UpsertProjectParams{
+		Instanceid:  project.InstanceID,
+		RemoteID:    project.ID,
+		ProjectName: project.Name,
+		FullPath:    project.FullPath,
+	})
+	if err != nil {
+		return Project{}, fmt.Errorf("failed to save project: %w", err)
+	}
+	return project, nil
+}
+
+// SaveGroup creates the group of its instance or updates its path and name,
+// and returns it with its key.
+func (s *Storage) SaveGroup(ctx context.Context, group Group) (Group, error) {
+	var saved Group
+	err := s.withTx(ctx, func(q *database.Queries) error {
+		var err error
+		saved, err = saveGroup(ctx, q, group)
+		return err
+	})
+	return saved, err
+}
+
+func saveGroup(ctx context.Context, q *database.Queries, group Group) (Group, error) {
+	instanceID, err := instanceOrLegacy(ctx, q, group.InstanceID)
+	if err != nil {
+		return Group{}, err
+	}
+	group.InstanceID = instanceID
+	group.FullPath = normalizePath(group.FullPath)
+	group.Key, err = q.UpsertGroup(ctx, database.UpsertGroupParams{
+		Instanceid: group.InstanceID,
+		RemoteID:   group.ID,
+		GroupName:  group.Name,
+		FullPath:   group.FullPath,
+	})
+	if err != nil {
+		return Group{}, fmt.Errorf("failed to save group: %w", err)
+	}
+	return group, nil
+}
+
+// ProjectKey returns the key of the project of the instance with the given
+// ID, creating the project without path nor name if it is not known yet.
+func (s *Storage) ProjectKey(ctx context.Context, instanceID int64, id int64) (int64, error) {
+	var key int64
+	err := s.withTx(ctx, func(q *database.Queries) error {
+		var err error
+		key, err = ensureProject(ctx, q, instanceID, id)
+		return err
+	})
+	return key, err
+}
+
+// GroupKey returns the key of the group of the instance with the given ID,
+// creating the group without path nor name if it is not known yet.
+func (s *Storage) GroupKey(ctx context.Context, instanceID int64, id int64) (int64, error) {
+	var key int64
+	err := s.withTx(ctx, func(q *database.Queries) error {
+		var err error
+		key, err = ensureGroup(ctx, q, instanceID, id)
+		return err
+	})
+	return key, err
+}
+
+// GetProject returns the project of the instance with the given ID.
+func (s *Storage) GetProject(ctx context.Context, instanceID int64, id int64) (Project, error) {
+	instanceID, err := instanceOrLegacy(ctx, s.queries, instanceID)
+	if err != nil {
+		return Project{}, err
+	}
+	project, err := s.queries.GetProjectByRemoteID(ctx, database.GetProjectByRemoteIDParams{
+		Instanceid: instanceID,
+		RemoteID:   id,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Project{}, fmt.Errorf("%w: %d", ErrProjectNotFound, id)
+	}
+	if err != nil {
+		return Project{}, fmt.Errorf("failed to get project: %w", err)
+	}
+	return projectOf(project), nil
+}
+
+// GetGroup returns the group of the instance with the given ID.
+func (s *Storage) GetGroup(ctx context.Context, instanceID int64, id int64) (Group, error) {
+	instanceID, err := instanceOrLegacy(ctx, s.queries, instanceID)
+	if err != nil {
+		return Group{}, err
+	}
+	group, err := s.queries.GetGroupByRemoteID(ctx, database.GetGroupByRemoteIDParams{
+		Instanceid: instanceID,
+		RemoteID:   id,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Group{}, fmt.Errorf("%w: %d", ErrGroupNotFound, id)
+	}
+	if err != nil {
+		return Group{}, fmt.Errorf("failed to get group: %w", err)
+	}
+	return groupOf(group), nil
+}
+
+// GetProjectByKey returns the project with the given key.
+func (s *Storage) GetProjectByKey(ctx context.Context, key int64) (Project, error) {
+	project, err := s.queries.GetProjectByKey(ctx, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Project{}, fmt.Errorf("%w: key %d", ErrProjectNotFound, key)
+	}
+	if err != nil {
+		return Project{}, fmt.Errorf("failed to get project: %w", err)
+	}
+	return projectOf(project), nil
+}
+
+// GetGroupByKey returns the group with the given key.
+func (s *Storage) GetGroupByKey(ctx context.Context, key int64) (Group, error) {
+	group, err := s.queries.GetGroupByKey(ctx, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Group{}, fmt.Errorf("%w: key %d", ErrGroupNotFound, key)
+	}
+	if err != nil {
+		return Group{}, fmt.Errorf("failed to get group: %w", err)
+	}
+	return groupOf(group), nil
+}
+
+// GetProjectByPath returns the cached project of the instance with the given
+// full path. Use 0 as instanceID for the legacy GitLab instance.
+func (s *Storage) GetProjectByPath(ctx context.Context, instanceID int64, fullPath string) (Project, error) {
+	instanceID, err := instanceOrLegacy(ctx, s.queries, instanceID)
+	if err != nil {
+		return Project{}, err
+	}
+	project, err := s.queries.GetProjectByPath(ctx, database.GetProjectByPathParams{
+		Instanceid: instanceID,
+		FullPath:   normalizePath(fullPath),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Project{}, fmt.Errorf("%w: %s", ErrProjectNotFound, fullPath)
+	}
+	if err != nil {
+		return Project{}, fmt.Errorf("failed to get project by path: %w", err)
+	}
+	return projectOf(project), nil
+}
+
+// GetGroupByPath returns the cached group of the instance with the given
+// full path. Use 0 as instanceID for the legacy GitLab instance.
+func (s *Storage) GetGroupByPath(ctx context.Context, instanceID int64, fullPath string) (Group, error) {
+	instanceID, err := instanceOrLegacy(ctx, s.queries, instanceID)
+	if err != nil {
+		return Group{}, err
+	}
+	group, err := s.queries.GetGroupByPath(ctx, database.GetGroupByPathParams{
+		Instanceid: instanceID,
+		FullPath:   normalizePath(fullPath),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Group{}, fmt.Errorf("%w: %s", ErrGroupNotFound, fullPath)
+	}
+	if err != nil {
+		return Group{}, fmt.Errorf("failed to get group by path: %w", err)
+	}
+	return groupOf(group), nil
+}
+
+// ensureProject returns the key of the project of the instance with the
+// given remote ID, creating the project without path nor name if it is not
+// known yet. Use 0 as instanceID for the legacy GitLab instance.
+func ensureProject(ctx context.Context, q *database.Queries, instanceID int64, remoteID int64) (int64, error) {
+	instanceID, err := instanceOrLegacy(ctx, q, instanceID)
+	if err != nil {
+		return 0, err
+	}
+	project, err := q.GetProjectByRemoteID(ctx, database.GetProjectByRemoteIDParams{
+		Instanceid: instanceID,
+		RemoteID:   remoteID,
+	})
+	if err == nil {
+		return project.ID, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to get project: %w", err)
+	}
+	saved, err := saveProject(ctx, q, Project{ID: remoteID, InstanceID: instanceID})
+	return saved.Key, err
+}
+
+// ensureGroup returns the key of the group of the instance with the given
+// remote ID, creating the group if it is not known yet, see ensureProject.
+func ensureGroup(ctx context.Context, q *database.Queries, instanceID int64, remoteID int64) (int64, error) {
+	instanceID, err := instanceOrLegacy(ctx, q, instanceID)
+	if err != nil {
+		return 0, err
+	}
+	group, err := q.GetGroupByRemoteID(ctx, database.GetGroupByRemoteIDParams{
+		Instanceid: instanceID,
+		RemoteID:   remoteID,
+	})
+	if err == nil {
+		return group.ID, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to get group: %w", err)
+	}
+	saved, err := saveGroup(ctx, q, Group{ID: remoteID, InstanceID: instanceID})
+	return saved.Key, err
+}
+
+// checkProject returns ErrProjectNotFound if no project has the given key.
+func checkProject(ctx context.Context, q *database.Queries, projectKey int64) error {
+	_, err := q.GetProject(ctx, projectKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: key %d", ErrProjectNotFound, projectKey)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get project: %w", err)
+	}
+	return nil
+}
+
+// checkGroup returns ErrGroupNotFound if no group has the given key.
+func checkGroup(ctx context.Context, q *database.Queries, groupKey int64) error {
+	_, err := q.GetGroup(ctx, groupKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: key %d", ErrGroupNotFound, groupKey)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get group: %w", err)
+	}
+	return nil
+}
+
+// normalizePath makes "/ns/project/" and "ns/project" share the same key.
+// Paths are compared case insensitively, as GitLab does.
+func normalizePath(fullPath string) string {
+	return strings.Trim(strings.TrimSpace(fullPath), "/")
+}
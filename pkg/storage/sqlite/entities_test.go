@@ -0,0 +1,171 @@
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang-module/carbon/v2"
+	"github.com/sgaunet/gitlab-stats/pkg/storage/sqlite"
+)
+
+func TestProjectByPath(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	want, err := s.SaveProject(ctx, sqlite.Project{ID: 42, FullPath: "Grp/Sub/proj", Name: "Proj"})
+	if err != nil {
+		t.Fatalf("err returned by SaveProject(): %v", err.Error())
+	}
+	if want.Key == 0 || want.InstanceID == 0 {
+		t.Errorf("SaveProject() = %+v, want a key on the legacy instance", want)
+	}
+	got, err := s.GetProjectByPath(ctx, 0, "/grp/sub/proj/")
+	if err != nil {
+		t.Fatalf("err returned by GetProjectByPath(): %v", err.Error())
+	}
+	if got != want {
+		t.Errorf("GetProjectByPath() = %+v, want %+v", got, want)
+	}
+
+	// the project was moved
+	if _, err := s.SaveProject(ctx, sqlite.Project{ID: 42, FullPath: "other/proj", Name: "Proj"}); err != nil {
+		t.Fatalf("err returned by SaveProject(): %v", err.Error())
+	}
+	_, err = s.GetProjectByPath(ctx, 0, "grp/sub/proj")
+	if !errors.Is(err, sqlite.ErrProjectNotFound) {
+		t.Errorf("GetProjectByPath() error = %v, want %v", err, sqlite.ErrProjectNotFound)
+	}
+}
+
+func TestGroupByPath(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	// statistics recorded before the path was known
+	if err := s.AddGroupStatsWithContext(ctx, 0, 7, 1, 2, 3, carbon.Now()); err != nil {
+		t.Fatalf("err returned by AddGroupStatsWithContext(): %v", err.Error())
+	}
+	saved, err := s.SaveGroup(ctx, sqlite.Group{ID: 7, FullPath: "grp/sub", Name: "Sub"})
+	if err != nil {
+		t.Fatalf("err returned by SaveGroup(): %v", err.Error())
+	}
+	got, err := s.GetGroupByPath(ctx, 0, "grp/sub")
+	if err != nil {
+		t.Fatalf("err returned by GetGroupByPath(): %v", err.Error())
+	}
+	stats, err := s.GetEnhancedStatsByGroupID(got.Key, carbon.Now().SubMonth(), carbon.Now().AddDay())
+	if err != nil {
+		t.Fatalf("err returned by GetEnhancedStatsByGroupID(): %v", err.Error())
+	}
+	if got.Key != saved.Key || got.ID != 7 || got.Name != "Sub" || len(stats.DateExecSeries) != 1 {
+		t.Errorf("GetGroupByPath() = %+v, want group 7 with its statistics", got)
+	}
+	_, err = s.GetGroupByPath(ctx, 0, "grp")
+	if !errors.Is(err, sqlite.ErrGroupNotFound) {
+		t.Errorf("GetGroupByPath() error = %v, want %v", err, sqlite.ErrGroupNotFound)
+	}
+}
+
+func TestEntitiesOfInstances(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	// project recorded before the instances
+	legacy, err := s.SaveProject(ctx, sqlite.Project{ID: 42, FullPath: "acme/api", Name: "api"})
+	if err != nil {
+		t.Fatalf("err returned by SaveProject(): %v", err.Error())
+	}
+	// the first GitLab instance registered takes the legacy instance over
+	gitlabID, err := s.GetOrCreateInstance(ctx, "https://gitlab.com/")
+	if err != nil {
+		t.Fatalf("err returned by GetOrCreateInstance(): %v", err.Error())
+	}
+	if legacy.InstanceID != gitlabID {
+		t.Errorf("SaveProject() instance = %d, want the legacy instance %d", legacy.InstanceID, gitlabID)
+	}
+	selfHostedID, err := s.GetOrCreateInstance(ctx, "https://gitlab.example.com")
+	if err != nil {
+		t.Fatalf("err returned by GetOrCreateInstance(): %v", err.Error())
+	}
+	githubID, err := s.RegisterInstance(ctx, "github", "https://github.com")
+	if err != nil {
+		t.Fatalf("err returned by RegisterInstance(): %v", err.Error())
+	}
+	if _, err := s.RegisterInstance(ctx, "github", "https://gitlab.example.com"); !errors.Is(err, sqlite.ErrInstanceProviderMismatch) {
+		t.Errorf("RegisterInstance() error = %v, want %v", err, sqlite.ErrInstanceProviderMismatch)
+	}
+
+	// the same ID on another instance is another project
+	selfHosted, err := s.SaveProject(ctx, sqlite.Project{ID: 42, FullPath: "acme/api", Name: "api", InstanceID: selfHostedID})
+	if err != nil {
+		t.Fatalf("err returned by SaveProject(): %v", err.Error())
+	}
+	if selfHosted.Key == legacy.Key {
+		t.Errorf("SaveProject() on %d returned key %d of the project of %d", selfHostedID, selfHosted.Key, gitlabID)
+	}
+	github, err := s.SaveProject(ctx, sqlite.Project{ID: 42, FullPath: "acme/api", Name: "api", InstanceID: githubID})
+	if err != nil {
+		t.Fatalf("err returned by SaveProject(): %v", err.Error())
+	}
+	for _, want := range []sqlite.Project{legacy, selfHosted, github} {
+		got, err := s.GetProjectByPath(ctx, want.InstanceID, "acme/api")
+		if err != nil || got != want {
+			t.Errorf("GetProjectByPath(%d) = %+v, %v, want %+v", want.InstanceID, got, err, want)
+		}
+		got, err = s.GetProject(ctx, want.InstanceID, 42)
+		if err != nil || got != want {
+			t.Errorf("GetProject(%d) = %+v, %v, want %+v", want.InstanceID, got, err, want)
+		}
+		got, err = s.GetProjectByKey(ctx, want.Key)
+		if err != nil || got != want {
+			t.Errorf("GetProjectByKey(%d) = %+v, %v, want %+v", want.Key, got, err, want)
+		}
+	}
+	key, err := s.ProjectKey(ctx, selfHostedID, 42)
+	if err != nil || key != selfHosted.Key {
+		t.Errorf("ProjectKey() = %d, %v, want %d", key, err, selfHosted.Key)
+	}
+
+	// statistics of the project of one instance are not those of the other
+	if err := s.AddProjectStatsWithContext(ctx, selfHostedID, 42, 1, 2, 3, carbon.Now()); err != nil {
+		t.Fatalf("err returned by AddProjectStatsWithContext(): %v", err.Error())
+	}
+	begin, end := carbon.Now().SubMonth(), carbon.Now().AddDay()
+	stats, err := s.GetEnhancedStatsByProjectID(legacy.Key, begin, end)
+	if err != nil {
+		t.Fatalf("err returned by GetEnhancedStatsByProjectID(): %v", err.Error())
+	}
+	if len(stats.DateExecSeries) != 0 {
+		t.Errorf("GetEnhancedStatsByProjectID(legacy) = %+v, want no statistics", stats)
+	}
+	// a GitLab ID equal to the key of a project of another instance is another project
+	if err := s.AddProjectStatsWithContext(ctx, 0, selfHosted.Key, 1, 2, 3, carbon.Now()); err != nil {
+		t.Fatalf("err returned by AddProjectStatsWithContext(): %v", err.Error())
+	}
+	stats, err = s.GetEnhancedStatsByProjectID(selfHosted.Key, begin, end)
+	if err != nil {
+		t.Fatalf("err returned by GetEnhancedStatsByProjectID(): %v", err.Error())
+	}
+	if len(stats.DateExecSeries) != 1 || stats.TotalOpenedSeries[0] != 1 {
+		t.Errorf("GetEnhancedStatsByProjectID(self-hosted) = %+v, want its single snapshot", stats)
+	}
+	if _, err := s.GetProject(ctx, 0, selfHosted.Key); err != nil {
+		t.Errorf("GetProject(legacy, %d) error = %v, want the project created", selfHosted.Key, err)
+	}
+
+	group, err := s.SaveGroup(ctx, sqlite.Group{ID: 3, FullPath: "acme", Name: "Acme", InstanceID: githubID})
+	if err != nil {
+		t.Fatalf("err returned by SaveGroup(): %v", err.Error())
+	}
+	if _, err := s.GetGroupByPath(ctx, 0, "acme"); !errors.Is(err, sqlite.ErrGroupNotFound) {
+		t.Errorf("GetGroupByPath(gitlab) error = %v, want %v", err, sqlite.ErrGroupNotFound)
+	}
+	got, err := s.GetGroupByPath(ctx, githubID, "acme")
+	if err != nil || got != group {
+		t.Errorf("GetGroupByPath(github) = %+v, %v, want %+v", got, err, group)
+	}
+	if _, err := s.GetGroup(ctx, selfHostedID, 3); !errors.Is(err, sqlite.ErrGroupNotFound) {
+		t.Errorf("GetGroup(self-hosted) error = %v, want %v", err, sqlite.ErrGroupNotFound)
+	}
+}
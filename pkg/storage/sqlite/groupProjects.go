@@ -0,0 +1,71 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang-module/carbon/v2"
+	"github.com/sgaunet/gitlab-stats/internal/database"
+)
+
+// GroupProject represents a project found while crawling a group.
+type GroupProject struct {
+	// Key identifies the project in the database.
+	Key int64
+	// ID is the ID of the project on its instance.
+	ID         int64
+	InstanceID int64
+	FullPath   string
+	Name       string
+	LastSeen   time.Time
+}
+
+// AddGroupProject records that a project belongs to the group with the given
+// key, which must exist. The project is saved with its path and name, see
+// SaveProject.
+func (s *Storage) AddGroupProject(
+	ctx context.Context,
+	groupKey int64,
+	project Project,
+	dateExec *carbon.Carbon,
+) error {
+	return s.withTx(ctx, func(q *database.Queries) error {
+		if err := checkGroup(ctx, q, groupKey); err != nil {
+			return err
+		}
+		project, err := saveProject(ctx, q, project)
+		if err != nil {
+			return err
+		}
+		err = q.UpsertGroupProject(ctx, database.UpsertGroupProjectParams{
+			Groupid:   groupKey,
+			Projectid: project.Key,
+			LastSeen:  dateExec.StdTime(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to insert group project: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetGroupProjects returns the projects recorded as members of the group with the given key.
+func (s *Storage) GetGroupProjects(ctx context.Context, groupKey int64) ([]GroupProject, error) {
+	rows, err := s.queries.GetGroupProjects(ctx, groupKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group projects: %w", err)
+	}
+	projects := make([]GroupProject, 0, len(rows))
+	for _, row := range rows {
+		projects = append(projects, GroupProject{
+			Key:        row.ID,
+			ID:         row.RemoteID,
+			InstanceID: row.Instanceid,
+			FullPath:   row.FullPath,
+			Name:       row.ProjectName,
+			LastSeen:   row.LastSeen,
+		})
+	}
+	return projects, nil
+}
@@ -0,0 +1,51 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang-module/carbon/v2"
+	"github.com/sgaunet/gitlab-stats/pkg/storage/sqlite"
+)
+
+func TestAddGroupProject(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	key, err := s.GroupKey(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("err returned by GroupKey(): %v", err.Error())
+	}
+	if err := s.AddGroupProject(ctx, key, sqlite.Project{ID: 2, FullPath: "grp/b", Name: "b"}, carbon.Now()); err != nil {
+		t.Fatalf("err returned by AddGroupProject(): %v", err.Error())
+	}
+	if err := s.AddGroupProject(ctx, key, sqlite.Project{ID: 1, FullPath: "grp/a", Name: "a"}, carbon.Now()); err != nil {
+		t.Fatalf("err returned by AddGroupProject(): %v", err.Error())
+	}
+	// crawling again must not duplicate the membership
+	project := sqlite.Project{ID: 1, FullPath: "grp/sub/a", Name: "renamed"}
+	if err := s.AddGroupProject(ctx, key, project, carbon.Now()); err != nil {
+		t.Fatalf("err returned by AddGroupProject(): %v", err.Error())
+	}
+
+	projects, err := s.GetGroupProjects(ctx, key)
+	if err != nil {
+		t.Fatalf("err returned by GetGroupProjects(): %v", err.Error())
+	}
+	if len(projects) != 2 {
+		t.Fatalf("GetGroupProjects() returned %d projects, want 2", len(projects))
+	}
+	if projects[0].ID != 1 || projects[0].Name != "renamed" || projects[0].FullPath != "grp/sub/a" {
+		t.Errorf("GetGroupProjects()[0] = %+v, want project 1 moved and renamed", projects[0])
+	}
+	if projects[0].LastSeen.IsZero() {
+		t.Errorf("GetGroupProjects()[0] has no last seen date")
+	}
+	other, err := s.GetGroupProjects(ctx, key+1)
+	if err != nil {
+		t.Fatalf("err returned by GetGroupProjects(): %v", err.Error())
+	}
+	if len(other) != 0 {
+		t.Errorf("GetGroupProjects() of another group = %+v, want none", other)
+	}
+}
@@ -0,0 +1,295 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-module/carbon/v2"
+	"github.com/sgaunet/gitlab-stats/internal/database"
+)
+
+// EntityInstance is the entity type of the statistics of a whole GitLab instance.
+const EntityInstance = "instance"
+
+// DefaultProvider is the provider of the instances and entities recorded
+// before other forges were supported.
+const DefaultProvider = "gitlab"
+
+// LegacyInstanceURI is the URI of the legacy GitLab instance when the
+// database has no GitLab instance: the URI of the instance the entities were
+// collected from is unknown until the first GitLab instance is registered,
+// which takes the legacy instance over.
+const LegacyInstanceURI = ""
+
+var (
+	// ErrInstanceNotFound is returned when no instance has the requested URI.
+	ErrInstanceNotFound = errors.New("instance not found")
+	// ErrInstanceProviderMismatch is returned when an instance is registered
+	// with another provider than the one it was created with.
+	ErrInstanceProviderMismatch = errors.New("instance registered with another provider")
+)
+
+// Instance is a GitLab instance or another forge.
+type Instance struct {
+	ID       int64
+	URI      string
+	Provider string
+}
+
+// GetOrCreateInstance returns the ID of the GitLab instance with the given URI, creating it if needed.
+func (s *Storage) GetOrCreateInstance(ctx context.Context, uri string) (int64, error) {
+	return s.RegisterInstance(ctx, DefaultProvider, uri)
+}
+
+// RegisterInstance returns the ID of the instance of the provider with the
+// given URI, creating it if needed.
+func (s *Storage) RegisterInstance(ctx context.Context, provider string, uri string) (int64, error) {
+	var id int64
+	err := s.withTx(ctx, func(q *database.Queries) error {
+		var err error
+		id, err = getOrCreateInstance(ctx, q, provider, uri)
+		return err
+	})
+	return id, err
+}
+
+// FindInstance returns the ID of the instance of the provider with the given
+// URI without creating it. A GitLab instance which was never registered is
+// the legacy instance if the database has one, as RegisterInstance would make
+// it. ErrInstanceNotFound is returned otherwise.
+func (s *Storage) FindInstance(ctx context.Context, provider string, uri string) (int64, error) {
+	uri = normalizeInstanceURI(uri)
+	instance, err := s.queries.GetInstanceByURI(ctx, uri)
+	if errors.Is(err, sql.ErrNoRows) && provider == DefaultProvider {
+		instance, err = s.queries.GetInstanceByURI(ctx, LegacyInstanceURI)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: %s", ErrInstanceNotFound, uri)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get instance: %w", err)
+	}
+	if instance.Provider != provider {
+		return 0, fmt.Errorf("%w: %s is a %s instance", ErrInstanceProviderMismatch, uri, instance.Provider)
+	}
+	return instance.ID, nil
+}
+
+func getOrCreateInstance(ctx context.Context, q *database.Queries, provider string, uri string) (int64, error) {
+	uri = normalizeInstanceURI(uri)
+	instance, err := q.GetInstanceByURI(ctx, uri)
+	if err == nil {
+		if instance.Provider != provider {
+			return 0, fmt.Errorf("%w: %s is a %s instance", ErrInstanceProviderMismatch, uri, instance.Provider)
+		}
+		return instance.ID, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to get instance: %w", err)
+	}
+	if provider == DefaultProvider && uri != LegacyInstanceURI {
+		id, adopted, err := adoptLegacyInstance(ctx, q, uri)
+		if err != nil || adopted {
+			return id, err
+		}
+	}
+	id, err := q.InsertNewInstance(ctx, database.InsertNewInstanceParams{Uri: uri, Provider: provider})
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert new instance: %w", err)
+	}
+	return id, nil
+}
+
+// adoptLegacyInstance gives uri to the legacy GitLab instance, if the
+// database has one, so that the GitLab instance first registered keeps the
+// entities recorded before the instances were known.
+func adoptLegacyInstance(ctx context.Context, q *database.Queries, uri string) (int64, bool, error) {
+	legacy, err := q.GetInstanceByURI(ctx, LegacyInstanceURI)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get legacy instance: %w", err)
+	}
+	if err := q.UpdateInstanceURI(ctx, database.UpdateInstanceURIParams{Uri: uri, ID: legacy.ID}); err != nil {
+		return 0, false, fmt.Errorf("failed to update legacy instance: %w", err)
+	}
+	return legacy.ID, true, nil
+}
+
+// LegacyInstance returns the ID of the GitLab instance of the entities
+// recorded without instance: the first GitLab instance of the database,
+// LegacyInstanceURI if there is none.
+func (s *Storage) LegacyInstance(ctx context.Context) (int64, error) {
+	return legacyInstance(ctx, s.queries)
+}
+
+func legacyInstance(ctx context.Context, q *database.Queries) (int64, error) {
+	instance, err := q.GetFirstInstanceOfProvider(ctx, DefaultProvider)
+	if err == nil {
+		return instance.ID, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to get GitLab instance: %w", err)
+	}
+	return getOrCreateInstance(ctx, q, DefaultProvider, LegacyInstanceURI)
+}
+
+// instanceOrLegacy returns instanceID, or the legacy GitLab instance for 0.
+func instanceOrLegacy(ctx context.Context, q *database.Queries, instanceID int64) (int64, error) {
+	if instanceID != 0 {
+		return instanceID, nil
+	}
+	return legacyInstance(ctx, q)
+}
+
+// GetInstance returns the instance with the given ID.
+func (s *Storage) GetInstance(ctx context.Context, id int64) (Instance, error) {
+	instance, err := s.queries.GetInstanceByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Instance{}, fmt.Errorf("%w: %d", ErrInstanceNotFound, id)
+	}
+	if err != nil {
+		return Instance{}, fmt.Errorf("failed to get instance: %w", err)
+	}
+	return Instance{ID: instance.ID, URI: instance.Uri, Provider: instance.Provider}, nil
+}
+
+// GetInstanceID returns the ID of the instance with the given URI.
+func (s *Storage) GetInstanceID(ctx context.Context, uri string) (int64, error) {
+	instance, err := s.queries.GetInstanceByURI(ctx, normalizeInstanceURI(uri))
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: %s", ErrInstanceNotFound, uri)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get instance: %w", err)
+	}
+	return instance.ID, nil
+}
+
+// AddInstanceStats adds statistics of a whole instance in a single transaction.
+func (s *Storage) AddInstanceStats(
+	ctx context.Context,
+	uri string,
+	opened int64,
+	closed int64,
+	total int64,
+	dateExec *carbon.Carbon,
+) error {
+	return s.withTx(ctx, func(q *database.Queries) error {
+		instanceID, err := getOrCreateInstance(ctx, q, DefaultProvider, uri)
+		if err != nil {
+			return err
+		}
+		_, err = addInstanceStats(ctx, q, instanceID, opened, closed, total, dateExec)
+		return err
+	})
+}
+
+// AddInstanceStatsByID adds statistics of the instance with the given ID in a
+// single transaction, whatever its provider.
+func (s *Storage) AddInstanceStatsByID(
+	ctx context.Context,
+	instanceID int64,
+	opened int64,
+	closed int64,
+	total int64,
+	dateExec *carbon.Carbon,
+) error {
+	return s.withTx(ctx, func(q *database.Queries) error {
+		_, err := addInstanceStats(ctx, q, instanceID, opened, closed, total, dateExec)
+		return err
+	})
+}
+
+// addInstanceStats adds statistics of an instance and returns their ID.
+func addInstanceStats(
+	ctx context.Context,
+	q *database.Queries,
+	instanceID int64,
+	opened int64,
+	closed int64,
+	total int64,
+	dateExec *carbon.Carbon,
+) (int64, error) {
+	statsID, err := q.InsertNewStats(ctx, database.InsertNewStatsParams{
+		Total:    total,
+		Closed:   closed,
+		Opened:   opened,
+		DateExec: dateExec.StdTime(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert new stats: %w", err)
+	}
+	_, err = q.InsertStatsInstances(ctx, database.InsertStatsInstancesParams{
+		Instanceid: instanceID,
+		Statsid:    statsID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert stats instances: %w", err)
+	}
+	return statsID, nil
+}
+
+// GetEnhancedStatsByInstance gets enhanced statistics of the instance with the given URI.
+func (s *Storage) GetEnhancedStatsByInstance(
+	ctx context.Context,
+	uri string,
+	beginDate *carbon.Carbon,
+	endDate *carbon.Carbon,
+) (*EnhancedStats, error) {
+	instanceID, err := s.GetInstanceID(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetEnhancedStatsByInstanceID(ctx, instanceID, beginDate, endDate)
+}
+
+// GetEnhancedStatsByInstanceID gets enhanced statistics of the instance with the given ID.
+func (s *Storage) GetEnhancedStatsByInstanceID(
+	ctx context.Context,
+	instanceID int64,
+	beginDate *carbon.Carbon,
+	endDate *carbon.Carbon,
+) (*EnhancedStats, error) {
+	stats, err := s.queries.GetEnhancedStatsByInstanceID(ctx, database.GetEnhancedStatsByInstanceIDParams{
+		Instanceid: instanceID,
+		Begindate:  beginDate.StdTime(),
+		Enddate:    endDate.StdTime(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enhanced stats by instance: %w", err)
+	}
+	return processEnhancedStatsInstance(stats), nil
+}
+
+func processEnhancedStatsInstance(stats []database.GetEnhancedStatsByInstanceIDRow) *EnhancedStats {
+	count := len(stats)
+	totalOpened := make([]interface{}, count)
+	currentOpened := make([]interface{}, count)
+	currentClosed := make([]interface{}, count)
+	prevTotal := make([]interface{}, count)
+	prevClosed := make([]interface{}, count)
+	dateExec := make([]interface{}, count)
+	reconstructed := make([]interface{}, count)
+
+	for i, stat := range stats {
+		totalOpened[i] = stat.TotalOpened
+		currentOpened[i] = stat.CurrentOpened
+		currentClosed[i] = stat.CurrentClosed
+		prevTotal[i] = stat.PrevTotal
+		prevClosed[i] = stat.PrevClosed
+		dateExec[i] = stat.DateExec
+		reconstructed[i] = stat.Reconstructed
+	}
+
+	return processEnhancedStatsGeneric(totalOpened, currentOpened, currentClosed, prevTotal, prevClosed, dateExec, reconstructed)
+}
+
+// normalizeInstanceURI makes equivalent URIs of an instance share the same key.
+func normalizeInstanceURI(uri string) string {
+	return strings.TrimSuffix(strings.TrimSpace(uri), "/")
+}
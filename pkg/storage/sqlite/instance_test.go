@@ -0,0 +1,195 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/amacneil/dbmate/v2/pkg/dbmate"
+	"github.com/golang-module/carbon/v2"
+	"github.com/sgaunet/gitlab-stats/pkg/storage/sqlite"
+)
+
+func TestInstanceStats(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	now := carbon.Now()
+	if err := s.AddInstanceStats(ctx, "https://gitlab.example.com/", 5, 6, 11, now); err != nil {
+		t.Fatalf("err returned by AddInstanceStats(): %v", err.Error())
+	}
+	stats, err := s.GetEnhancedStatsByInstance(ctx, "https://gitlab.example.com", carbon.Now().SubMonth(), carbon.Now().AddMonth())
+	if err != nil {
+		t.Fatalf("err returned by GetEnhancedStatsByInstance(): %v", err.Error())
+	}
+	if len(stats.TotalOpenedSeries) != 1 || stats.TotalOpenedSeries[0] != 5 {
+		t.Errorf("GetEnhancedStatsByInstance() opened = %v, want [5]", stats.TotalOpenedSeries)
+	}
+	_, err = s.GetEnhancedStatsByInstance(ctx, "https://other.example.com", carbon.Now().SubMonth(), carbon.Now().AddMonth())
+	if !errors.Is(err, sqlite.ErrInstanceNotFound) {
+		t.Errorf("GetEnhancedStatsByInstance() error = %v, want %v", err, sqlite.ErrInstanceNotFound)
+	}
+
+	// instances of other providers are given by ID
+	githubID, err := s.RegisterInstance(ctx, "github", "https://github.com")
+	if err != nil {
+		t.Fatalf("err returned by RegisterInstance(): %v", err.Error())
+	}
+	if err := s.AddInstanceStatsByID(ctx, githubID, 7, 1, 8, now); err != nil {
+		t.Fatalf("err returned by AddInstanceStatsByID(): %v", err.Error())
+	}
+	stats, err = s.GetEnhancedStatsByInstanceID(ctx, githubID, carbon.Now().SubMonth(), carbon.Now().AddMonth())
+	if err != nil {
+		t.Fatalf("err returned by GetEnhancedStatsByInstanceID(): %v", err.Error())
+	}
+	if len(stats.TotalOpenedSeries) != 1 || stats.TotalOpenedSeries[0] != 7 {
+		t.Errorf("GetEnhancedStatsByInstanceID() opened = %v, want [7]", stats.TotalOpenedSeries)
+	}
+}
+
+// newLegacyDatabase creates a database migrated up to the last version before
+// the entities were keyed by instance, with project 42 and one snapshot.
+func newLegacyDatabase(t *testing.T) string {
+	t.Helper()
+	dbFile := filepath.Join(t.TempDir(), "db.sqlite3")
+	entries, err := os.ReadDir("db/migrations")
+	if err != nil {
+		t.Fatalf("err returned by ReadDir(): %v", err.Error())
+	}
+	migrations := fstest.MapFS{}
+	for _, entry := range entries {
+		if entry.Name() >= "20261017160000" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join("db/migrations", entry.Name()))
+		if err != nil {
+			t.Fatalf("err returned by ReadFile(): %v", err.Error())
+		}
+		migrations["db/migrations/"+entry.Name()] = &fstest.MapFile{Data: data}
+	}
+	u, _ := url.Parse("sqlite://" + dbFile)
+	m := dbmate.New(u)
+	m.FS = migrations
+	m.AutoDumpSchema = false
+	m.Log = &strings.Builder{}
+	if err := m.CreateAndMigrate(); err != nil {
+		t.Fatalf("err returned by CreateAndMigrate(): %v", err.Error())
+	}
+
+	db, err := sql.Open("sqlite", dbFile)
+	if err != nil {
+		t.Fatalf("err returned by Open(): %v", err.Error())
+	}
+	defer db.Close()
+	for _, query := range []string{
+		"INSERT INTO projects (id, project_name, full_path) VALUES (42, 'proj', 'grp/proj')",
+		"INSERT INTO stats (id, date_exec, total, closed, opened) VALUES (1, datetime('now'), 3, 1, 2)",
+		"INSERT INTO stats_projects (projectId, statsId) VALUES (42, 1)",
+	} {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatalf("err returned by Exec(%q): %v", query, err.Error())
+		}
+	}
+	return dbFile
+}
+
+func TestUpgradeSelfHostedDatabase(t *testing.T) {
+	ctx := context.Background()
+	s, err := sqlite.NewStorage(newLegacyDatabase(t))
+	if err != nil {
+		t.Fatalf("err returned by NewStorage(): %v", err.Error())
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(); err != nil {
+		t.Fatalf("err returned by Migrate(): %v", err.Error())
+	}
+
+	// a graph before the first run finds the legacy instance without registering it
+	legacyID, err := s.FindInstance(ctx, sqlite.DefaultProvider, "https://gitlab.example.com")
+	if err != nil {
+		t.Fatalf("err returned by FindInstance(): %v", err.Error())
+	}
+	if _, err := s.GetInstanceID(ctx, "https://gitlab.example.com"); !errors.Is(err, sqlite.ErrInstanceNotFound) {
+		t.Errorf("GetInstanceID() error = %v, want %v", err, sqlite.ErrInstanceNotFound)
+	}
+	if _, err := s.FindInstance(ctx, "github", "https://github.com"); !errors.Is(err, sqlite.ErrInstanceNotFound) {
+		t.Errorf("FindInstance(github) error = %v, want %v", err, sqlite.ErrInstanceNotFound)
+	}
+
+	// first run with GITLAB_URI on the self-hosted server
+	instanceID, err := s.RegisterInstance(ctx, sqlite.DefaultProvider, "https://gitlab.example.com/")
+	if err != nil {
+		t.Fatalf("err returned by RegisterInstance(): %v", err.Error())
+	}
+	if instanceID != legacyID {
+		t.Errorf("RegisterInstance() = %d, want the legacy instance %d", instanceID, legacyID)
+	}
+	instance, err := s.GetInstance(ctx, instanceID)
+	if err != nil {
+		t.Fatalf("err returned by GetInstance(): %v", err.Error())
+	}
+	if instance.URI != "https://gitlab.example.com" {
+		t.Errorf("GetInstance() URI = %q, want https://gitlab.example.com", instance.URI)
+	}
+	project, err := s.GetProject(ctx, instanceID, 42)
+	if err != nil {
+		t.Fatalf("err returned by GetProject(): %v", err.Error())
+	}
+	stats, err := s.GetEnhancedStatsByProjectID(project.Key, carbon.Now().SubMonth(), carbon.Now().AddMonth())
+	if err != nil {
+		t.Fatalf("err returned by GetEnhancedStatsByProjectID(): %v", err.Error())
+	}
+	if len(stats.TotalOpenedSeries) != 1 || stats.TotalOpenedSeries[0] != 2 {
+		t.Errorf("GetEnhancedStatsByProjectID() opened = %v, want [2]", stats.TotalOpenedSeries)
+	}
+
+	// another GitLab instance does not take the legacy entities
+	otherID, err := s.RegisterInstance(ctx, sqlite.DefaultProvider, "https://gitlab.com")
+	if err != nil {
+		t.Fatalf("err returned by RegisterInstance(): %v", err.Error())
+	}
+	if otherID == instanceID {
+		t.Errorf("RegisterInstance() = %d, want another instance than %d", otherID, instanceID)
+	}
+}
+
+func TestUpgradeChecksForeignKeys(t *testing.T) {
+	dbFile := newLegacyDatabase(t)
+	db, err := sql.Open("sqlite", dbFile)
+	if err != nil {
+		t.Fatalf("err returned by Open(): %v", err.Error())
+	}
+	// statistics of a project which does not exist
+	_, err = db.Exec("INSERT INTO stats_projects (projectId, statsId) VALUES (7, 1)")
+	_ = db.Close()
+	if err != nil {
+		t.Fatalf("err returned by Exec(): %v", err.Error())
+	}
+
+	s, err := sqlite.NewStorage(dbFile)
+	if err != nil {
+		t.Fatalf("err returned by NewStorage(): %v", err.Error())
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(); err == nil {
+		t.Fatalf("Migrate() should refuse a statistic of a missing project")
+	}
+
+	// the tables are left as they were
+	db, err = sql.Open("sqlite", dbFile)
+	if err != nil {
+		t.Fatalf("err returned by Open(): %v", err.Error())
+	}
+	defer db.Close()
+	var columns int
+	err = db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('projects') WHERE name = 'remote_id'").Scan(&columns)
+	if err != nil || columns != 0 {
+		t.Errorf("remote_id columns of projects = %d, %v, want 0", columns, err)
+	}
+}
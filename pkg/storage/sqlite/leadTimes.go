@@ -0,0 +1,118 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang-module/carbon/v2"
+	"github.com/sgaunet/gitlab-stats/internal/database"
+)
+
+// leadTimePeriodLayout is the layout of the month of the lead times.
+const leadTimePeriodLayout = "2006-01"
+
+// LeadTime is the distribution of the lead time, in days, of the issues
+// closed during a month.
+type LeadTime struct {
+	// Month is the first day of the month.
+	Month time.Time
+	// Label is empty for all the issues.
+	Label  string
+	Closed int64
+	P50    float64
+	P85    float64
+	P95    float64
+}
+
+// SaveLeadTimes stores the lead times of the project or group with the given
+// key for the months of beginDate to endDate included. They replace all the
+// lead times of these months, so that a label without closed issues anymore
+// does not keep the percentiles of a previous run.
+func (s *Storage) SaveLeadTimes(
+	ctx context.Context,
+	entityType string,
+	key int64,
+	beginDate *carbon.Carbon,
+	endDate *carbon.Carbon,
+	leadTimes []LeadTime,
+	dateExec *carbon.Carbon,
+) error {
+	return s.withTx(ctx, func(q *database.Queries) error {
+		var err error
+		switch entityType {
+		case EntityProject:
+			err = checkProject(ctx, q, key)
+		case EntityGroup:
+			err = checkGroup(ctx, q, key)
+		default:
+			err = fmt.Errorf("%w: %q", ErrUnsupportedEntityType, entityType)
+		}
+		if err != nil {
+			return err
+		}
+		err = q.DeleteLeadTimes(ctx, database.DeleteLeadTimesParams{
+			Entitytype:  entityType,
+			Entityid:    key,
+			Beginperiod: beginDate.StdTime().Format(leadTimePeriodLayout),
+			Endperiod:   endDate.StdTime().Format(leadTimePeriodLayout),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete lead times: %w", err)
+		}
+		for _, leadTime := range leadTimes {
+			err := q.UpsertLeadTime(ctx, database.UpsertLeadTimeParams{
+				EntityType: entityType,
+				EntityID:   key,
+				Period:     leadTime.Month.Format(leadTimePeriodLayout),
+				Label:      leadTime.Label,
+				Closed:     leadTime.Closed,
+				P50:        leadTime.P50,
+				P85:        leadTime.P85,
+				P95:        leadTime.P95,
+				DateExec:   dateExec.StdTime(),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to upsert lead time: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetLeadTimes returns the lead times of the project or group with the given
+// key from the month of beginDate to the month of endDate included, ordered
+// by month then label. The months are in the local time zone.
+func (s *Storage) GetLeadTimes(
+	ctx context.Context,
+	entityType string,
+	key int64,
+	beginDate *carbon.Carbon,
+	endDate *carbon.Carbon,
+) ([]LeadTime, error) {
+	rows, err := s.queries.GetLeadTimes(ctx, database.GetLeadTimesParams{
+		Entitytype:  entityType,
+		Entityid:    key,
+		Beginperiod: beginDate.StdTime().Format(leadTimePeriodLayout),
+		Endperiod:   endDate.StdTime().Format(leadTimePeriodLayout),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lead times: %w", err)
+	}
+	leadTimes := make([]LeadTime, 0, len(rows))
+	for _, row := range rows {
+		month, err := time.ParseInLocation(leadTimePeriodLayout, row.Period, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the month of a lead time: %w", err)
+		}
+		leadTimes = append(leadTimes, LeadTime{
+			Month:  month,
+			Label:  row.Label,
+			Closed: row.Closed,
+			P50:    row.P50,
+			P85:    row.P85,
+			P95:    row.P95,
+		})
+	}
+	return leadTimes, nil
+}
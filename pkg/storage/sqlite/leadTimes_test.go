@@ -0,0 +1,65 @@
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang-module/carbon/v2"
+	"github.com/sgaunet/gitlab-stats/pkg/storage/sqlite"
+)
+
+func TestLeadTimes(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	key, err := s.ProjectKey(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("err returned by ProjectKey(): %v", err.Error())
+	}
+	lastMonth := carbon.Now().SubMonth().StartOfMonth()
+	thisMonth := carbon.Now().StartOfMonth()
+	leadTimes := []sqlite.LeadTime{
+		{Month: lastMonth.StdTime(), Closed: 3, P50: 1, P85: 4, P95: 9},
+		{Month: thisMonth.StdTime(), Closed: 2, P50: 2, P85: 3, P95: 3},
+		{Month: thisMonth.StdTime(), Label: "bug", Closed: 1, P50: 3, P85: 3, P95: 3},
+	}
+	if err := s.SaveLeadTimes(ctx, sqlite.EntityProject, key, lastMonth, thisMonth, leadTimes, carbon.Now()); err != nil {
+		t.Fatalf("err returned by SaveLeadTimes(): %v", err.Error())
+	}
+	// the lead times of the current month are recomputed by the next run:
+	// the bug issue was reopened, its label has no lead time anymore
+	update := []sqlite.LeadTime{{Month: thisMonth.StdTime(), Closed: 4, P50: 2, P85: 5, P95: 8}}
+	if err := s.SaveLeadTimes(ctx, sqlite.EntityProject, key, thisMonth, thisMonth, update, carbon.Now()); err != nil {
+		t.Fatalf("err returned by SaveLeadTimes(): %v", err.Error())
+	}
+
+	got, err := s.GetLeadTimes(ctx, sqlite.EntityProject, key, lastMonth, thisMonth.EndOfMonth())
+	if err != nil {
+		t.Fatalf("err returned by GetLeadTimes(): %v", err.Error())
+	}
+	want := []sqlite.LeadTime{leadTimes[0], update[0]}
+	if len(got) != len(want) {
+		t.Fatalf("GetLeadTimes() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if !got[i].Month.Equal(want[i].Month) || got[i].Label != want[i].Label || got[i].Closed != want[i].Closed ||
+			got[i].P50 != want[i].P50 || got[i].P85 != want[i].P85 || got[i].P95 != want[i].P95 {
+			t.Errorf("GetLeadTimes()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	got, err = s.GetLeadTimes(ctx, sqlite.EntityGroup, key, lastMonth, thisMonth.EndOfMonth())
+	if err != nil || len(got) != 0 {
+		t.Errorf("GetLeadTimes(group) = %+v, %v, want none", got, err)
+	}
+	got, err = s.GetLeadTimes(ctx, sqlite.EntityProject, key, carbon.Now().StartOfMonth().AddMonth(), carbon.Now().EndOfMonth().AddMonthsNoOverflow(2))
+	if err != nil || len(got) != 0 {
+		t.Errorf("GetLeadTimes(next months) = %+v, %v, want none", got, err)
+	}
+
+	err = s.SaveLeadTimes(ctx, sqlite.EntityInstance, 1, lastMonth, thisMonth, leadTimes, carbon.Now())
+	if !errors.Is(err, sqlite.ErrUnsupportedEntityType) {
+		t.Errorf("SaveLeadTimes(instance) error = %v, want %v", err, sqlite.ErrUnsupportedEntityType)
+	}
+}
@@ -0,0 +1,159 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang-module/carbon/v2"
+	"github.com/sgaunet/gitlab-stats/internal/database"
+)
+
+// MergeRequestStats represents a snapshot of merge request counts per state.
+type MergeRequestStats struct {
+	Total  int64
+	Opened int64
+	Merged int64
+	Closed int64
+	Locked int64
+}
+
+// MergeRequestSeries represents the series for merge request graphing.
+type MergeRequestSeries struct {
+	OpenedSeries       []float64
+	MergedDuringPeriod []float64
+	ClosedDuringPeriod []float64
+	DateExecSeries     []time.Time
+}
+
+// AddProjectMergeRequestStats adds merge request statistics for the project with the given key in a single transaction.
+func (s *Storage) AddProjectMergeRequestStats(
+	ctx context.Context,
+	projectKey int64,
+	stats MergeRequestStats,
+	dateExec *carbon.Carbon,
+) error {
+	return s.withTx(ctx, func(q *database.Queries) error {
+		if err := checkProject(ctx, q, projectKey); err != nil {
+			return err
+		}
+		mrStatsID, err := insertMergeRequestStats(ctx, q, stats, dateExec)
+		if err != nil {
+			return err
+		}
+		_, err = q.InsertMRStatsProjects(ctx, database.InsertMRStatsProjectsParams{
+			Projectid: projectKey,
+			Mrstatsid: mrStatsID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to insert merge request stats projects: %w", err)
+		}
+		return nil
+	})
+}
+
+// AddGroupMergeRequestStats adds merge request statistics for the group with the given key in a single transaction.
+func (s *Storage) AddGroupMergeRequestStats(
+	ctx context.Context,
+	groupKey int64,
+	stats MergeRequestStats,
+	dateExec *carbon.Carbon,
+) error {
+	return s.withTx(ctx, func(q *database.Queries) error {
+		if err := checkGroup(ctx, q, groupKey); err != nil {
+			return err
+		}
+		mrStatsID, err := insertMergeRequestStats(ctx, q, stats, dateExec)
+		if err != nil {
+			return err
+		}
+		_, err = q.InsertMRStatsGroups(ctx, database.InsertMRStatsGroupsParams{
+			Groupid:   groupKey,
+			Mrstatsid: mrStatsID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to insert merge request stats groups: %w", err)
+		}
+		return nil
+	})
+}
+
+func insertMergeRequestStats(
+	ctx context.Context,
+	q *database.Queries,
+	stats MergeRequestStats,
+	dateExec *carbon.Carbon,
+) (int64, error) {
+	id, err := q.InsertNewMRStats(ctx, database.InsertNewMRStatsParams{
+		Total:    stats.Total,
+		Opened:   stats.Opened,
+		Merged:   stats.Merged,
+		Closed:   stats.Closed,
+		Locked:   stats.Locked,
+		DateExec: dateExec.StdTime(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert new merge request stats: %w", err)
+	}
+	return id, nil
+}
+
+// GetMergeRequestStatsByProjectID gets monthly merge request series of the project with the given key.
+func (s *Storage) GetMergeRequestStatsByProjectID(
+	ctx context.Context,
+	projectKey int64,
+	beginDate *carbon.Carbon,
+	endDate *carbon.Carbon,
+) (*MergeRequestSeries, error) {
+	stats, err := s.queries.GetMRStatsByProjectID(ctx, database.GetMRStatsByProjectIDParams{
+		Projectid: projectKey,
+		Begindate: beginDate.StdTime(),
+		Enddate:   endDate.StdTime(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merge request stats by project ID: %w", err)
+	}
+	series := &MergeRequestSeries{}
+	for _, stat := range stats {
+		series.append(stat.CurrentOpened, stat.CurrentMerged, stat.CurrentClosed,
+			stat.PrevMerged, stat.PrevClosed, stat.DateExec)
+	}
+	return series, nil
+}
+
+// GetMergeRequestStatsByGroupID gets monthly merge request series of the group with the given key.
+func (s *Storage) GetMergeRequestStatsByGroupID(
+	ctx context.Context,
+	groupKey int64,
+	beginDate *carbon.Carbon,
+	endDate *carbon.Carbon,
+) (*MergeRequestSeries, error) {
+	stats, err := s.queries.GetMRStatsByGroupID(ctx, database.GetMRStatsByGroupIDParams{
+		Groupid:   groupKey,
+		Begindate: beginDate.StdTime(),
+		Enddate:   endDate.StdTime(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merge request stats by group ID: %w", err)
+	}
+	series := &MergeRequestSeries{}
+	for _, stat := range stats {
+		series.append(stat.CurrentOpened, stat.CurrentMerged, stat.CurrentClosed,
+			stat.PrevMerged, stat.PrevClosed, stat.DateExec)
+	}
+	return series, nil
+}
+
+// append adds a period to the series, skipping periods with an invalid date.
+func (m *MergeRequestSeries) append(opened, merged, closed, prevMerged, prevClosed, dateExec interface{}) {
+	dateTime := convertToTime(dateExec)
+	if dateTime.IsZero() {
+		return
+	}
+	m.OpenedSeries = append(m.OpenedSeries, float64(convertToInt64(opened)))
+	m.MergedDuringPeriod = append(m.MergedDuringPeriod,
+		float64(convertToInt64(merged)-convertToInt64(prevMerged)))
+	m.ClosedDuringPeriod = append(m.ClosedDuringPeriod,
+		float64(convertToInt64(closed)-convertToInt64(prevClosed)))
+	m.DateExecSeries = append(m.DateExecSeries, dateTime.UTC())
+}
@@ -0,0 +1,57 @@
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang-module/carbon/v2"
+	"github.com/sgaunet/gitlab-stats/pkg/storage/sqlite"
+)
+
+func TestMergeRequestStats(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	key, err := s.GroupKey(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("err returned by GroupKey(): %v", err.Error())
+	}
+	lastMonth := carbon.Now().SubMonth().StartOfMonth()
+	thisMonth := carbon.Now().StartOfMonth()
+	err = s.AddGroupMergeRequestStats(ctx, key,
+		sqlite.MergeRequestStats{Total: 10, Opened: 2, Merged: 7, Closed: 1}, lastMonth)
+	if err != nil {
+		t.Fatalf("err returned by AddGroupMergeRequestStats(): %v", err.Error())
+	}
+	err = s.AddGroupMergeRequestStats(ctx, key,
+		sqlite.MergeRequestStats{Total: 15, Opened: 3, Merged: 10, Closed: 2}, thisMonth)
+	if err != nil {
+		t.Fatalf("err returned by AddGroupMergeRequestStats(): %v", err.Error())
+	}
+
+	series, err := s.GetMergeRequestStatsByGroupID(ctx, key, lastMonth, thisMonth.EndOfMonth())
+	if err != nil {
+		t.Fatalf("err returned by GetMergeRequestStatsByGroupID(): %v", err.Error())
+	}
+	if len(series.DateExecSeries) != 2 {
+		t.Fatalf("GetMergeRequestStatsByGroupID() returned %d periods, want 2: %+v", len(series.DateExecSeries), series)
+	}
+	if series.OpenedSeries[1] != 3 || series.MergedDuringPeriod[1] != 3 || series.ClosedDuringPeriod[1] != 1 {
+		t.Errorf("GetMergeRequestStatsByGroupID() = %+v", series)
+	}
+
+	project, err := s.GetMergeRequestStatsByProjectID(ctx, key, lastMonth, thisMonth.EndOfMonth())
+	if err != nil {
+		t.Fatalf("err returned by GetMergeRequestStatsByProjectID(): %v", err.Error())
+	}
+	if len(project.DateExecSeries) != 0 {
+		t.Errorf("GetMergeRequestStatsByProjectID() returned %d periods, want 0", len(project.DateExecSeries))
+	}
+
+	// statistics are only added to known groups
+	err = s.AddGroupMergeRequestStats(ctx, key+1, sqlite.MergeRequestStats{Total: 1}, thisMonth)
+	if !errors.Is(err, sqlite.ErrGroupNotFound) {
+		t.Errorf("AddGroupMergeRequestStats(unknown group) error = %v, want %v", err, sqlite.ErrGroupNotFound)
+	}
+}
@@ -0,0 +1,141 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang-module/carbon/v2"
+	"github.com/sgaunet/gitlab-stats/internal/database"
+)
+
+// OpenIssueAges is the number of open issues of a snapshot per age, from
+// their creation.
+type OpenIssueAges struct {
+	// UnderWeek counts the issues created less than 7 days ago.
+	UnderWeek int64
+	// WeekToMonth counts the issues created 7 to 30 days ago.
+	WeekToMonth int64
+	// MonthToQuarter counts the issues created 30 to 90 days ago.
+	MonthToQuarter int64
+	// QuarterToYear counts the issues created 90 to 365 days ago.
+	QuarterToYear int64
+	// OverYear counts the issues created more than 365 days ago.
+	OverYear int64
+}
+
+// OpenIssueAgesSeries represents the monthly series of the age of the open
+// issues, from the last snapshot of each month with ages.
+type OpenIssueAgesSeries struct {
+	UnderWeek      []float64
+	WeekToMonth    []float64
+	MonthToQuarter []float64
+	QuarterToYear  []float64
+	OverYear       []float64
+	DateExecSeries []time.Time
+}
+
+// AddSnapshotWithAges adds a snapshot of the project, group, instance or series
+// with the given key in a single transaction, with the age of its open issues.
+// The key of an instance or a series is its ID.
+func (s *Storage) AddSnapshotWithAges(
+	ctx context.Context,
+	entityType string,
+	key int64,
+	snapshot Snapshot,
+	ages OpenIssueAges,
+) error {
+	dateExec := carbon.CreateFromStdTime(snapshot.DateExec)
+	return s.withTx(ctx, func(q *database.Queries) error {
+		var statsID int64
+		var err error
+		switch entityType {
+		case EntityProject:
+			statsID, err = addProjectStats(ctx, q, key, snapshot.Opened, snapshot.Closed, snapshot.Total, dateExec)
+		case EntityGroup:
+			statsID, err = addGroupStats(ctx, q, key, snapshot.Opened, snapshot.Closed, snapshot.Total, dateExec)
+		case EntityInstance:
+			statsID, err = addInstanceStats(ctx, q, key, snapshot.Opened, snapshot.Closed, snapshot.Total, dateExec)
+		case EntitySeries:
+			statsID, err = addSeriesStats(ctx, q, key, snapshot.Opened, snapshot.Closed, snapshot.Total, dateExec)
+		default:
+			return fmt.Errorf("%w: %q", ErrUnsupportedEntityType, entityType)
+		}
+		if err != nil {
+			return err
+		}
+		return insertOpenIssueAges(ctx, q, statsID, ages)
+	})
+}
+
+func insertOpenIssueAges(ctx context.Context, q *database.Queries, statsID int64, ages OpenIssueAges) error {
+	_, err := q.InsertOpenIssueAges(ctx, database.InsertOpenIssueAgesParams{
+		Statsid:     statsID,
+		Under7Days:  ages.UnderWeek,
+		Days7To30:   ages.WeekToMonth,
+		Days30To90:  ages.MonthToQuarter,
+		Days90To365: ages.QuarterToYear,
+		Over365Days: ages.OverYear,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert open issue ages: %w", err)
+	}
+	return nil
+}
+
+// GetOpenIssueAges gets the monthly series of the age of the open issues of
+// the project, group, instance or series with the given key.
+func (s *Storage) GetOpenIssueAges(
+	ctx context.Context,
+	entityType string,
+	key int64,
+	beginDate *carbon.Carbon,
+	endDate *carbon.Carbon,
+) (*OpenIssueAgesSeries, error) {
+	switch entityType {
+	case EntityProject, EntityGroup, EntityInstance, EntitySeries:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedEntityType, entityType)
+	}
+	rows, err := s.queries.GetOpenIssueAges(ctx, database.GetOpenIssueAgesParams{
+		Begindate:  beginDate.StdTime(),
+		Enddate:    endDate.StdTime(),
+		Entitytype: entityType,
+		Entityid:   key,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open issue ages: %w", err)
+	}
+
+	// rows are ordered by date: the last one of a month replaces the others
+	series := &OpenIssueAgesSeries{}
+	for _, row := range rows {
+		n := len(series.DateExecSeries)
+		if n > 0 && monthOf(series.DateExecSeries[n-1]) == monthOf(row.DateExec) {
+			series.truncate(n - 1)
+		}
+		series.UnderWeek = append(series.UnderWeek, float64(row.Under7Days))
+		series.WeekToMonth = append(series.WeekToMonth, float64(row.Days7To30))
+		series.MonthToQuarter = append(series.MonthToQuarter, float64(row.Days30To90))
+		series.QuarterToYear = append(series.QuarterToYear, float64(row.Days90To365))
+		series.OverYear = append(series.OverYear, float64(row.Over365Days))
+		series.DateExecSeries = append(series.DateExecSeries, row.DateExec.UTC())
+	}
+	return series, nil
+}
+
+// truncate keeps the n first periods of the series.
+func (a *OpenIssueAgesSeries) truncate(n int) {
+	a.UnderWeek = a.UnderWeek[:n]
+	a.WeekToMonth = a.WeekToMonth[:n]
+	a.MonthToQuarter = a.MonthToQuarter[:n]
+	a.QuarterToYear = a.QuarterToYear[:n]
+	a.OverYear = a.OverYear[:n]
+	a.DateExecSeries = a.DateExecSeries[:n]
+}
+
+// monthOf returns the month of a stored date, as grouped by the queries of the
+// monthly series.
+func monthOf(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
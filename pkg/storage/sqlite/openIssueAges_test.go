@@ -0,0 +1,95 @@
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang-module/carbon/v2"
+	"github.com/sgaunet/gitlab-stats/pkg/storage/sqlite"
+)
+
+func TestOpenIssueAges(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	key, err := s.ProjectKey(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("err returned by ProjectKey(): %v", err.Error())
+	}
+	other, err := s.ProjectKey(ctx, 0, 11)
+	if err != nil {
+		t.Fatalf("err returned by ProjectKey(): %v", err.Error())
+	}
+	lastMonth := carbon.Now().SubMonthNoOverflow().StartOfMonth()
+	ages := []sqlite.OpenIssueAges{
+		{UnderWeek: 1, WeekToMonth: 2, MonthToQuarter: 3, QuarterToYear: 4, OverYear: 5},
+		{UnderWeek: 2, WeekToMonth: 1, MonthToQuarter: 3, QuarterToYear: 4, OverYear: 6},
+	}
+	for i, a := range ages {
+		snapshot := sqlite.Snapshot{Opened: 16, Total: 16, DateExec: lastMonth.Copy().AddDays(i + 1).StdTime()}
+		if err := s.AddSnapshotWithAges(ctx, sqlite.EntityProject, key, snapshot, a); err != nil {
+			t.Fatalf("err returned by AddSnapshotWithAges(): %v", err.Error())
+		}
+	}
+	// snapshots without ages, of this month and of another project
+	if err := s.AddProjectStatsWithContext(ctx, 0, 10, 3, 0, 3, carbon.Now()); err != nil {
+		t.Fatalf("err returned by AddProjectStatsWithContext(): %v", err.Error())
+	}
+	snapshot := sqlite.Snapshot{Opened: 1, Total: 1, DateExec: lastMonth.StdTime()}
+	if err := s.AddSnapshotWithAges(ctx, sqlite.EntityProject, other, snapshot, ages[0]); err != nil {
+		t.Fatalf("err returned by AddSnapshotWithAges(): %v", err.Error())
+	}
+
+	series, err := s.GetOpenIssueAges(ctx, sqlite.EntityProject, key, lastMonth, carbon.Now().AddDay())
+	if err != nil {
+		t.Fatalf("err returned by GetOpenIssueAges(): %v", err.Error())
+	}
+	// the last snapshot of the month is kept
+	if len(series.DateExecSeries) != 1 || series.UnderWeek[0] != 2 || series.WeekToMonth[0] != 1 ||
+		series.MonthToQuarter[0] != 3 || series.QuarterToYear[0] != 4 || series.OverYear[0] != 6 {
+		t.Errorf("GetOpenIssueAges() = %+v, want the ages of the second snapshot", series)
+	}
+
+	series, err = s.GetOpenIssueAges(ctx, sqlite.EntityGroup, key, lastMonth, carbon.Now().AddDay())
+	if err != nil || len(series.DateExecSeries) != 0 {
+		t.Errorf("GetOpenIssueAges(group) = %+v, %v, want none", series, err)
+	}
+	if _, err := s.GetOpenIssueAges(ctx, "milestone", 10, lastMonth, carbon.Now()); !errors.Is(err, sqlite.ErrUnsupportedEntityType) {
+		t.Errorf("GetOpenIssueAges(milestone) error = %v, want %v", err, sqlite.ErrUnsupportedEntityType)
+	}
+	if err := s.AddSnapshotWithAges(ctx, "milestone", 10, snapshot, ages[0]); !errors.Is(err, sqlite.ErrUnsupportedEntityType) {
+		t.Errorf("AddSnapshotWithAges(milestone) error = %v, want %v", err, sqlite.ErrUnsupportedEntityType)
+	}
+}
+
+func TestOpenIssueAgesOfSeries(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	series, err := s.SaveSeries(ctx, "bugs", sqlite.EntityProject, 10, `{"labels":["bug"]}`)
+	if err != nil {
+		t.Fatalf("err returned by SaveSeries(): %v", err.Error())
+	}
+	lastMonth := carbon.Now().SubMonthNoOverflow().StartOfMonth()
+	snapshot := sqlite.Snapshot{Opened: 3, Total: 4, Closed: 1, DateExec: lastMonth.StdTime()}
+	ages := sqlite.OpenIssueAges{UnderWeek: 1, OverYear: 2}
+	if err := s.AddSnapshotWithAges(ctx, sqlite.EntitySeries, series.ID, snapshot, ages); err != nil {
+		t.Fatalf("err returned by AddSnapshotWithAges(): %v", err.Error())
+	}
+
+	got, err := s.GetOpenIssueAges(ctx, sqlite.EntitySeries, series.ID, lastMonth, carbon.Now().AddDay())
+	if err != nil {
+		t.Fatalf("err returned by GetOpenIssueAges(): %v", err.Error())
+	}
+	if len(got.DateExecSeries) != 1 || got.UnderWeek[0] != 1 || got.OverYear[0] != 2 {
+		t.Errorf("GetOpenIssueAges(series) = %+v, want the ages of the snapshot", got)
+	}
+	stats, err := s.GetEnhancedStatsBySeries(ctx, "bugs", lastMonth, carbon.Now().AddDay())
+	if err != nil {
+		t.Fatalf("err returned by GetEnhancedStatsBySeries(): %v", err.Error())
+	}
+	if len(stats.TotalOpenedSeries) != 1 || stats.TotalOpenedSeries[0] != 3 {
+		t.Errorf("GetEnhancedStatsBySeries() = %+v, want the snapshot", stats)
+	}
+}
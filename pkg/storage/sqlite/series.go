@@ -0,0 +1,198 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/golang-module/carbon/v2"
+	"github.com/sgaunet/gitlab-stats/internal/database"
+)
+
+// Entity types a series can be attached to.
+const (
+	EntityProject = "project"
+	EntityGroup   = "group"
+)
+
+// EntitySeries is the entity type of the snapshots of a series, whose key is
+// the ID of the series.
+const EntitySeries = "series"
+
+var (
+	// ErrSeriesNotFound is returned when no series has the requested name.
+	ErrSeriesNotFound = errors.New("series not found")
+	// ErrSeriesEntityMismatch is returned when a series is saved for another entity than the existing one.
+	ErrSeriesEntityMismatch = errors.New("series is already attached to another entity")
+	// ErrSeriesFilterMismatch is returned when a series is saved with another filter than the existing one.
+	ErrSeriesFilterMismatch = errors.New("series is already collected with another filter")
+)
+
+// Series is a named set of snapshots of an entity, collected with a persisted filter.
+// Snapshots of a series are kept apart from the unfiltered snapshots of the entity.
+type Series struct {
+	ID         int64
+	Name       string
+	EntityType string
+	EntityID   int64
+	// Filter is the serialized definition of the filter used to collect the series.
+	Filter string
+}
+
+// SaveSeries creates a series, or returns the existing series of the same
+// entity and filter: the snapshots of a series are all collected with the
+// same filter.
+func (s *Storage) SaveSeries(ctx context.Context, name string, entityType string, entityID int64, filter string) (Series, error) {
+	var series Series
+	err := s.withTx(ctx, func(q *database.Queries) error {
+		row, err := q.GetSeriesByName(ctx, name)
+		if errors.Is(err, sql.ErrNoRows) {
+			id, err := q.InsertNewSeries(ctx, database.InsertNewSeriesParams{
+				Name:       name,
+				EntityType: entityType,
+				EntityID:   entityID,
+				Filter:     filter,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to insert new series: %w", err)
+			}
+			series = Series{ID: id, Name: name, EntityType: entityType, EntityID: entityID, Filter: filter}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get series: %w", err)
+		}
+		series = Series(row)
+		if series.EntityType != entityType || series.EntityID != entityID {
+			return fmt.Errorf("%w: %s is attached to %s %d",
+				ErrSeriesEntityMismatch, name, series.EntityType, series.EntityID)
+		}
+		if series.Filter != filter {
+			return fmt.Errorf("%w: %s is collected with filter %s", ErrSeriesFilterMismatch, name, series.Filter)
+		}
+		return nil
+	})
+	if err != nil {
+		return Series{}, err
+	}
+	return series, nil
+}
+
+// GetSeries returns the series with the given name.
+func (s *Storage) GetSeries(ctx context.Context, name string) (Series, error) {
+	series, err := s.queries.GetSeriesByName(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Series{}, fmt.Errorf("%w: %s", ErrSeriesNotFound, name)
+	}
+	if err != nil {
+		return Series{}, fmt.Errorf("failed to get series: %w", err)
+	}
+	return Series(series), nil
+}
+
+// ListSeries returns all the series ordered by name.
+func (s *Storage) ListSeries(ctx context.Context) ([]Series, error) {
+	rows, err := s.queries.ListSeries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list series: %w", err)
+	}
+	series := make([]Series, 0, len(rows))
+	for _, row := range rows {
+		series = append(series, Series(row))
+	}
+	return series, nil
+}
+
+// AddSeriesStats adds a snapshot to the series with the given name in a single transaction.
+func (s *Storage) AddSeriesStats(
+	ctx context.Context,
+	name string,
+	opened int64,
+	closed int64,
+	total int64,
+	dateExec *carbon.Carbon,
+) error {
+	series, err := s.GetSeries(ctx, name)
+	if err != nil {
+		return err
+	}
+	return s.withTx(ctx, func(q *database.Queries) error {
+		_, err := addSeriesStats(ctx, q, series.ID, opened, closed, total, dateExec)
+		return err
+	})
+}
+
+// addSeriesStats adds a snapshot to the series with the given ID and returns the ID of the snapshot.
+func addSeriesStats(
+	ctx context.Context,
+	q *database.Queries,
+	seriesID int64,
+	opened int64,
+	closed int64,
+	total int64,
+	dateExec *carbon.Carbon,
+) (int64, error) {
+	statsID, err := q.InsertNewStats(ctx, database.InsertNewStatsParams{
+		Total:    total,
+		Closed:   closed,
+		Opened:   opened,
+		DateExec: dateExec.StdTime(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert new stats: %w", err)
+	}
+	_, err = q.InsertStatsSeries(ctx, database.InsertStatsSeriesParams{
+		Seriesid: seriesID,
+		Statsid:  statsID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert stats series: %w", err)
+	}
+	return statsID, nil
+}
+
+// GetEnhancedStatsBySeries gets enhanced statistics of the series with the given name.
+func (s *Storage) GetEnhancedStatsBySeries(
+	ctx context.Context,
+	name string,
+	beginDate *carbon.Carbon,
+	endDate *carbon.Carbon,
+) (*EnhancedStats, error) {
+	series, err := s.GetSeries(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.queries.GetEnhancedStatsBySeriesID(ctx, database.GetEnhancedStatsBySeriesIDParams{
+		Seriesid:  series.ID,
+		Begindate: beginDate.StdTime(),
+		Enddate:   endDate.StdTime(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enhanced stats by series: %w", err)
+	}
+	return processEnhancedStatsSeries(stats), nil
+}
+
+func processEnhancedStatsSeries(stats []database.GetEnhancedStatsBySeriesIDRow) *EnhancedStats {
+	count := len(stats)
+	totalOpened := make([]interface{}, count)
+	currentOpened := make([]interface{}, count)
+	currentClosed := make([]interface{}, count)
+	prevTotal := make([]interface{}, count)
+	prevClosed := make([]interface{}, count)
+	dateExec := make([]interface{}, count)
+	reconstructed := make([]interface{}, count)
+
+	for i, stat := range stats {
+		totalOpened[i] = stat.TotalOpened
+		currentOpened[i] = stat.CurrentOpened
+		currentClosed[i] = stat.CurrentClosed
+		prevTotal[i] = stat.PrevTotal
+		prevClosed[i] = stat.PrevClosed
+		dateExec[i] = stat.DateExec
+		reconstructed[i] = stat.Reconstructed
+	}
+
+	return processEnhancedStatsGeneric(totalOpened, currentOpened, currentClosed, prevTotal, prevClosed, dateExec, reconstructed)
+}
@@ -0,0 +1,97 @@
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-module/carbon/v2"
+	"github.com/sgaunet/gitlab-stats/pkg/storage/sqlite"
+)
+
+func newTestStorage(t *testing.T) *sqlite.Storage {
+	t.Helper()
+	s, err := sqlite.NewStorage(filepath.Join(t.TempDir(), "db.sqlite3"))
+	if err != nil {
+		t.Fatalf("err returned by NewStorage(): %v", err.Error())
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(); err != nil {
+		t.Fatalf("err returned by Migrate(): %v", err.Error())
+	}
+	return s
+}
+
+func TestSaveSeries(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	created, err := s.SaveSeries(ctx, "bugs", sqlite.EntityProject, 1, `{"labels":["bug"]}`)
+	if err != nil {
+		t.Fatalf("err returned by SaveSeries(): %v", err.Error())
+	}
+	again, err := s.SaveSeries(ctx, "bugs", sqlite.EntityProject, 1, `{"labels":["bug"]}`)
+	if err != nil {
+		t.Fatalf("err returned by SaveSeries(): %v", err.Error())
+	}
+	if again != created {
+		t.Errorf("SaveSeries() = %+v, want the existing series %+v", again, created)
+	}
+	_, err = s.SaveSeries(ctx, "bugs", sqlite.EntityProject, 1, `{"labels":["bug","p1"]}`)
+	if !errors.Is(err, sqlite.ErrSeriesFilterMismatch) {
+		t.Errorf("SaveSeries() error = %v, want %v", err, sqlite.ErrSeriesFilterMismatch)
+	}
+	got, err := s.GetSeries(ctx, "bugs")
+	if err != nil {
+		t.Fatalf("err returned by GetSeries(): %v", err.Error())
+	}
+	if got.Filter != `{"labels":["bug"]}` {
+		t.Errorf("GetSeries() filter = %s, want the filter of the creation", got.Filter)
+	}
+
+	_, err = s.SaveSeries(ctx, "bugs", sqlite.EntityGroup, 1, `{}`)
+	if !errors.Is(err, sqlite.ErrSeriesEntityMismatch) {
+		t.Errorf("SaveSeries() error = %v, want %v", err, sqlite.ErrSeriesEntityMismatch)
+	}
+	_, err = s.GetSeries(ctx, "unknown")
+	if !errors.Is(err, sqlite.ErrSeriesNotFound) {
+		t.Errorf("GetSeries() error = %v, want %v", err, sqlite.ErrSeriesNotFound)
+	}
+}
+
+func TestSeriesStatsAreKeptApart(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+	key, err := s.ProjectKey(ctx, 0, 1)
+	if err != nil {
+		t.Fatalf("err returned by ProjectKey(): %v", err.Error())
+	}
+	if _, err := s.SaveSeries(ctx, "bugs", sqlite.EntityProject, key, `{"labels":["bug"]}`); err != nil {
+		t.Fatalf("err returned by SaveSeries(): %v", err.Error())
+	}
+
+	now := carbon.Now()
+	if err := s.AddProjectStats(0, 1, 10, 20, 30, now); err != nil {
+		t.Fatalf("err returned by AddProjectStats(): %v", err.Error())
+	}
+	if err := s.AddSeriesStats(ctx, "bugs", 1, 2, 3, now); err != nil {
+		t.Fatalf("err returned by AddSeriesStats(): %v", err.Error())
+	}
+
+	begin, end := carbon.Now().SubMonth(), carbon.Now().AddMonth()
+	series, err := s.GetEnhancedStatsBySeries(ctx, "bugs", begin, end)
+	if err != nil {
+		t.Fatalf("err returned by GetEnhancedStatsBySeries(): %v", err.Error())
+	}
+	if len(series.TotalOpenedSeries) != 1 || series.TotalOpenedSeries[0] != 1 {
+		t.Errorf("GetEnhancedStatsBySeries() opened = %v, want [1]", series.TotalOpenedSeries)
+	}
+	project, err := s.GetEnhancedStatsByProjectID(key, begin, end)
+	if err != nil {
+		t.Fatalf("err returned by GetEnhancedStatsByProjectID(): %v", err.Error())
+	}
+	if len(project.TotalOpenedSeries) != 1 || project.TotalOpenedSeries[0] != 10 {
+		t.Errorf("GetEnhancedStatsByProjectID() opened = %v, want [10]", project.TotalOpenedSeries)
+	}
+}
@@ -5,9 +5,9 @@ import (
 	"context"
 	"database/sql"
 	"embed"
-	"errors"
 	"fmt"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/amacneil/dbmate/v2/pkg/dbmate"
@@ -30,7 +30,8 @@ type Storage struct {
 
 // NewStorage creates a new SQLite storage instance.
 func NewStorage(dbFile string) (*Storage, error) {
-	db, err := sql.Open("sqlite", dbFile)
+	// write timestamps in a format understood by SQLite date functions
+	db, err := sql.Open("sqlite", dbFile+"?_time_format=sqlite")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -57,9 +58,7 @@ func (s *Storage) Close() error {
 
 // Init initializes the database with migrations.
 func (s *Storage) Init() error {
-	u, _ := url.Parse("sqlite://" + s.dbFile)
-	db := dbmate.New(u)
-	db.FS = fs
+	db := s.newMigrator()
 
 	fmt.Println("Migrations:")
 	migrations, err := db.FindMigrations()
@@ -69,7 +68,6 @@ func (s *Storage) Init() error {
 	for _, m := range migrations {
 		fmt.Println(m.Version, m.FilePath)
 	}
-	db.AutoDumpSchema = false
 	err = db.CreateAndMigrate()
 	if err != nil {
 		return fmt.Errorf("failed to create and migrate database: %w", err)
@@ -77,91 +75,187 @@ func (s *Storage) Init() error {
 	return nil
 }
 
+// Migrate applies the pending migrations to an existing database.
+func (s *Storage) Migrate() error {
+	if err := s.newMigrator().CreateAndMigrate(); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) newMigrator() *dbmate.DB {
+	u, _ := url.Parse("sqlite://" + s.dbFile)
+	db := dbmate.New(u)
+	db.FS = fs
+	db.AutoDumpSchema = false
+	return db
+}
+
 // AddProjectStats adds statistics for a project.
 func (s *Storage) AddProjectStats(
-	projectID int64,
+	instanceID int64,
+	remoteID int64,
 	opened int64,
 	closed int64,
 	total int64,
 	dateExec *carbon.Carbon,
 ) error {
-	// Check if project exists
-	_, err := s.queries.GetProject(context.Background(), projectID)
-	if errors.Is(err, sql.ErrNoRows) {
-		// Create project
-		_, err = s.queries.InsertNewProject(context.Background(), database.InsertNewProjectParams{
-			ID:          projectID,
-			ProjectName: "",
-		})
+	return s.AddProjectStatsWithContext(context.Background(), instanceID, remoteID, opened, closed, total, dateExec)
+}
+
+// AddProjectStatsWithContext adds statistics for a project in a single transaction,
+// so that a canceled context never leaves a half-written snapshot.
+// The project is the one of the instance with the given remote ID, created
+// if needed; use 0 as instanceID for the legacy GitLab instance.
+func (s *Storage) AddProjectStatsWithContext(
+	ctx context.Context,
+	instanceID int64,
+	remoteID int64,
+	opened int64,
+	closed int64,
+	total int64,
+	dateExec *carbon.Carbon,
+) error {
+	return s.withTx(ctx, func(q *database.Queries) error {
+		projectKey, err := ensureProject(ctx, q, instanceID, remoteID)
 		if err != nil {
-			return fmt.Errorf("failed to insert new project: %w", err)
+			return err
 		}
+		_, err = addProjectStats(ctx, q, projectKey, opened, closed, total, dateExec)
+		return err
+	})
+}
+
+// addProjectStats adds statistics for the project with the given key and returns their ID.
+func addProjectStats(
+	ctx context.Context,
+	q *database.Queries,
+	projectKey int64,
+	opened int64,
+	closed int64,
+	total int64,
+	dateExec *carbon.Carbon,
+) (int64, error) {
+	if err := checkProject(ctx, q, projectKey); err != nil {
+		return 0, err
 	}
 	// Add Stats
-	statsID, err := s.queries.InsertNewStats(context.Background(), database.InsertNewStatsParams{
+	statsID, err := q.InsertNewStats(ctx, database.InsertNewStatsParams{
 		Total:    total,
 		Closed:   closed,
 		Opened:   opened,
 		DateExec: dateExec.StdTime(),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to insert new stats: %w", err)
+		return 0, fmt.Errorf("failed to insert new stats: %w", err)
 	}
-	_, err = s.queries.InsertStatsProjects(context.Background(), database.InsertStatsProjectsParams{
+	_, err = q.InsertStatsProjects(ctx, database.InsertStatsProjectsParams{
 		Statsid:   statsID,
-		Projectid: projectID,
+		Projectid: projectKey,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to insert stats projects: %w", err)
+		return 0, fmt.Errorf("failed to insert stats projects: %w", err)
 	}
-	return nil
+	return statsID, nil
 }
 
 // AddGroupStats adds statistics for a group.
-func (s *Storage) AddGroupStats(groupID int64, opened int64, closed int64, total int64, dateExec *carbon.Carbon) error {
-	// Check if group exists
-	group, err := s.queries.GetGroup(context.Background(), groupID)
-	if errors.Is(err, sql.ErrNoRows) {
-		// Create group
-		groupID, err = s.queries.InsertNewGroup(context.Background(), database.InsertNewGroupParams{
-			ID:        groupID,
-			GroupName: "",
-		})
+func (s *Storage) AddGroupStats(
+	instanceID int64,
+	remoteID int64,
+	opened int64,
+	closed int64,
+	total int64,
+	dateExec *carbon.Carbon,
+) error {
+	return s.AddGroupStatsWithContext(context.Background(), instanceID, remoteID, opened, closed, total, dateExec)
+}
+
+// AddGroupStatsWithContext adds statistics for a group in a single transaction,
+// so that a canceled context never leaves a half-written snapshot.
+// The group is the one of the instance with the given remote ID, created
+// if needed; use 0 as instanceID for the legacy GitLab instance.
+func (s *Storage) AddGroupStatsWithContext(
+	ctx context.Context,
+	instanceID int64,
+	remoteID int64,
+	opened int64,
+	closed int64,
+	total int64,
+	dateExec *carbon.Carbon,
+) error {
+	return s.withTx(ctx, func(q *database.Queries) error {
+		groupKey, err := ensureGroup(ctx, q, instanceID, remoteID)
 		if err != nil {
-			return fmt.Errorf("failed to insert new group: %w", err)
+			return err
 		}
-	} else {
-		groupID = group.ID
+		_, err = addGroupStats(ctx, q, groupKey, opened, closed, total, dateExec)
+		return err
+	})
+}
+
+// addGroupStats adds statistics for the group with the given key and returns their ID.
+func addGroupStats(
+	ctx context.Context,
+	q *database.Queries,
+	groupKey int64,
+	opened int64,
+	closed int64,
+	total int64,
+	dateExec *carbon.Carbon,
+) (int64, error) {
+	if err := checkGroup(ctx, q, groupKey); err != nil {
+		return 0, err
 	}
 	// Add Stats
-	statsID, err := s.queries.InsertNewStats(context.Background(), database.InsertNewStatsParams{
+	statsID, err := q.InsertNewStats(ctx, database.InsertNewStatsParams{
 		Total:    total,
 		Closed:   closed,
 		Opened:   opened,
 		DateExec: dateExec.StdTime(),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to insert new stats: %w", err)
+		return 0, fmt.Errorf("failed to insert new stats: %w", err)
 	}
-	_, err = s.queries.InsertStatsGroups(context.Background(), database.InsertStatsGroupsParams{
+	_, err = q.InsertStatsGroups(ctx, database.InsertStatsGroupsParams{
 		Statsid: statsID,
-		Groupid: groupID,
+		Groupid: groupKey,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to insert stats groups: %w", err)
+		return 0, fmt.Errorf("failed to insert stats groups: %w", err)
+	}
+	return statsID, nil
+}
+
+// withTx runs fn in a transaction, committed only if fn succeeds and the context is not done.
+func (s *Storage) withTx(ctx context.Context, fn func(q *database.Queries) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(s.queries.WithTx(tx)); err != nil {
+		_ = tx.Rollback() // ignore error
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		_ = tx.Rollback() // ignore error
+		return fmt.Errorf("transaction canceled: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 	return nil
 }
 
-// GetStatsByProjectID6Months gets project statistics for the last 6 months.
+// GetStatsByProjectID6Months gets statistics of the project with the given key for the last 6 months.
 func (s *Storage) GetStatsByProjectID6Months(
-	projectID int64,
+	projectKey int64,
 	beginDate *carbon.Carbon,
 	endDate *carbon.Carbon,
 ) ([]float64, []float64, []time.Time, error) {
 	// Get project stats
 	stats, err := s.queries.GetStatsByProjectID6Months(context.Background(), database.GetStatsByProjectID6MonthsParams{
-		Projectid: projectID,
+		Projectid: projectKey,
 		Begindate: beginDate.StdTime(),
 		Enddate:   endDate.StdTime(),
 	})
@@ -172,15 +266,15 @@ func (s *Storage) GetStatsByProjectID6Months(
 	return openedSerie, closedSerie, dateExecSerie, nil
 }
 
-// GetStatsByGroupID6Months gets group statistics for the last 6 months.
+// GetStatsByGroupID6Months gets statistics of the group with the given key for the last 6 months.
 func (s *Storage) GetStatsByGroupID6Months(
-	groupID int64,
+	groupKey int64,
 	beginDate *carbon.Carbon,
 	endDate *carbon.Carbon,
 ) ([]float64, []float64, []time.Time, error) {
 	// Get group stats
 	stats, err := s.queries.GetStatsByGroupID6Months(context.Background(), database.GetStatsByGroupID6MonthsParams{
-		Groupid:   groupID,
+		Groupid:   groupKey,
 		Begindate: beginDate.StdTime(),
 		Enddate:   endDate.StdTime(),
 	})
@@ -222,16 +316,20 @@ type EnhancedStats struct {
 	ClosedDuringPeriod     []float64
 	VelocitySeries         []float64
 	DateExecSeries         []time.Time
+	// ReconstructedSeries tells which periods include snapshots rebuilt by
+	// -backfill from the history of the issues.
+	ReconstructedSeries    []bool
 }
 
-// GetEnhancedStatsByProjectID gets enhanced project statistics with velocity calculations.
+// GetEnhancedStatsByProjectID gets enhanced statistics of the project with the
+// given key with velocity calculations.
 func (s *Storage) GetEnhancedStatsByProjectID(
-	projectID int64,
+	projectKey int64,
 	beginDate *carbon.Carbon,
 	endDate *carbon.Carbon,
 ) (*EnhancedStats, error) {
 	stats, err := s.queries.GetEnhancedStatsByProjectID(context.Background(), database.GetEnhancedStatsByProjectIDParams{
-		Projectid: projectID,
+		Projectid: projectKey,
 		Begindate: beginDate.StdTime(),
 		Enddate:   endDate.StdTime(),
 	})
@@ -241,14 +339,15 @@ func (s *Storage) GetEnhancedStatsByProjectID(
 	return processEnhancedStats(stats), nil
 }
 
-// GetEnhancedStatsByGroupID gets enhanced group statistics with velocity calculations.
+// GetEnhancedStatsByGroupID gets enhanced statistics of the group with the
+// given key with velocity calculations.
 func (s *Storage) GetEnhancedStatsByGroupID(
-	groupID int64,
+	groupKey int64,
 	beginDate *carbon.Carbon,
 	endDate *carbon.Carbon,
 ) (*EnhancedStats, error) {
 	stats, err := s.queries.GetEnhancedStatsByGroupID(context.Background(), database.GetEnhancedStatsByGroupIDParams{
-		Groupid:   groupID,
+		Groupid:   groupKey,
 		Begindate: beginDate.StdTime(),
 		Enddate:   endDate.StdTime(),
 	})
@@ -261,6 +360,7 @@ func (s *Storage) GetEnhancedStatsByGroupID(
 func processEnhancedStatsGeneric(
 	totalOpened, currentOpened, currentClosed, prevTotal, prevClosed []interface{},
 	dateExec []interface{},
+	reconstructed []interface{},
 ) *EnhancedStats {
 	count := len(totalOpened)
 	totalOpenedSeries := make([]float64, 0, count)
@@ -268,6 +368,7 @@ func processEnhancedStatsGeneric(
 	closedDuringPeriod := make([]float64, 0, count)
 	velocitySeries := make([]float64, 0, count)
 	dateExecSeries := make([]time.Time, 0, count)
+	reconstructedSeries := make([]bool, 0, count)
 
 	for i := range totalOpened {
 		// Check date validity first - skip entire data point if date is invalid
@@ -298,6 +399,7 @@ func processEnhancedStatsGeneric(
 		closedDuringPeriod = append(closedDuringPeriod, float64(closedInPeriod))
 		velocitySeries = append(velocitySeries, float64(velocity))
 		dateExecSeries = append(dateExecSeries, dateTime.UTC())
+		reconstructedSeries = append(reconstructedSeries, convertToInt64(reconstructed[i]) != 0)
 	}
 
 	return &EnhancedStats{
@@ -306,6 +408,7 @@ func processEnhancedStatsGeneric(
 		ClosedDuringPeriod:     closedDuringPeriod,
 		VelocitySeries:         velocitySeries,
 		DateExecSeries:         dateExecSeries,
+		ReconstructedSeries:    reconstructedSeries,
 	}
 }
 
@@ -317,6 +420,7 @@ func processEnhancedStats(stats []database.GetEnhancedStatsByProjectIDRow) *Enha
 	prevTotal := make([]interface{}, count)
 	prevClosed := make([]interface{}, count)
 	dateExec := make([]interface{}, count)
+	reconstructed := make([]interface{}, count)
 	
 	for i, stat := range stats {
 		totalOpened[i] = stat.TotalOpened
@@ -325,9 +429,10 @@ func processEnhancedStats(stats []database.GetEnhancedStatsByProjectIDRow) *Enha
 		prevTotal[i] = stat.PrevTotal
 		prevClosed[i] = stat.PrevClosed
 		dateExec[i] = stat.DateExec
+		reconstructed[i] = stat.Reconstructed
 	}
 	
-	return processEnhancedStatsGeneric(totalOpened, currentOpened, currentClosed, prevTotal, prevClosed, dateExec)
+	return processEnhancedStatsGeneric(totalOpened, currentOpened, currentClosed, prevTotal, prevClosed, dateExec, reconstructed)
 }
 
 func processEnhancedStatsGroup(stats []database.GetEnhancedStatsByGroupIDRow) *EnhancedStats {
@@ -338,6 +443,7 @@ func processEnhancedStatsGroup(stats []database.GetEnhancedStatsByGroupIDRow) *E
 	prevTotal := make([]interface{}, count)
 	prevClosed := make([]interface{}, count)
 	dateExec := make([]interface{}, count)
+	reconstructed := make([]interface{}, count)
 	
 	for i, stat := range stats {
 		totalOpened[i] = stat.TotalOpened
@@ -346,9 +452,10 @@ func processEnhancedStatsGroup(stats []database.GetEnhancedStatsByGroupIDRow) *E
 		prevTotal[i] = stat.PrevTotal
 		prevClosed[i] = stat.PrevClosed
 		dateExec[i] = stat.DateExec
+		reconstructed[i] = stat.Reconstructed
 	}
 	
-	return processEnhancedStatsGeneric(totalOpened, currentOpened, currentClosed, prevTotal, prevClosed, dateExec)
+	return processEnhancedStatsGeneric(totalOpened, currentOpened, currentClosed, prevTotal, prevClosed, dateExec, reconstructed)
 }
 
 func convertToInt64(val interface{}) int64 {
@@ -375,8 +482,15 @@ func convertToTime(val interface{}) time.Time {
 	case time.Time:
 		return v
 	case string:
+		// modernc.org/sqlite stores time.Time with its String() format,
+		// which may end with the monotonic clock reading ("m=+0.001")
+		if i := strings.Index(v, " m="); i >= 0 {
+			v = v[:i]
+		}
 		// Try to parse common SQLite datetime formats
 		formats := []string{
+			"2006-01-02 15:04:05.999999999-07:00",
+			"2006-01-02 15:04:05.999999999 -0700 MST",
 			"2006-01-02 15:04:05-07:00",
 			"2006-01-02 15:04:05+00:00",
 			"2006-01-02 15:04:05",
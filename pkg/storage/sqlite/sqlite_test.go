@@ -1,9 +1,12 @@
 package sqlite_test
 
 import (
+	"context"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/golang-module/carbon/v2"
 	_ "modernc.org/sqlite"
 	"github.com/sgaunet/gitlab-stats/pkg/storage/sqlite"
 )
@@ -39,3 +42,28 @@ func TestInit(t *testing.T) {
 	// delete db file
 	os.Remove("/tmp/db.sqlite3")
 }
+
+func TestAddProjectStatsWithCanceledContext(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "db.sqlite3")
+	s, _ := sqlite.NewStorage(dbFile)
+	defer s.Close()
+	if err := s.Init(); err != nil {
+		t.Fatalf("err returned by Init(): %v", err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	now := carbon.Now()
+	err := s.AddProjectStatsWithContext(ctx, 0, 1, 2, 3, 5, now)
+	if err == nil {
+		t.Errorf("AddProjectStatsWithContext() should return an error")
+	}
+
+	stats, err := s.GetEnhancedStatsByProjectID(1, carbon.Now().SubMonth(), carbon.Now().AddMonth())
+	if err != nil {
+		t.Fatalf("err returned by GetEnhancedStatsByProjectID(): %v", err.Error())
+	}
+	if len(stats.DateExecSeries) != 0 {
+		t.Errorf("no snapshot should be written, got %d", len(stats.DateExecSeries))
+	}
+}